@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"restapi/internal/config"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// Форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// cli - флаги и окружение команды, работающей с базой данных напрямую.
+// Принимает те же флаги конфигурации, что и serve
+type cli struct {
+	fs     *flag.FlagSet
+	config *config.Flags
+	output *string
+	// args - позиционные аргументы после разбора
+	args []string
+}
+
+// newCLI создает команду с флагом -output и флагами конфигурации.
+// usage - вызов команды для справки, например "tasks get [flags] <id>"
+func newCLI(usage string) *cli {
+	fs := flag.NewFlagSet("restapi "+usage, flag.ContinueOnError)
+	c := &cli{
+		fs:     fs,
+		output: fs.String("output", outputTable, "output format: table or json"),
+	}
+	c.config = config.AddFlags(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: restapi %s\n\nFlags:\n", usage)
+
+		// Флаги параметров конфигурации те же, что у serve, и в справке не повторяются
+		own := flag.NewFlagSet(usage, flag.ContinueOnError)
+		own.SetOutput(fs.Output())
+		fs.VisitAll(func(f *flag.Flag) {
+			if !c.config.IsParam(f.Name) {
+				own.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+		own.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nConfiguration flags are the same as for restapi serve.")
+	}
+
+	return c
+}
+
+// parse разбирает флаги, в том числе заданные после позиционных аргументов
+func (c *cli) parse(args []string) error {
+	for {
+		if err := c.fs.Parse(args); err != nil {
+			return err
+		}
+		if c.fs.NArg() == 0 {
+			break
+		}
+		c.args = append(c.args, c.fs.Arg(0))
+		args = c.fs.Args()[1:]
+	}
+
+	if *c.output != outputTable && *c.output != outputJSON {
+		return &usageError{msg: fmt.Sprintf("unknown output format %q, use table or json", *c.output)}
+	}
+
+	return nil
+}
+
+// connect загружает конфигурацию и подключается к базе. Сообщения уровнем ниже level
+// не выводятся. Возвращаемая функция закрывает подключение
+func (c *cli) connect(ctx context.Context, level zapcore.Level) (*db.DBrepository, func(), error) {
+	cfg, err := c.config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log := logger.NewConsole(level)
+
+	repo, err := db.NewRepo(ctx, log, *cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating repository")
+	}
+
+	return repo, func() {
+		repo.Close()
+		_ = logger.Sync(log)
+	}, nil
+}
+
+// commandContext - контекст команды, отменяемый по SIGINT и SIGTERM
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// writeJSON выводит v в w с отступами
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
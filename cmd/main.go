@@ -1,57 +1,105 @@
 package main
 
 import (
-	"context"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
-	"restapi/internal/api"
 	"restapi/internal/config"
-	"restapi/internal/logger"
-	"restapi/internal/repo/db"
-	"restapi/internal/service"
-	"syscall"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// command - команда restapi
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands - команды в порядке вывода в справке
+var commands = []command{
+	{"serve", "run the API server (default)", serve},
+	{"migrate", "apply database migrations", migrate},
+	{"tasks", "list|get|create|update|delete tasks", tasks},
+	{"export", "export tasks as JSON", exportTasks},
+	{"import", "import tasks from JSON", importTasks},
+	{"config", "print the effective configuration", configCommand},
+	{"users", "list|create|delete API users", users},
+	{"apikeys", "list|create|revoke API keys of users", apikeys},
+}
+
+// usageError - неверный вызов команды
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
 func main() {
-	// Загрузка конфигурации
-	cfg := config.NewConfig()
+	// restapi [флаги] запускает API, как и restapi serve [флаги]
+	args := os.Args[1:]
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Инициализация логгера
-	log, err := logger.NewLogger(cfg.LogLevel)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "error creating logger"))
+	if name == "help" {
+		printUsage()
+		return
 	}
 
-	log.Info("Config: ", cfg)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(exitCode(cmd.run(args)))
+		}
+	}
 
-	// Инициализация репозитория
-	repo, err := db.NewRepo(context.Background(), log, *cfg)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "error creating repository"))
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+// exitCode печатает ошибку команды и возвращает код завершения:
+// 2 - неверный вызов или конфигурация, 1 - ошибка выполнения
+func exitCode(err error) int {
+	var (
+		usageErr  *usageError
+		configErr config.Errors
+	)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr), errors.As(err, &configErr):
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+}
 
-	// repo, err := memory.NewRepo(context.Background(), log, *cfg) // Для подключения репозитория in-memory
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: restapi <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nrestapi <command> -h prints the command flags")
+}
 
-	// Инициализация сервиса
-	service := service.NewService(log, repo)
+// configCommand - restapi config print [флаги]
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return &usageError{msg: "usage: restapi config print [flags]"}
+	}
 
-	// Инициализация API
-	app := api.NewRouters(&api.Routers{Service: service}, cfg.Rest.Token, log)
+	cfg, err := config.Load(args[1:])
+	if err != nil {
+		return err
+	}
 
-	// Запуск сервера в горутине
-	go func() {
-		log.Info("Server started on port: ", cfg.Rest.ListenPort)
-		if err := app.Listen(":" + cfg.Rest.ListenPort); err != nil {
-			log.Fatal(errors.Wrap(err, "error starting server"))
-		}
-	}()
-
-	// Ожидание завершения
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
-	<-signalCh
-	app.Shutdown()
-	log.Info("Shutting down server...")
-}
\ No newline at end of file
+	return config.Print(os.Stdout, cfg)
+}
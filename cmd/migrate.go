@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// migrate - restapi migrate, применение миграций без запуска API.
+// serve применяет их и сам, команда нужна для отдельного шага развертывания
+func migrate(args []string) error {
+	c := newCLI("migrate [flags]")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi migrate [flags]"}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	// Уровень info, чтобы были видны примененные миграции
+	repo, closeRepo, err := c.connect(ctx, zapcore.InfoLevel)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	if err := repo.Migrate(ctx); err != nil {
+		return errors.Wrap(err, "error applying migrations")
+	}
+
+	fmt.Fprintln(os.Stderr, "Database schema is up to date")
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"restapi/internal/api"
+	"restapi/internal/auth"
+	"restapi/internal/board"
+	"restapi/internal/config"
+	"restapi/internal/gql"
+	"restapi/internal/health"
+	"restapi/internal/lifecycle"
+	"restapi/internal/logger"
+	"restapi/internal/metrics"
+	"restapi/internal/outbox"
+	"restapi/internal/ratelimit"
+	"restapi/internal/recurrence"
+	"restapi/internal/reminder"
+	"restapi/internal/repo/db"
+	"restapi/internal/rpc"
+	"restapi/internal/service"
+	"restapi/internal/stream"
+	"restapi/internal/tlsconfig"
+	"restapi/internal/tracing"
+	"restapi/internal/webhook"
+	"restapi/internal/worklog"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+)
+
+// serve запускает HTTP и gRPC API с фоновыми обработчиками до сигнала остановки.
+// Ошибка запуска или работы возвращается и пишется в лог, код завершения - 1
+func serve(args []string) (err error) {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	// Инициализация логгера
+	log, logLevel, err := logger.NewLogger(cfg.Log)
+	if err != nil {
+		return errors.Wrap(err, "error creating logger")
+	}
+	defer func() {
+		if err != nil {
+			log.Error(err)
+		}
+		_ = logger.Sync(log)
+	}()
+
+	log.Infow("Config loaded", "config", cfg.Redacted())
+
+	// Запуск и остановка компонентов
+	lc := lifecycle.New(log, cfg.Shutdown)
+
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return errors.Wrap(err, "error setting up tracing")
+	}
+	lc.OnStop(lifecycle.PhaseResources, "tracing", shutdownTracing)
+
+	// Инициализация репозитория
+	repo, err := db.NewRepo(context.Background(), log, *cfg)
+	if err != nil {
+		return errors.Wrap(err, "error creating repository")
+	}
+
+	lc.OnStop(lifecycle.PhaseResources, "database", func(context.Context) error {
+		repo.Close()
+		return nil
+	})
+
+	// Метрики пула соединений
+	if err := metrics.RegisterPool(repo.Stat); err != nil {
+		return errors.Wrap(err, "error registering pool metrics")
+	}
+
+	// repo, err := memory.NewRepo(context.Background(), log, *cfg) // Для подключения репозитория in-memory
+
+	// Применение миграций
+	if err := repo.Migrate(context.Background()); err != nil {
+		return errors.Wrap(err, "error applying migrations")
+	}
+
+	// Запуск доставки вебхуков
+	dispatcher := webhook.NewDispatcher(log, repo, cfg.Webhook)
+	lc.Go("webhook_dispatcher", dispatcher.Run)
+
+	// Запуск публикации событий из outbox
+	publishers, err := newPublishers(log, cfg.Outbox, dispatcher)
+	if err != nil {
+		return errors.Wrap(err, "error creating outbox publishers")
+	}
+	relay := outbox.NewRelay(log, repo, publishers, cfg.Outbox)
+	lc.Go("outbox_relay", relay.Run)
+
+	// Запуск создания повторяющихся задач
+	scheduler := recurrence.NewScheduler(log, repo, cfg.Recurrence)
+	lc.Go("recurrence_scheduler", scheduler.Run)
+
+	// Запуск отправки напоминаний
+	reminders := reminder.NewDispatcher(log, repo, reminder.Notifiers(log, cfg.Reminder), cfg.Reminder)
+	lc.Go("reminder_dispatcher", reminders.Run)
+
+	// Запуск раздачи событий подписчикам потока. Поток закрывается до остановки
+	// HTTP-сервера, иначе открытые SSE и WebSocket задержат его до SHUTDOWN_TIMEOUT
+	hub := stream.NewHub(log, repo, cfg.Stream)
+	streamCtx, stopStreams := context.WithCancel(context.Background())
+	lc.Go("event_stream", func(context.Context) { hub.Run(streamCtx) })
+	lc.OnStop(lifecycle.PhaseServers, "event_stream", func(context.Context) error {
+		stopStreams()
+		return nil
+	})
+
+	// Ограничение запросов
+	limiter, err := newRateLimiter(log, cfg.RateLimit, repo)
+	if err != nil {
+		return errors.Wrap(err, "error creating rate limiter")
+	}
+	if limiter != nil {
+		lc.Go("rate_limit_cleanup", limiter.Run)
+	}
+
+	// Проверки состояния
+	checker := health.NewChecker(log, cfg.Health)
+	checker.AddReadiness("database", repo.Ping)
+	checker.AddReadiness("migrations", repo.CheckMigrations)
+	checker.AddReadiness("event_stream", hub.Check)
+	checker.AddLiveness("webhook_dispatcher", dispatcher.Check)
+	checker.AddLiveness("outbox_relay", relay.Check)
+	checker.AddLiveness("recurrence_scheduler", scheduler.Check)
+	checker.AddLiveness("reminder_dispatcher", reminders.Check)
+
+	// Инициализация сервиса
+	service := service.WithTracing(service.NewService(repo))
+
+	// Перезагрузка конфигурации по SIGHUP и при изменении файла
+	reloader, err := config.NewReloader(log, args, cfg)
+	if err != nil {
+		return errors.Wrap(err, "error creating config reloader")
+	}
+	reloader.OnReload(func(old, new *config.AppConfig) error {
+		// Уровень, заданный через /v1/admin/log-level, сохраняется, пока не изменится LOG_LEVEL
+		if old.Log.Level == new.Log.Level {
+			return nil
+		}
+		level, err := zapcore.ParseLevel(new.Log.Level)
+		if err != nil {
+			return errors.Wrap(err, "error applying log level")
+		}
+		logLevel.SetLevel(level)
+		return nil
+	})
+	if limiter != nil {
+		reloader.OnReload(func(old, new *config.AppConfig) error {
+			return errors.Wrap(limiter.SetLimits(new.RateLimit), "error applying rate limits")
+		})
+	}
+	lc.Go("config_reloader", reloader.Run)
+
+	// Авторизация по TOKEN из действующей конфигурации или ключу API пользователя
+	rest := func() config.Rest { return reloader.Current().Rest }
+	authenticator := auth.NewAuthenticator(func() string { return rest().Token }, repo)
+
+	// Инициализация API
+	routers := &api.Routers{
+		Service:    service,
+		Webhook:    webhook.NewService(log, repo),
+		Recurrence: recurrence.NewService(log, repo),
+		Reminder:   reminder.NewService(log, repo),
+		Worklog:    worklog.NewService(log, repo),
+		Board:      board.NewService(log, repo),
+		Stream:     stream.NewService(log, hub),
+		GraphQL:    gql.NewService(log, service),
+		Health:     checker,
+		Auth:       authenticator,
+		RateLimit:  limiter,
+		LogLevel:   logLevel,
+	}
+	app, err := api.NewRouters(routers, rest, log)
+	if err != nil {
+		return errors.Wrap(err, "error creating routers")
+	}
+
+	// Запуск сервера в горутине, с TLS_CERT_FILE - по HTTPS
+	ln, err := net.Listen("tcp", ":"+cfg.Rest.ListenPort)
+	if err != nil {
+		return errors.Wrap(err, "error listening port")
+	}
+	if cfg.Rest.TLS.CertFile != "" {
+		cert, err := tlsconfig.NewCertificate(log, cfg.Rest.TLS)
+		if err != nil {
+			return errors.Wrap(err, "error loading TLS certificate")
+		}
+		lc.Go("tls_certificate", cert.Run)
+
+		tlsCfg, err := tlsconfig.Config(cert, cfg.Rest.TLS)
+		if err != nil {
+			return errors.Wrap(err, "error configuring TLS")
+		}
+		ln = tls.NewListener(ln, tlsCfg)
+	}
+	go func() {
+		log.Info("Server started on port: ", cfg.Rest.ListenPort)
+		if err := app.Listener(ln); err != nil {
+			lc.Fail(errors.Wrap(err, "error serving HTTP"))
+		}
+	}()
+	lc.OnStop(lifecycle.PhaseServers, "http", func(context.Context) error {
+		return app.ShutdownWithTimeout(cfg.Shutdown.Timeout)
+	})
+
+	// Запуск gRPC API на отдельном порту
+	grpcServer := rpc.NewServer(log, service, hub, authenticator)
+	lis, err := net.Listen("tcp", ":"+cfg.Grpc.ListenPort)
+	if err != nil {
+		return errors.Wrap(err, "error listening gRPC port")
+	}
+	go func() {
+		log.Info("gRPC server started on port: ", cfg.Grpc.ListenPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			lc.Fail(errors.Wrap(err, "error serving gRPC"))
+		}
+	}()
+	lc.OnStop(lifecycle.PhaseServers, "grpc", func(ctx context.Context) error {
+		return stopGRPC(ctx, grpcServer)
+	})
+
+	// Снятие с балансировки: /readyz отвечает 503 в течение SHUTDOWN_DRAIN_PERIOD
+	lc.OnStop(lifecycle.PhaseDrain, "readiness", func(context.Context) error {
+		checker.Shutdown()
+		return nil
+	})
+
+	// Ожидание сигнала и остановка по этапам
+	failure := lc.Wait()
+	shutdownErr := lc.Shutdown()
+	log.Info("Server stopped")
+
+	if failure != nil {
+		return failure
+	}
+
+	return shutdownErr
+}
+
+// stopGRPC дожидается завершения текущих вызовов, а по истечении ctx обрывает их
+func stopGRPC(ctx context.Context, server *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return errors.New("gRPC calls did not finish in time")
+	}
+}
+
+// newPublishers собирает получателей событий outbox из конфигурации
+func newPublishers(log *zap.SugaredLogger, cfg config.Outbox, dispatcher *webhook.Dispatcher) (outbox.Publishers, error) {
+	publishers := make(outbox.Publishers, 0, len(cfg.Publishers))
+	for _, name := range cfg.Publishers {
+		switch name {
+		case "log":
+			publishers = append(publishers, outbox.NewLogPublisher(log))
+		case "webhook":
+			publishers = append(publishers, dispatcher)
+		case "kafka":
+			producer := outbox.NewKafkaRESTProducer(cfg.KafkaRESTURL)
+			publishers = append(publishers, outbox.NewBrokerPublisher(producer, cfg.KafkaTopicPrefix))
+		default:
+			return nil, errors.Errorf("unknown outbox publisher %q", name)
+		}
+	}
+
+	return publishers, nil
+}
+
+// newRateLimiter создает ограничитель запросов с хранилищем из RATE_LIMIT_STORE,
+// nil - ограничение выключено
+func newRateLimiter(log *zap.SugaredLogger, cfg config.RateLimit, repo db.RateLimitRepository) (*ratelimit.Limiter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var store ratelimit.Store
+	switch cfg.Store {
+	case ratelimit.StoreMemory:
+		store = ratelimit.NewMemoryStore()
+	case ratelimit.StorePostgres:
+		store = ratelimit.NewPostgresStore(repo)
+	default:
+		return nil, errors.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	return ratelimit.NewLimiter(log, store, cfg)
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"restapi/internal/service"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// listLimit - размер страницы tasks list по умолчанию
+	listLimit int = 20
+	// descriptionWidth - наибольшая длина описания в таблице
+	descriptionWidth int = 40
+)
+
+// tasks - restapi tasks list|get|create|update|delete
+func tasks(args []string) error {
+	if len(args) == 0 {
+		return &usageError{msg: "usage: restapi tasks list|get|create|update|delete [flags]"}
+	}
+
+	switch args[0] {
+	case "list":
+		return listTasks(args[1:])
+	case "get":
+		return getTask(args[1:])
+	case "create":
+		return createTask(args[1:])
+	case "update":
+		return updateTask(args[1:])
+	case "delete":
+		return deleteTask(args[1:])
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown tasks command %q, use list, get, create, update or delete", args[0])}
+	}
+}
+
+// listTasks - restapi tasks list, страница задач с фильтром по статусу
+func listTasks(args []string) error {
+	c := newCLI("tasks list [flags]")
+	page := c.fs.Int("page", 1, "page number, starting from 1")
+	limit := c.fs.Int("limit", listLimit, "tasks per page, up to 100")
+	status := c.fs.String("status", "", "comma-separated statuses: new, in_progress, done")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi tasks list [flags]"}
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		input := service.ListTasksInput{
+			Page:  *page,
+			Limit: *limit,
+		}
+		if *status != "" {
+			input.Statuses = strings.Split(*status, ",")
+		}
+
+		list, err := svc.ListTasks(ctx, input)
+		if err != nil {
+			return taskError(err, 0)
+		}
+
+		return c.printTasks(list...)
+	})
+}
+
+// getTask - restapi tasks get <id>
+func getTask(args []string) error {
+	c := newCLI("tasks get [flags] <id>")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	id, err := c.taskID()
+	if err != nil {
+		return err
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		task, err := svc.GetTask(ctx, id)
+		if err != nil {
+			return taskError(err, id)
+		}
+
+		return c.printTasks(task)
+	})
+}
+
+// createTask - restapi tasks create, с теми же правилами, что POST /v1/tasks
+func createTask(args []string) error {
+	c := newCLI("tasks create [flags]")
+	title := c.fs.String("title", "", "task title")
+	description := c.fs.String("description", "", "task description")
+	status := c.fs.String("status", "new", "task status")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi tasks create [flags]"}
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		task, err := svc.CreateTask(ctx, service.CreateTaskInput{
+			Title:       *title,
+			Description: *description,
+			Status:      *status,
+		})
+		if err != nil {
+			return taskError(err, 0)
+		}
+
+		return c.printTasks(task)
+	})
+}
+
+// updateTask - restapi tasks update <id>. Незаданные флаги сохраняют текущие значения,
+// итог проверяется по тем же правилам, что PUT /v1/tasks/:id
+func updateTask(args []string) error {
+	c := newCLI("tasks update [flags] <id>")
+	title := c.fs.String("title", "", "new task title")
+	description := c.fs.String("description", "", "new task description")
+	status := c.fs.String("status", "", "new task status: new, in_progress or done")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	id, err := c.taskID()
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	c.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		current, err := svc.GetTask(ctx, id)
+		if err != nil {
+			return taskError(err, id)
+		}
+
+		input := service.UpdateTaskInput{
+			Title:       current.Title,
+			Description: current.Description,
+			Status:      current.Status,
+		}
+		if set["title"] {
+			input.Title = *title
+		}
+		if set["description"] {
+			input.Description = *description
+		}
+		if set["status"] {
+			input.Status = *status
+		}
+
+		task, err := svc.UpdateTask(ctx, id, input)
+		if err != nil {
+			return taskError(err, id)
+		}
+
+		return c.printTasks(task)
+	})
+}
+
+// deleteTask - restapi tasks delete <id>
+func deleteTask(args []string) error {
+	c := newCLI("tasks delete [flags] <id>")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	id, err := c.taskID()
+	if err != nil {
+		return err
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		if err := svc.DeleteTask(ctx, id); err != nil {
+			return taskError(err, id)
+		}
+
+		if *c.output == outputJSON {
+			return writeJSON(os.Stdout, map[string]any{"id": id, "deleted": true})
+		}
+		fmt.Printf("Task %d deleted\n", id)
+
+		return nil
+	})
+}
+
+// withService подключается к базе и выполняет fn с сервисом задач
+func (c *cli) withService(fn func(ctx context.Context, svc service.TaskService) error) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	// Ошибки репозитория возвращаются командой и в журнале не повторяются
+	repo, closeRepo, err := c.connect(ctx, zapcore.DPanicLevel)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	return fn(ctx, service.NewService(repo))
+}
+
+// taskID - единственный позиционный аргумент, id задачи
+func (c *cli) taskID() (int64, error) {
+	if len(c.args) != 1 {
+		return 0, &usageError{msg: "expected exactly one task id"}
+	}
+
+	id, err := strconv.ParseInt(c.args[0], 10, 64)
+	if err != nil || id < 1 {
+		return 0, &usageError{msg: fmt.Sprintf("invalid task id %q", c.args[0])}
+	}
+
+	return id, nil
+}
+
+// printTasks выводит задачи таблицей или JSON-массивом
+func (c *cli) printTasks(list ...service.Task) error {
+	if *c.output == outputJSON {
+		if list == nil {
+			list = []service.Task{}
+		}
+		return writeJSON(os.Stdout, list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tTITLE\tDESCRIPTION\tUPDATED")
+	for _, task := range list {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			task.ID, task.Status, task.Title, truncate(task.Description, descriptionWidth),
+			task.UpdatedAt.Local().Format(time.DateTime))
+	}
+
+	return w.Flush()
+}
+
+// taskError - ошибка сервиса задач в виде для командной строки
+func taskError(err error, id int64) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return errors.Wrap(validationErr, "invalid task")
+	case errors.Is(err, service.ErrNotFound):
+		return errors.Errorf("task %d not found", id)
+	default:
+		return err
+	}
+}
+
+// truncate обрезает s до width символов, переводы строк заменяются пробелами
+func truncate(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+
+	return string([]rune(s)[:width-1]) + "…"
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"restapi/internal/service"
+	"restapi/pkg/validator"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exportPage - сколько задач читается за один запрос при выгрузке
+const exportPage int = 100
+
+// exportTasks - restapi export, выгрузка всех задач JSON-массивом в формате tasks get -output json
+func exportTasks(args []string) error {
+	c := newCLI("export [flags]")
+	file := c.fs.String("file", "-", "output file, - for stdout")
+	status := c.fs.String("status", "", "comma-separated statuses to export, empty for all")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi export [flags]"}
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		input := service.ListTasksInput{Limit: exportPage}
+		if *status != "" {
+			input.Statuses = strings.Split(*status, ",")
+		}
+
+		all := []service.Task{}
+		for input.Page = 1; ; input.Page++ {
+			page, err := svc.ListTasks(ctx, input)
+			if err != nil {
+				return taskError(err, 0)
+			}
+			all = append(all, page...)
+			if len(page) < exportPage {
+				break
+			}
+		}
+
+		if *file == "-" {
+			return writeJSON(os.Stdout, all)
+		}
+
+		f, err := os.Create(*file)
+		if err != nil {
+			return errors.Wrap(err, "error creating export file")
+		}
+		if err := writeJSON(f, all); err != nil {
+			_ = f.Close()
+			return errors.Wrap(err, "error writing export file")
+		}
+		if err := f.Close(); err != nil {
+			return errors.Wrap(err, "error writing export file")
+		}
+
+		fmt.Fprintf(os.Stderr, "Exported %d tasks to %s\n", len(all), *file)
+
+		return nil
+	})
+}
+
+// importTasks - restapi import, создание задач из JSON-массива. Принимает вывод export,
+// id и даты не переносятся: задачи создаются заново. Все задачи проверяются
+// по правилам POST /v1/tasks до создания первой из них
+func importTasks(args []string) error {
+	c := newCLI("import [flags]")
+	file := c.fs.String("file", "-", "input file, - for stdin")
+	dryRun := c.fs.Bool("dry-run", false, "only validate the input")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi import [flags]"}
+	}
+
+	inputs, err := readImport(*file)
+	if err != nil {
+		return err
+	}
+
+	var invalid []string
+	for i, input := range inputs {
+		if err := validator.Validate(context.Background(), input); err != nil {
+			invalid = append(invalid, fmt.Sprintf("task %d: %v", i+1, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("invalid tasks, nothing imported:\n  %s", strings.Join(invalid, "\n  "))
+	}
+
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "%d tasks are valid\n", len(inputs))
+		return nil
+	}
+
+	return c.withService(func(ctx context.Context, svc service.TaskService) error {
+		created := make([]service.Task, 0, len(inputs))
+		for i, input := range inputs {
+			task, err := svc.CreateTask(ctx, input)
+			if err != nil {
+				_ = c.printTasks(created...)
+				return errors.Wrapf(taskError(err, 0), "imported %d of %d tasks, task %d", len(created), len(inputs), i+1)
+			}
+			created = append(created, task)
+		}
+
+		return c.printTasks(created...)
+	})
+}
+
+// readImport читает задачи для импорта из файла или stdin
+func readImport(file string) ([]service.CreateTaskInput, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "error opening import file")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var inputs []service.CreateTaskInput
+	if err := json.NewDecoder(r).Decode(&inputs); err != nil {
+		return nil, errors.Wrap(err, "error decoding tasks, expected a JSON array")
+	}
+
+	return inputs, nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"restapi/internal/auth"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// userInput - пользователь из командной строки, id с теми же правилами, что user_id в API
+type userInput struct {
+	ID    string `json:"id" validate:"required,max=200"`
+	Name  string `json:"name" validate:"max=200"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// apiKeyInput - ключ API из командной строки
+type apiKeyInput struct {
+	UserID string `json:"user_id" validate:"required,max=200"`
+	Name   string `json:"name" validate:"max=200"`
+}
+
+// users - restapi users list|create|delete
+func users(args []string) error {
+	if len(args) == 0 {
+		return &usageError{msg: "usage: restapi users list|create|delete [flags]"}
+	}
+
+	switch args[0] {
+	case "list":
+		return listUsers(args[1:])
+	case "create":
+		return createUser(args[1:])
+	case "delete":
+		return deleteUser(args[1:])
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown users command %q, use list, create or delete", args[0])}
+	}
+}
+
+// apikeys - restapi apikeys list|create|revoke
+func apikeys(args []string) error {
+	if len(args) == 0 {
+		return &usageError{msg: "usage: restapi apikeys list|create|revoke [flags]"}
+	}
+
+	switch args[0] {
+	case "list":
+		return listAPIKeys(args[1:])
+	case "create":
+		return createAPIKey(args[1:])
+	case "revoke":
+		return revokeAPIKey(args[1:])
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown apikeys command %q, use list, create or revoke", args[0])}
+	}
+}
+
+// listUsers - restapi users list
+func listUsers(args []string) error {
+	c := newCLI("users list [flags]")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi users list [flags]"}
+	}
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		list, err := repo.GetUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		return c.printUsers(list...)
+	})
+}
+
+// createUser - restapi users create <id>
+func createUser(args []string) error {
+	c := newCLI("users create [flags] <id>")
+	name := c.fs.String("name", "", "user name")
+	email := c.fs.String("email", "", "user email")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) != 1 {
+		return &usageError{msg: "expected exactly one user id"}
+	}
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		input := userInput{ID: c.args[0], Name: *name, Email: *email}
+		if err := validator.Validate(ctx, input); err != nil {
+			return errors.Wrap(err, "invalid user")
+		}
+
+		user, err := repo.CreateUser(ctx, db.User{ID: input.ID, Name: input.Name, Email: input.Email})
+		if err != nil {
+			return userError(err, input.ID)
+		}
+
+		return c.printUsers(user)
+	})
+}
+
+// deleteUser - restapi users delete <id>, ключи API пользователя удаляются вместе с ним
+func deleteUser(args []string) error {
+	c := newCLI("users delete [flags] <id>")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) != 1 {
+		return &usageError{msg: "expected exactly one user id"}
+	}
+	id := c.args[0]
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		if err := repo.DeleteUser(ctx, id); err != nil {
+			return userError(err, id)
+		}
+
+		if *c.output == outputJSON {
+			return writeJSON(os.Stdout, map[string]any{"id": id, "deleted": true})
+		}
+		fmt.Printf("User %s deleted\n", id)
+
+		return nil
+	})
+}
+
+// listAPIKeys - restapi apikeys list, ключи всех пользователей или одного
+func listAPIKeys(args []string) error {
+	c := newCLI("apikeys list [flags]")
+	user := c.fs.String("user", "", "only keys of this user")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) > 0 {
+		return &usageError{msg: "usage: restapi apikeys list [flags]"}
+	}
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		list, err := repo.GetAPIKeys(ctx, *user)
+		if err != nil {
+			return err
+		}
+
+		return c.printAPIKeys(list...)
+	})
+}
+
+// createAPIKey - restapi apikeys create <user_id>. Ключ выводится один раз,
+// в базе хранится только его хеш
+func createAPIKey(args []string) error {
+	c := newCLI("apikeys create [flags] <user_id>")
+	name := c.fs.String("name", "", "key name, e.g. where it is used")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) != 1 {
+		return &usageError{msg: "expected exactly one user id"}
+	}
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		input := apiKeyInput{UserID: c.args[0], Name: *name}
+		if err := validator.Validate(ctx, input); err != nil {
+			return errors.Wrap(err, "invalid API key")
+		}
+
+		secret, prefix, hash, err := auth.NewKey()
+		if err != nil {
+			return err
+		}
+
+		key, err := repo.CreateAPIKey(ctx, db.APIKey{UserID: input.UserID, Name: input.Name, Prefix: prefix}, hash)
+		if err != nil {
+			return userError(err, input.UserID)
+		}
+
+		if *c.output == outputJSON {
+			return writeJSON(os.Stdout, struct {
+				*db.APIKey
+				Key string `json:"key"`
+			}{key, secret})
+		}
+		if err := c.printAPIKeys(key); err != nil {
+			return err
+		}
+		fmt.Printf("\nAPI key (shown only once): %s\n", secret)
+
+		return nil
+	})
+}
+
+// revokeAPIKey - restapi apikeys revoke <id>, отозванный ключ сразу перестает приниматься
+func revokeAPIKey(args []string) error {
+	c := newCLI("apikeys revoke [flags] <id>")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	if len(c.args) != 1 {
+		return &usageError{msg: "expected exactly one API key id"}
+	}
+	id, err := strconv.ParseInt(c.args[0], 10, 64)
+	if err != nil || id < 1 {
+		return &usageError{msg: fmt.Sprintf("invalid API key id %q", c.args[0])}
+	}
+
+	return c.withUsers(func(ctx context.Context, repo db.UserRepository) error {
+		key, err := repo.RevokeAPIKey(ctx, id)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return errors.Errorf("API key %d not found", id)
+			}
+			return err
+		}
+
+		return c.printAPIKeys(key)
+	})
+}
+
+// withUsers подключается к базе и выполняет fn с хранилищем пользователей
+func (c *cli) withUsers(fn func(ctx context.Context, repo db.UserRepository) error) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	// Ошибки репозитория возвращаются командой и в журнале не повторяются
+	repo, closeRepo, err := c.connect(ctx, zapcore.DPanicLevel)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	return fn(ctx, repo)
+}
+
+// printUsers выводит пользователей таблицей или JSON-массивом
+func (c *cli) printUsers(list ...*db.User) error {
+	if *c.output == outputJSON {
+		if list == nil {
+			list = []*db.User{}
+		}
+		return writeJSON(os.Stdout, list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tCREATED")
+	for _, user := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			user.ID, user.Name, user.Email, user.CreatedAt.Local().Format(time.DateTime))
+	}
+
+	return w.Flush()
+}
+
+// printAPIKeys выводит ключи API таблицей или JSON-массивом, сами ключи не выводятся
+func (c *cli) printAPIKeys(list ...*db.APIKey) error {
+	if *c.output == outputJSON {
+		if list == nil {
+			list = []*db.APIKey{}
+		}
+		return writeJSON(os.Stdout, list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSER\tNAME\tPREFIX\tCREATED\tREVOKED")
+	for _, key := range list {
+		revoked := "-"
+		if key.RevokedAt != nil {
+			revoked = key.RevokedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s…\t%s\t%s\n",
+			key.ID, key.UserID, key.Name, key.Prefix, key.CreatedAt.Local().Format(time.DateTime), revoked)
+	}
+
+	return w.Flush()
+}
+
+// userError - ошибка хранилища пользователей в виде для командной строки
+func userError(err error, id string) error {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return errors.Errorf("user %s not found", id)
+	case errors.Is(err, db.ErrConflict):
+		return errors.Errorf("user %s already exists", id)
+	default:
+		return err
+	}
+}
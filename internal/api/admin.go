@@ -0,0 +1,75 @@
+package api
+
+import (
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevel - текущий уровень логирования
+type LogLevel struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error dpanic panic fatal"`
+}
+
+// adminHandler - служебные HTTP-обработчики
+type adminHandler struct {
+	log   *zap.SugaredLogger
+	level zap.AtomicLevel
+}
+
+func newAdminHandler(log *zap.SugaredLogger, level zap.AtomicLevel) *adminHandler {
+	return &adminHandler{
+		log:   log,
+		level: level,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (h *adminHandler) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), h.log)
+}
+
+// GetLogLevel - возвращает текущий уровень логирования
+func (h *adminHandler) GetLogLevel(ctx *fiber.Ctx) error {
+	responce := dto.Response{
+		Status: "success",
+		Data:   LogLevel{Level: h.level.String()},
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// SetLogLevel - меняет уровень логирования без перезапуска
+func (h *adminHandler) SetLogLevel(ctx *fiber.Ctx) error {
+	var req LogLevel
+
+	if err := ctx.BodyParser(&req); err != nil {
+		h.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		h.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, "Invalid log level")
+	}
+
+	previous := h.level.Level()
+	h.level.SetLevel(level)
+	h.logger(ctx).Infow("Log level changed", "from", previous.String(), "to", level.String())
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   LogLevel{Level: level.String()},
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
@@ -2,7 +2,23 @@ package api
 
 import (
 	"restapi/internal/api/middleware"
+	"restapi/internal/auth"
+	"restapi/internal/board"
+	"restapi/internal/config"
+	"restapi/internal/gql"
+	"restapi/internal/health"
+	"restapi/internal/metrics"
+	"restapi/internal/openapi"
+	"restapi/internal/ratelimit"
+	"restapi/internal/recurrence"
+	"restapi/internal/reminder"
 	"restapi/internal/service"
+	"restapi/internal/stream"
+	"restapi/internal/tracing"
+	"restapi/internal/webhook"
+	"restapi/internal/worklog"
+	"slices"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -10,39 +26,170 @@ import (
 )
 
 type Routers struct {
-	Service service.Service
+	Service    service.TaskService
+	Webhook    webhook.Service
+	Recurrence recurrence.Service
+	Reminder   reminder.Service
+	Worklog    worklog.Service
+	Board      board.Service
+	Stream     stream.Service
+	GraphQL    gql.Service
+	Health     health.Service
+	// Auth - проверка TOKEN и ключей API для /v1
+	Auth *auth.Authenticator
+	// RateLimit - ограничение запросов к /v1, nil - без ограничения
+	RateLimit *ratelimit.Limiter
+	// LogLevel - уровень логгера, меняется через /v1/admin/log-level
+	LogLevel zap.AtomicLevel
 }
 
-func NewRouters(r *Routers, token string, log *zap.SugaredLogger) *fiber.App {
-	app := fiber.New()
+// NewRouters собирает приложение и документ OpenAPI по его маршрутам.
+// rest возвращает действующие настройки: источники CORS читаются
+// на каждый запрос и меняются при перезагрузке конфигурации.
+// Возвращает ошибку, если маршруты расходятся с описанием в operations
+func NewRouters(r *Routers, rest func() config.Rest, log *zap.SugaredLogger) (*fiber.App, error) {
+	cfg := rest()
+	app := fiber.New(fiber.Config{
+		ReadTimeout:             cfg.ReadTimeout,
+		WriteTimeout:            cfg.WriteTimeout,
+		IdleTimeout:             cfg.IdleTimeout,
+		BodyLimit:               cfg.BodyLimit,
+		ServerHeader:            cfg.ServerName,
+		EnableTrustedProxyCheck: len(cfg.TrustedProxies) > 0,
+		TrustedProxies:          cfg.TrustedProxies,
+		ProxyHeader:             proxyHeader(cfg),
+	})
 
 	// Настройка CORS (разрешенные методы, заголовки, авторизация)
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3000", // Явно укажите разрешенные домены
-		AllowMethods:     "GET, POST, PUT, DELETE",
-		AllowHeaders:     "Accept, Authorization, Content-Type, X-CSRF-Token, X-REQUEST-SomeID",
-		ExposeHeaders:    "Link",
+		AllowOriginsFunc: func(origin string) bool {
+			return slices.Contains(rest().CORSOrigins, origin)
+		},
+		AllowMethods:     strings.Join(cfg.CORSMethods, ","),
+		AllowHeaders:     "Accept, Authorization, Content-Type, X-CSRF-Token, X-Request-ID",
+		ExposeHeaders:    "Link, X-Request-ID",
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	api := app.Group("/v1", middleware.Autorization(token))
+	spec := &openapi.Spec{}
+
+	// Идентификатор и логгер запроса, трассировка, метрики и журнал доступа
+	app.Use(middleware.RequestLogger(log, spec.Route))
+	app.Use(tracing.Middleware(log))
+	app.Use(metrics.Middleware(spec.Route))
+	app.Use(middleware.AccessLog(log))
+
+	tasks := newTaskHandler(log, r.Service)
+	admin := newAdminHandler(log, r.LogLevel)
+
+	// Настройки сервиса доступны только с TOKEN
+	tokenOnly := middleware.RequireToken()
+
+	// Документация API
+	app.Get("/openapi.json", spec.Handler)
+	app.Get("/docs", openapi.DocsHandler)
+
+	// Проверки состояния для оркестратора
+	app.Get("/healthz", r.Health.Live)
+	app.Get("/readyz", r.Health.Ready)
+
+	// Метрики Prometheus
+	app.Get("/metrics", metrics.Handler())
+
+	// Лимит по IP действует до авторизации, чтобы ограничить и подбор токена
+	var handlers []fiber.Handler
+	if r.RateLimit != nil {
+		handlers = append(handlers, middleware.RateLimit(r.RateLimit, middleware.ByIP))
+	}
+	handlers = append(handlers, middleware.Autorization(log, r.Auth))
+	if r.RateLimit != nil {
+		handlers = append(handlers, middleware.RateLimit(r.RateLimit, middleware.ByCaller))
+	}
+	if cfg.ValidateRequests {
+		// Проверка запросов по документу OpenAPI
+		handlers = append(handlers, spec.ValidateRequest)
+	}
+
+	api := app.Group("/v1", handlers...)
 	{
 		// Создание задачи
-		api.Post("/tasks", r.Service.CreateTask)
+		api.Post("/tasks", tasks.CreateTask)
+
+		// Поток событий задач, регистрируется до /tasks/:id
+		api.Get("/tasks/stream", r.Stream.Stream)
+		api.Get("/tasks/ws", r.Stream.WebSocket)
 
 		// Получение задачи
-		api.Get("/tasks/:id", r.Service.GetTask)
+		api.Get("/tasks/:id", tasks.GetTask)
 
 		// Получение всех задач
-		api.Get("/tasks", r.Service.GetAllTasks)
+		api.Get("/tasks", tasks.GetAllTasks)
 
 		// Удаление задачи
-		api.Delete("/tasks/:id", r.Service.DeleteTask)
+		api.Delete("/tasks/:id", tasks.DeleteTask)
 
 		// Обновление задачи
-		api.Put("/tasks/:id", r.Service.UpdateTask)
+		api.Put("/tasks/:id", tasks.UpdateTask)
+
+		// Правило повторения задачи
+		api.Put("/tasks/:id/recurrence", r.Recurrence.SetRecurrence)
+		api.Get("/tasks/:id/recurrence", r.Recurrence.GetRecurrence)
+		api.Delete("/tasks/:id/recurrence", r.Recurrence.DeleteRecurrence)
+
+		// Напоминания о задаче и настройки уведомлений
+		api.Post("/tasks/:id/reminders", r.Reminder.CreateReminder)
+		api.Get("/tasks/:id/reminders", r.Reminder.GetReminders)
+		api.Delete("/tasks/:id/reminders/:reminder_id", r.Reminder.DeleteReminder)
+		api.Get("/users/:user_id/notification-preferences", r.Reminder.GetPreferences)
+		api.Put("/users/:user_id/notification-preferences", r.Reminder.SetPreferences)
+
+		// Учет времени по задачам
+		api.Post("/tasks/:id/worklogs/start", r.Worklog.StartWorklog)
+		api.Post("/tasks/:id/worklogs/stop", r.Worklog.StopWorklog)
+		api.Post("/tasks/:id/worklogs", r.Worklog.CreateWorklog)
+		api.Get("/tasks/:id/worklogs", r.Worklog.GetWorklogs)
+		api.Delete("/tasks/:id/worklogs/:worklog_id", r.Worklog.DeleteWorklog)
+		api.Get("/reports/worklogs", r.Worklog.GetReport)
+
+		// Доска: порядок задач в колонках и WIP-лимиты
+		api.Post("/tasks/:id/move", r.Board.MoveTask)
+		api.Get("/board/columns", r.Board.GetColumns)
+		api.Put("/board/columns/:status", tokenOnly, r.Board.SetWIPLimit)
+		api.Delete("/board/columns/:status", tokenOnly, r.Board.DeleteWIPLimit)
+
+		// GraphQL API
+		api.Post("/graphql", r.GraphQL.Query)
+
+		// Подписки на события задач
+		api.Post("/webhooks", tokenOnly, r.Webhook.CreateWebhook)
+		api.Get("/webhooks", tokenOnly, r.Webhook.GetWebhooks)
+		api.Get("/webhooks/:id", tokenOnly, r.Webhook.GetWebhook)
+		api.Put("/webhooks/:id", tokenOnly, r.Webhook.UpdateWebhook)
+		api.Delete("/webhooks/:id", tokenOnly, r.Webhook.DeleteWebhook)
+
+		// Журнал доставок и повторная отправка
+		api.Get("/webhooks/:id/deliveries", tokenOnly, r.Webhook.GetDeliveries)
+		api.Post("/webhooks/:id/deliveries/:delivery_id/redeliver", tokenOnly, r.Webhook.Redeliver)
+
+		// Уровень логирования
+		api.Get("/admin/log-level", tokenOnly, admin.GetLogLevel)
+		api.Put("/admin/log-level", tokenOnly, admin.SetLogLevel)
+	}
+
+	if err := spec.Build(info, app.GetRoutes(true), operations); err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// proxyHeader - заголовок с адресом клиента учитывается только за доверенными прокси,
+// иначе клиент мог бы подменить свой адрес и обойти лимиты по IP
+func proxyHeader(cfg config.Rest) string {
+	if len(cfg.TrustedProxies) == 0 {
+		return ""
 	}
 
-	return app
+	return cfg.ProxyHeader
 }
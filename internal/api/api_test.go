@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"restapi/internal/auth"
+	"restapi/internal/dto"
+	"restapi/internal/repo/db"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// userKey - ключ API пользователя alice
+const userKey = auth.KeyPrefix + "0123456789abcdef0123456789abcdef"
+
+// testKeys знает только userKey
+type testKeys struct{}
+
+func (testKeys) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	if hash != auth.Hash(userKey) {
+		return "", errors.Wrap(db.ErrNotFound, "API key not found")
+	}
+	return "alice", nil
+}
+
+func TestRequireToken(t *testing.T) {
+	app := newTestApp(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "admin read", method: http.MethodGet, path: "/v1/admin/log-level"},
+		{name: "admin write", method: http.MethodPut, path: "/v1/admin/log-level", body: `{"level":"debug"}`},
+		{name: "webhook list", method: http.MethodGet, path: "/v1/webhooks"},
+		{name: "webhook create", method: http.MethodPost, path: "/v1/webhooks", body: `{"url":"https://example.com","events":["task.created"]}`},
+		{name: "webhook delete", method: http.MethodDelete, path: "/v1/webhooks/1"},
+		{name: "webhook redeliver", method: http.MethodPost, path: "/v1/webhooks/1/deliveries/1/redeliver"},
+		{name: "board limit", method: http.MethodPut, path: "/v1/board/columns/new", body: `{"wip_limit":1}`},
+		{name: "board limit delete", method: http.MethodDelete, path: "/v1/board/columns/new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+userKey)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body dto.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusForbidden || body.Error == nil || body.Error.Code != dto.Forbidden {
+				t.Errorf("status = %d, body = %+v, want 403 %s", resp.StatusCode, body.Error, dto.Forbidden)
+			}
+		})
+	}
+}
+
+func TestRequireTokenAllowsToken(t *testing.T) {
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/log-level", nil)
+	req.Header.Set("Authorization", "Bearer token")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
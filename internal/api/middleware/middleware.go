@@ -1,9 +1,75 @@
 package middleware
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"restapi/internal/auth"
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"strings"
 
-func Autorization(token string) fiber.Handler {
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const bearerPrefix = "Bearer "
+
+// Ключи Locals вызывающего
+const (
+	callerKey = "caller"
+	userKey   = "user_id"
+)
+
+// Autorization проверяет заголовок Authorization: Bearer <TOKEN или ключ API> и добавляет
+// идентификатор вызывающего и пользователя ключа в Locals и логгер запроса
+func Autorization(log *zap.SugaredLogger, authenticator *auth.Authenticator) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		value := ctx.Get(fiber.HeaderAuthorization)
+		if !strings.HasPrefix(value, bearerPrefix) {
+			return dto.UnauthorizedError(ctx)
+		}
+
+		caller, err := authenticator.Authenticate(ctx.UserContext(), strings.TrimPrefix(value, bearerPrefix))
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthorized) {
+				return dto.UnauthorizedError(ctx)
+			}
+			logger.FromContext(ctx.UserContext(), log).Errorf("Error checking authorization: %v", err)
+			return dto.InternalServerError(ctx)
+		}
+
+		ctx.Locals(callerKey, caller.ID)
+		fields := []any{"caller", caller.ID}
+		if caller.UserID != "" {
+			ctx.Locals(userKey, caller.UserID)
+			fields = append(fields, "user_id", caller.UserID)
+		}
+		reqLog := logger.FromContext(ctx.UserContext(), log).With(fields...)
+		ctx.SetUserContext(logger.WithContext(ctx.UserContext(), reqLog))
+
+		return ctx.Next()
+	}
+}
+
+// Caller возвращает идентификатор вызывающего, пустой для запросов без авторизации
+func Caller(ctx *fiber.Ctx) string {
+	caller, _ := ctx.Locals(callerKey).(string)
+	return caller
+}
+
+// UserID возвращает пользователя ключа API, пустой для запросов с TOKEN
+func UserID(ctx *fiber.Ctx) string {
+	userID, _ := ctx.Locals(userKey).(string)
+	return userID
+}
+
+// RequireToken пропускает только запросы с TOKEN: ключи API пользователей
+// не дают доступа к настройкам сервиса
+func RequireToken() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
+		if UserID(ctx) != "" {
+			return dto.ForbiddenError(ctx, "The endpoint requires the service token")
+		}
+
 		return ctx.Next()
 	}
 }
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"math"
+	"restapi/internal/dto"
+	"restapi/internal/ratelimit"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Заголовки ограничения запросов (draft-ietf-httpapi-ratelimit-headers)
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// rateLimitKey - ключ Locals с самым строгим результатом проверки лимитов запроса
+const rateLimitKey = "ratelimit"
+
+// KeyFunc возвращает ключ корзины запроса, пустой ключ - без ограничения
+type KeyFunc func(ctx *fiber.Ctx) string
+
+// ByIP - корзина по адресу клиента
+func ByIP(ctx *fiber.Ctx) string {
+	return "ip:" + ctx.IP()
+}
+
+// ByCaller - корзина по вызывающему, работает после Autorization
+func ByCaller(ctx *fiber.Ctx) string {
+	return Caller(ctx)
+}
+
+// RateLimit ограничивает запросы корзиной по ключу key. Запросы GET, HEAD и OPTIONS
+// расходуют лимит чтения, остальные - лимит записи. Если лимитов несколько,
+// заголовки RateLimit-* показывают тот, в котором осталось меньше запросов
+func RateLimit(limiter *ratelimit.Limiter, key KeyFunc) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		k := key(ctx)
+		if k == "" {
+			return ctx.Next()
+		}
+
+		res := limiter.Allow(ctx.UserContext(), k, isWrite(ctx.Method()))
+		if prev, ok := ctx.Locals(rateLimitKey).(ratelimit.Result); !ok || res.Remaining < prev.Remaining {
+			ctx.Locals(rateLimitKey, res)
+			ctx.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+			ctx.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			ctx.Set(HeaderRateLimitReset, ceilSeconds(res.Reset))
+		}
+
+		if !res.Allowed {
+			ctx.Set(fiber.HeaderRetryAfter, ceilSeconds(res.RetryAfter))
+			return dto.TooManyRequestsError(ctx)
+		}
+
+		return ctx.Next()
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"restapi/internal/config"
+	"restapi/internal/dto"
+	"restapi/internal/ratelimit"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// newLimiter - корзины чтения на readBurst и записи на 1 запрос, за время теста не пополняются
+func newLimiter(t *testing.T, readBurst int) *ratelimit.Limiter {
+	t.Helper()
+
+	limiter, err := ratelimit.NewLimiter(zap.NewNop().Sugar(), ratelimit.NewMemoryStore(), config.RateLimit{
+		ReadRate: 0.001, ReadBurst: readBurst, WriteRate: 0.001, WriteBurst: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return limiter
+}
+
+// setCaller - вызывающий из заголовка X-Caller вместо проверки авторизации
+func setCaller(ctx *fiber.Ctx) error {
+	if caller := ctx.Get("X-Caller"); caller != "" {
+		ctx.Locals(callerKey, caller)
+	}
+	return ctx.Next()
+}
+
+// newRateLimitApp - приложение с лимитами handlers. Адрес клиента берется из X-Forwarded-For
+func newRateLimitApp(handlers ...fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(setCaller)
+	for _, h := range handlers {
+		app.Use(h)
+	}
+	app.All("/tasks", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	return app
+}
+
+// limitResponse - статус, заголовки и тело ответа 429
+type limitResponse struct {
+	status int
+	header func(string) string
+	body   dto.Response
+}
+
+func send(t *testing.T, app *fiber.App, method, caller, ip string) (*limitResponse, error) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/tasks", nil)
+	req.Header.Set(fiber.HeaderXForwardedFor, ip)
+	if caller != "" {
+		req.Header.Set("X-Caller", caller)
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := &limitResponse{status: resp.StatusCode, header: resp.Header.Get}
+	if resp.StatusCode == fiber.StatusTooManyRequests {
+		if err := json.NewDecoder(resp.Body).Decode(&r.body); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	app := newRateLimitApp(RateLimit(newLimiter(t, 2), ByIP))
+
+	tests := []struct {
+		name          string
+		method        string
+		wantStatus    int
+		wantRemaining string
+		wantRetry     bool
+	}{
+		{name: "first read", method: fiber.MethodGet, wantStatus: fiber.StatusOK, wantRemaining: "1"},
+		{name: "second read", method: fiber.MethodGet, wantStatus: fiber.StatusOK, wantRemaining: "0"},
+		{name: "read limit reached", method: fiber.MethodGet, wantStatus: fiber.StatusTooManyRequests, wantRemaining: "0", wantRetry: true},
+		{name: "write has its own bucket", method: fiber.MethodPost, wantStatus: fiber.StatusOK, wantRemaining: "0"},
+		{name: "write limit reached", method: fiber.MethodPut, wantStatus: fiber.StatusTooManyRequests, wantRemaining: "0", wantRetry: true},
+	}
+
+	// Шаги выполняются по порядку с одного адреса
+	for _, tt := range tests {
+		resp, err := send(t, app, tt.method, "", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.status != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.name, resp.status, tt.wantStatus)
+		}
+		if got := resp.header(HeaderRateLimitRemaining); got != tt.wantRemaining {
+			t.Errorf("%s: %s = %q, want %q", tt.name, HeaderRateLimitRemaining, got, tt.wantRemaining)
+		}
+		if resp.header(HeaderRateLimitLimit) == "" || resp.header(HeaderRateLimitReset) == "" {
+			t.Errorf("%s: no %s or %s header", tt.name, HeaderRateLimitLimit, HeaderRateLimitReset)
+		}
+
+		retry := resp.header(fiber.HeaderRetryAfter)
+		if tt.wantRetry {
+			// Токен появится через 1000 секунд
+			if retry != "1000" {
+				t.Errorf("%s: Retry-After = %q, want 1000", tt.name, retry)
+			}
+			if resp.body.Error == nil || resp.body.Error.Code != dto.RateLimited {
+				t.Errorf("%s: body error = %+v, want %s", tt.name, resp.body.Error, dto.RateLimited)
+			}
+		} else if retry != "" {
+			t.Errorf("%s: Retry-After = %q on an allowed request", tt.name, retry)
+		}
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  KeyFunc
+		// Второй запрос: другой вызывающий с того же адреса и тот же вызывающий с другого
+		wantOtherCaller int
+		wantOtherIP     int
+	}{
+		{name: "by IP", key: ByIP, wantOtherCaller: fiber.StatusTooManyRequests, wantOtherIP: fiber.StatusOK},
+		{name: "by caller", key: ByCaller, wantOtherCaller: fiber.StatusOK, wantOtherIP: fiber.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newRateLimitApp(RateLimit(newLimiter(t, 1), tt.key))
+
+			steps := []struct {
+				caller, ip string
+				want       int
+			}{
+				{caller: "key:alice", ip: "10.0.0.1", want: fiber.StatusOK},
+				{caller: "key:bob", ip: "10.0.0.1", want: tt.wantOtherCaller},
+				{caller: "key:alice", ip: "10.0.0.2", want: tt.wantOtherIP},
+			}
+			for i, step := range steps {
+				resp, err := send(t, app, fiber.MethodGet, step.caller, step.ip)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.status != step.want {
+					t.Errorf("request %d (%s from %s): status = %d, want %d", i+1, step.caller, step.ip, resp.status, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitWithoutCaller(t *testing.T) {
+	app := newRateLimitApp(RateLimit(newLimiter(t, 1), ByCaller))
+
+	// Без вызывающего ключ пустой, запросы не ограничиваются
+	for range 3 {
+		resp, err := send(t, app, fiber.MethodGet, "", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.status != fiber.StatusOK || resp.header(HeaderRateLimitLimit) != "" {
+			t.Fatalf("status = %d, limit header %q, want unlimited", resp.status, resp.header(HeaderRateLimitLimit))
+		}
+	}
+}
+
+func TestRateLimitStrictestHeaders(t *testing.T) {
+	// Лимит по IP шире лимита по вызывающему: заголовки показывают лимит вызывающего
+	app := newRateLimitApp(RateLimit(newLimiter(t, 10), ByIP), RateLimit(newLimiter(t, 3), ByCaller))
+
+	resp, err := send(t, app, fiber.MethodGet, "key:alice", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.header(HeaderRateLimitLimit) + "/" + resp.header(HeaderRateLimitRemaining); got != "3/2" {
+		t.Errorf("limit/remaining = %s, want 3/2", got)
+	}
+}
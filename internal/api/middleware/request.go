@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"restapi/internal/logger"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+)
+
+// maxRequestIDLen - входящий X-Request-ID длиннее считается недействительным
+const maxRequestIDLen = 128
+
+// requestIDKey - ключ Locals с идентификатором запроса
+const requestIDKey = "request_id"
+
+// RouteFunc возвращает шаблон маршрута для метода и пути до маршрутизации
+type RouteFunc func(method, path string) (string, bool)
+
+// RequestLogger берет X-Request-ID из запроса или создает новый, возвращает его
+// в ответе и кладет в UserContext логгер запроса с request_id, method и route
+func RequestLogger(log *zap.SugaredLogger, route RouteFunc) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id := ctx.Get(fiber.HeaderXRequestID)
+		if validRequestID(id) {
+			id = utils.CopyString(id)
+		} else {
+			id = utils.UUIDv4()
+		}
+		ctx.Set(fiber.HeaderXRequestID, id)
+		ctx.Locals(requestIDKey, id)
+
+		fields := []any{"request_id", id, "method", ctx.Method()}
+		if template, ok := route(ctx.Method(), ctx.Path()); ok {
+			fields = append(fields, "route", template)
+		}
+		ctx.SetUserContext(logger.WithContext(ctx.UserContext(), log.With(fields...)))
+
+		return ctx.Next()
+	}
+}
+
+// RequestID возвращает идентификатор запроса
+func RequestID(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals(requestIDKey).(string)
+	return id
+}
+
+// validRequestID - непустой идентификатор из печатных ASCII-символов разумной длины
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AccessLog пишет одну строку на запрос со статусом и длительностью. Ошибку обработчика
+// сразу передает обработчику ошибок приложения, чтобы записать итоговый статус
+func AccessLog(log *zap.SugaredLogger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := ctx.Next(); err != nil {
+			if err := ctx.App().Config().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		fields := []any{
+			"path", ctx.Path(),
+			"status", status,
+			"latency", time.Since(start),
+			"ip", ctx.IP(),
+			"bytes", len(ctx.Response().Body()),
+		}
+
+		reqLog := logger.FromContext(ctx.UserContext(), log)
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			reqLog.Errorw("HTTP request", fields...)
+		case status >= fiber.StatusBadRequest:
+			reqLog.Warnw("HTTP request", fields...)
+		default:
+			reqLog.Infow("HTTP request", fields...)
+		}
+
+		return nil
+	}
+}
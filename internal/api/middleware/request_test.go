@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"restapi/internal/logger"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// testRoute знает только шаблон /v1/tasks/:id
+func testRoute(method, path string) (string, bool) {
+	if strings.HasPrefix(path, "/v1/tasks/") {
+		return "/v1/tasks/:id", true
+	}
+	return "", false
+}
+
+// newRequestApp - приложение, обработчик которого пишет в лог запроса и отдает RequestID в теле
+func newRequestApp() (*fiber.App, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := zap.New(core).Sugar()
+
+	app := fiber.New()
+	app.Use(RequestLogger(log, testRoute))
+	app.Get("/*", func(ctx *fiber.Ctx) error {
+		logger.FromContext(ctx.UserContext(), log).Info("handled")
+		return ctx.SendString(RequestID(ctx))
+	})
+
+	return app, logs
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		// wantEcho - вернуть входящий идентификатор, иначе создать UUID
+		wantEcho bool
+	}{
+		{name: "echo", incoming: "req-123", wantEcho: true},
+		{name: "echo max length", incoming: strings.Repeat("a", maxRequestIDLen), wantEcho: true},
+		{name: "generate when missing"},
+		{name: "generate when too long", incoming: strings.Repeat("a", maxRequestIDLen+1)},
+		{name: "generate for space", incoming: "req 123"},
+		{name: "generate for non-ASCII", incoming: "запрос"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, logs := newRequestApp()
+
+			req := httptest.NewRequest(fiber.MethodGet, "/v1/tasks/1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.incoming)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			id := resp.Header.Get(fiber.HeaderXRequestID)
+			switch {
+			case tt.wantEcho && id != tt.incoming:
+				t.Errorf("X-Request-ID = %q, want %q", id, tt.incoming)
+			case !tt.wantEcho && !uuidPattern.MatchString(id):
+				t.Errorf("X-Request-ID = %q, want generated UUID", id)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != id {
+				t.Errorf("RequestID = %q, want %q", body, id)
+			}
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("log entries = %d, want 1", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["request_id"] != id || fields["method"] != fiber.MethodGet || fields["route"] != "/v1/tasks/:id" {
+				t.Errorf("log fields = %v, want request_id %s, method GET and route /v1/tasks/:id", fields, id)
+			}
+		})
+	}
+}
+
+func TestRequestIDUnique(t *testing.T) {
+	app, logs := newRequestApp()
+
+	seen := make(map[string]bool)
+	for range 3 {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/other", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		id := resp.Header.Get(fiber.HeaderXRequestID)
+		if seen[id] {
+			t.Errorf("X-Request-ID %q repeated", id)
+		}
+		seen[id] = true
+	}
+
+	// Путь вне документа логируется без route
+	for _, entry := range logs.All() {
+		if _, ok := entry.ContextMap()["route"]; ok {
+			t.Errorf("log fields = %v, want no route", entry.ContextMap())
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"net/http"
+	"restapi/internal/board"
+	"restapi/internal/dto"
+	"restapi/internal/event"
+	"restapi/internal/gql"
+	"restapi/internal/health"
+	"restapi/internal/openapi"
+	"restapi/internal/recurrence"
+	"restapi/internal/reminder"
+	"restapi/internal/repo/db"
+	"restapi/internal/service"
+	"restapi/internal/webhook"
+	"restapi/internal/worklog"
+)
+
+// info - описание API в документе OpenAPI
+var info = openapi.Info{
+	Title:   "Task Manager REST API",
+	Version: "1.0.0",
+}
+
+// Параметры потока событий
+var streamQuery = []openapi.Param{
+	{Name: "status", Type: "string", Description: "Статусы задач через запятую"},
+	{Name: "event", Type: "string", Description: "Типы событий через запятую"},
+	{Name: "task_id", Type: "integer", Description: "Только события задачи"},
+	{Name: "last_event_id", Type: "integer", Description: "Продолжить ленту после события"},
+}
+
+// pageQuery - номер страницы
+var pageQuery = []openapi.Param{
+	{Name: "page", Type: "integer", Description: "Номер страницы, начиная с 1"},
+}
+
+// taskListQuery - страница и фильтр списка задач
+var taskListQuery = []openapi.Param{
+	pageQuery[0],
+	{Name: "limit", Type: "integer", Description: "Размер страницы, до 100, по умолчанию 2"},
+	{Name: "status", Type: "string", Description: "Статусы задач через запятую"},
+	{Name: "sort", Type: "string", Description: "Порядок: created_at (по умолчанию) или rank - по колонкам доски",
+		Enum: []string{"created_at", "rank"}},
+}
+
+// worklogReportQuery - группировка и отбор отчета по учету времени
+var worklogReportQuery = []openapi.Param{
+	{Name: "group_by", Type: "string", Description: "Группировка, по умолчанию user", Enum: []string{"user", "task", "status", "day"}},
+	{Name: "from", Type: "string", Description: "Начало периода: время RFC 3339 или дата YYYY-MM-DD"},
+	{Name: "to", Type: "string", Description: "Конец периода, не включается; дата включается целиком"},
+	{Name: "timezone", Type: "string", Description: "Часовой пояс дат и группировки по дням, по умолчанию UTC"},
+	{Name: "user_id", Type: "string", Description: "Только записи пользователя"},
+	{Name: "task_id", Type: "integer", Description: "Только записи задачи"},
+	{Name: "status", Type: "string", Description: "Статусы задач через запятую"},
+}
+
+// userParams - user_id - строковый идентификатор пользователя во внешней системе
+var userParams = map[string]string{"user_id": "string"}
+
+// columnParams - status - статус колонки доски
+var columnParams = map[string]string{"status": "string"}
+
+// operations - описание всех маршрутов. Маршрут без описания или описание
+// без маршрута не дают собрать роутер, см. openapi.Verify
+var operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Summary: "Документ OpenAPI",
+		Raw: true, Response: map[string]any{}, Public: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/docs", ID: "getDocs", Summary: "Swagger UI",
+		ContentType: "text/html", Response: "", Public: true,
+	},
+
+	// Проверки состояния
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "live", Summary: "Проверка живости", Tag: "health",
+		Response: health.Report{}, Public: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "ready", Summary: "Проверка готовности", Tag: "health",
+		Response: health.Report{}, Public: true,
+	},
+	{
+		Method: http.MethodGet, Path: "/metrics", ID: "metrics", Summary: "Метрики Prometheus", Tag: "health",
+		ContentType: "text/plain", Response: "", Public: true,
+	},
+
+	// Задачи
+	{
+		Method: http.MethodPost, Path: "/v1/tasks", ID: "createTask", Summary: "Создание задачи", Tag: "tasks",
+		Request: service.CreateTaskInput{}, Status: http.StatusCreated, Response: dto.Created{},
+		Errors: []int{http.StatusConflict},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks", ID: "getAllTasks", Summary: "Список задач", Tag: "tasks",
+		Query: taskListQuery, Response: []service.Task{},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/:id", ID: "getTask", Summary: "Задача по ID", Tag: "tasks",
+		Response: service.Task{},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/tasks/:id", ID: "updateTask", Summary: "Обновление задачи", Tag: "tasks",
+		Request: service.UpdateTaskInput{}, Errors: []int{http.StatusConflict},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/tasks/:id", ID: "deleteTask", Summary: "Удаление задачи", Tag: "tasks",
+	},
+
+	// Повторяющиеся задачи
+	{
+		Method: http.MethodPut, Path: "/v1/tasks/:id/recurrence", ID: "setRecurrence", Summary: "Правило повторения задачи",
+		Tag: "recurrence", Request: recurrence.RecurrenceRequest{}, Response: recurrence.RecurrenceResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/:id/recurrence", ID: "getRecurrence", Summary: "Правило повторения задачи",
+		Tag: "recurrence", Response: recurrence.RecurrenceResponse{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/tasks/:id/recurrence", ID: "deleteRecurrence", Summary: "Удаление правила повторения",
+		Tag: "recurrence",
+	},
+
+	// Напоминания
+	{
+		Method: http.MethodPost, Path: "/v1/tasks/:id/reminders", ID: "createReminder", Summary: "Создание напоминания",
+		Tag: "reminders", Request: reminder.ReminderRequest{}, Status: http.StatusCreated, Response: db.Reminder{},
+		Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/:id/reminders", ID: "getReminders", Summary: "Напоминания о задаче",
+		Tag: "reminders", Response: []db.Reminder{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/tasks/:id/reminders/:reminder_id", ID: "deleteReminder",
+		Summary: "Удаление напоминания", Tag: "reminders",
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/users/:user_id/notification-preferences", ID: "getNotificationPreferences",
+		Summary: "Настройки уведомлений пользователя", Tag: "reminders", PathParams: userParams,
+		Response: db.NotificationPreferences{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/users/:user_id/notification-preferences", ID: "setNotificationPreferences",
+		Summary: "Настройки уведомлений пользователя", Tag: "reminders", PathParams: userParams,
+		Request: reminder.PreferencesRequest{}, Response: db.NotificationPreferences{}, Errors: []int{http.StatusForbidden},
+	},
+
+	// Учет времени
+	{
+		Method: http.MethodPost, Path: "/v1/tasks/:id/worklogs/start", ID: "startWorklog", Summary: "Запуск таймера",
+		Tag: "worklogs", Request: worklog.StartRequest{}, Status: http.StatusCreated, Response: db.Worklog{},
+		Errors: []int{http.StatusForbidden, http.StatusConflict},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/tasks/:id/worklogs/stop", ID: "stopWorklog", Summary: "Остановка таймера",
+		Tag: "worklogs", Request: worklog.StopRequest{}, Response: db.Worklog{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/tasks/:id/worklogs", ID: "createWorklog", Summary: "Запись о работе за период",
+		Tag: "worklogs", Request: worklog.WorklogRequest{}, Status: http.StatusCreated, Response: db.Worklog{},
+		Errors: []int{http.StatusForbidden, http.StatusConflict},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/:id/worklogs", ID: "getWorklogs", Summary: "Учет времени по задаче",
+		Tag: "worklogs", Response: []db.Worklog{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/tasks/:id/worklogs/:worklog_id", ID: "deleteWorklog",
+		Summary: "Удаление записи о работе", Tag: "worklogs",
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/reports/worklogs", ID: "reportWorklogs", Summary: "Отчет по учету времени",
+		Tag: "worklogs", Query: worklogReportQuery, Response: worklog.Report{},
+	},
+
+	// Доска
+	{
+		Method: http.MethodPost, Path: "/v1/tasks/:id/move", ID: "moveTask", Summary: "Перемещение задачи на доске",
+		Tag: "board", Request: board.MoveRequest{}, Response: service.Task{}, Errors: []int{http.StatusConflict},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/board/columns", ID: "getBoardColumns", Summary: "Колонки доски",
+		Tag: "board", Response: []db.BoardColumn{},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/board/columns/:status", ID: "setWIPLimit", Summary: "WIP-лимит колонки",
+		Tag: "board", PathParams: columnParams, Request: board.WIPLimitRequest{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/board/columns/:status", ID: "deleteWIPLimit", Summary: "Снятие WIP-лимита колонки",
+		Tag: "board", PathParams: columnParams, Errors: []int{http.StatusForbidden},
+	},
+
+	// Поток событий
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/stream", ID: "streamTasks", Summary: "Поток событий (SSE)", Tag: "events",
+		Query: streamQuery, ContentType: "text/event-stream", Response: event.Event{},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/tasks/ws", ID: "watchTasks", Summary: "Поток событий (WebSocket)", Tag: "events",
+		Query: streamQuery, Status: http.StatusSwitchingProtocols,
+	},
+
+	// GraphQL
+	{
+		Method: http.MethodPost, Path: "/v1/graphql", ID: "graphql", Summary: "Запрос GraphQL", Tag: "graphql",
+		Request: gql.Request{}, Raw: true, Response: map[string]any{},
+	},
+
+	// Вебхуки
+	{
+		Method: http.MethodPost, Path: "/v1/webhooks", ID: "createWebhook", Summary: "Создание подписки", Tag: "webhooks",
+		Request: webhook.WebhookRequest{}, Status: http.StatusCreated, Response: webhook.CreatedWebhook{},
+		Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/webhooks", ID: "getWebhooks", Summary: "Список подписок", Tag: "webhooks",
+		Response: []db.Webhook{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/webhooks/:id", ID: "getWebhook", Summary: "Подписка по ID", Tag: "webhooks",
+		Response: db.Webhook{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/webhooks/:id", ID: "updateWebhook", Summary: "Обновление подписки", Tag: "webhooks",
+		Request: webhook.UpdateWebhookRequest{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/webhooks/:id", ID: "deleteWebhook", Summary: "Удаление подписки", Tag: "webhooks",
+		Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/webhooks/:id/deliveries", ID: "getDeliveries", Summary: "Журнал доставок", Tag: "webhooks",
+		Query: pageQuery, Response: []db.WebhookDelivery{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/webhooks/:id/deliveries/:delivery_id/redeliver", ID: "redeliver",
+		Summary: "Повторная доставка", Tag: "webhooks", Status: http.StatusAccepted, Errors: []int{http.StatusForbidden},
+	},
+
+	// Администрирование
+	{
+		Method: http.MethodGet, Path: "/v1/admin/log-level", ID: "getLogLevel", Summary: "Уровень логирования", Tag: "admin",
+		Response: LogLevel{}, Errors: []int{http.StatusForbidden},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/admin/log-level", ID: "setLogLevel", Summary: "Смена уровня логирования", Tag: "admin",
+		Request: LogLevel{}, Response: LogLevel{}, Errors: []int{http.StatusForbidden},
+	},
+}
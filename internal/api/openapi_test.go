@@ -0,0 +1,78 @@
+package api
+
+import (
+	"restapi/internal/auth"
+	"restapi/internal/board"
+	"restapi/internal/config"
+	"restapi/internal/gql"
+	"restapi/internal/health"
+	"restapi/internal/openapi"
+	"restapi/internal/recurrence"
+	"restapi/internal/reminder"
+	"restapi/internal/service"
+	"restapi/internal/stream"
+	"restapi/internal/webhook"
+	"restapi/internal/worklog"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// newTestApp собирает настоящее приложение. Хранилище не нужно: обработчики
+// не вызываются, проверяется только таблица маршрутов
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	log := zap.NewNop().Sugar()
+	svc := service.NewService(nil)
+	routers := &Routers{
+		Service:    svc,
+		Webhook:    webhook.NewService(log, nil),
+		Recurrence: recurrence.NewService(log, nil),
+		Reminder:   reminder.NewService(log, nil),
+		Worklog:    worklog.NewService(log, nil),
+		Board:      board.NewService(log, nil),
+		Stream:     stream.NewService(log, stream.NewHub(log, nil, config.Stream{})),
+		GraphQL:    gql.NewService(log, svc),
+		Health:     health.NewChecker(log, config.Health{}),
+		Auth:       auth.NewAuthenticator(func() string { return "token" }, testKeys{}),
+		LogLevel:   zap.NewAtomicLevel(),
+	}
+
+	app, err := NewRouters(routers, func() config.Rest { return config.Rest{} }, log)
+	if err != nil {
+		t.Fatalf("NewRouters: %v", err)
+	}
+
+	return app
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	app := newTestApp(t)
+
+	if err := openapi.Verify(app.GetRoutes(true), operations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyReportsUnmappedRoute(t *testing.T) {
+	app := newTestApp(t)
+	app.Get("/v1/unmapped", func(c *fiber.Ctx) error { return nil })
+
+	err := openapi.Verify(app.GetRoutes(true), operations)
+	if err == nil || !strings.Contains(err.Error(), "route without spec: GET /v1/unmapped") {
+		t.Fatalf("Verify = %v, want unmapped route reported", err)
+	}
+}
+
+func TestVerifyReportsMissingRoute(t *testing.T) {
+	app := newTestApp(t)
+	ops := append(operations[:len(operations):len(operations)], openapi.Operation{Method: fiber.MethodGet, Path: "/v1/missing"})
+
+	err := openapi.Verify(app.GetRoutes(true), ops)
+	if err == nil || !strings.Contains(err.Error(), "spec without route: GET /v1/missing") {
+		t.Fatalf("Verify = %v, want missing route reported", err)
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/service"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// taskHandler - HTTP-обработчики задач: разбор запроса, вызов TaskService
+// и преобразование результата в dto.Response
+type taskHandler struct {
+	log     *zap.SugaredLogger
+	service service.TaskService
+}
+
+func newTaskHandler(log *zap.SugaredLogger, service service.TaskService) *taskHandler {
+	return &taskHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (h *taskHandler) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), h.log)
+}
+
+// CreateTask - создает новую задачу
+func (h *taskHandler) CreateTask(ctx *fiber.Ctx) error {
+	var req service.CreateTaskInput
+
+	if err := ctx.BodyParser(&req); err != nil {
+		h.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	task, err := h.service.CreateTask(ctx.UserContext(), req)
+	if err != nil {
+		return h.serviceError(ctx, err, "Error creating task")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   dto.Created{ID: task.ID},
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(responce)
+}
+
+// GetTask - возвращает задачу по id
+func (h *taskHandler) GetTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		h.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	task, err := h.service.GetTask(ctx.UserContext(), int64(id))
+	if err != nil {
+		return h.serviceError(ctx, err, "Error getting task")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetAllTasks - возвращает все задачи
+func (h *taskHandler) GetAllTasks(ctx *fiber.Ctx) error {
+	input := service.ListTasksInput{
+		Page:  ctx.QueryInt("page", 1),
+		Limit: ctx.QueryInt("limit", 0),
+		Sort:  ctx.Query("sort"),
+	}
+	if status := ctx.Query("status"); status != "" {
+		input.Statuses = strings.Split(status, ",")
+	}
+
+	tasks, err := h.service.ListTasks(ctx.UserContext(), input)
+	if err != nil {
+		return h.serviceError(ctx, err, "Error getting tasks")
+	}
+
+	if len(tasks) == 0 {
+		h.logger(ctx).Error("Tasks not found")
+		return dto.BadResponseError(ctx, dto.FieldNotFound, "Tasks not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   tasks,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteTask - удаляет задачу
+func (h *taskHandler) DeleteTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		h.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	if err := h.service.DeleteTask(ctx.UserContext(), int64(id)); err != nil {
+		return h.serviceError(ctx, err, "Error deleting task")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// UpdateTask - обновляет задачу
+func (h *taskHandler) UpdateTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		h.logger(ctx).Error("Invalid id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req service.UpdateTaskInput
+
+	if err := ctx.BodyParser(&req); err != nil {
+		h.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if _, err := h.service.UpdateTask(ctx.UserContext(), int64(id), req); err != nil {
+		return h.serviceError(ctx, err, "Error updating task")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// serviceError - преобразует ошибку TaskService в ответ
+func (h *taskHandler) serviceError(ctx *fiber.Ctx, err error, msg string) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	case errors.Is(err, service.ErrNotFound):
+		return dto.NotFoundError(ctx, "Task not found")
+	case errors.Is(err, service.ErrWIPLimit):
+		return dto.ConflictError(ctx, "WIP limit of the column is reached")
+	default:
+		h.logger(ctx).Errorf("%s: %v", msg, zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+}
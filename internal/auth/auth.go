@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"restapi/internal/repo/db"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KeyPrefix - начало ключей API, по нему ключ отличается от TOKEN
+const KeyPrefix = "rak_"
+
+// Длины ключа API
+const (
+	// keyBytes - случайная часть ключа
+	keyBytes = 24
+	// shownLen - начало ключа, которое хранится и выводится в списках
+	shownLen = len(KeyPrefix) + 8
+)
+
+// ErrUnauthorized - токен не подходит ни к TOKEN, ни к действующему ключу API
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Caller - вызывающий API
+type Caller struct {
+	// ID - идентификатор для логов и лимитов: token:<хеш TOKEN> или key:<начало ключа>
+	ID string
+	// UserID - владелец ключа API, пустой для TOKEN. Запросы с TOKEN выполняет сервис
+	// или администратор и могут действовать от имени любого пользователя
+	UserID string
+}
+
+// Authenticator проверяет TOKEN из конфигурации и ключи API пользователей
+type Authenticator struct {
+	token func() string
+	keys  db.APIKeyRepository
+}
+
+// NewAuthenticator создает проверку авторизации. token возвращает действующий TOKEN,
+// чтобы его можно было сменить без перезапуска; keys nil - ключи API не принимаются
+func NewAuthenticator(token func() string, keys db.APIKeyRepository) *Authenticator {
+	return &Authenticator{
+		token: token,
+		keys:  keys,
+	}
+}
+
+// Authenticate возвращает вызывающего по токену из заголовка Authorization.
+// Неизвестный или отозванный ключ - ErrUnauthorized
+func (a *Authenticator) Authenticate(ctx context.Context, secret string) (Caller, error) {
+	expected := a.token()
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1 {
+		return Caller{ID: tokenID(expected)}, nil
+	}
+
+	if a.keys == nil || !strings.HasPrefix(secret, KeyPrefix) || len(secret) <= shownLen {
+		return Caller{}, ErrUnauthorized
+	}
+
+	userID, err := a.keys.GetAPIKeyUser(ctx, Hash(secret))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return Caller{}, ErrUnauthorized
+		}
+		return Caller{}, errors.Wrap(err, "failed to check API key")
+	}
+
+	return Caller{ID: "key:" + secret[:shownLen], UserID: userID}, nil
+}
+
+// NewKey создает ключ API. Возвращает ключ, который показывается один раз,
+// его начало для списков и хеш для хранения
+func NewKey() (key, prefix, hash string, err error) {
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", errors.Wrap(err, "failed to generate API key")
+	}
+
+	key = KeyPrefix + hex.EncodeToString(random)
+	return key, key[:shownLen], Hash(key), nil
+}
+
+// Hash - хеш ключа API для хранения и поиска
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenID - идентификатор TOKEN для логов, сам токен в логи не попадает
+func tokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"restapi/internal/repo/db"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeKeys - ключи API по хешу; revoked - отозванные ключи, как их видит хранилище
+type fakeKeys struct {
+	users   map[string]string
+	revoked map[string]bool
+	err     error
+}
+
+func (f *fakeKeys) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	user, ok := f.users[hash]
+	if !ok || f.revoked[hash] {
+		return "", errors.Wrap(db.ErrNotFound, "API key not found")
+	}
+	return user, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, prefix, hash, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revokedKey, _, revokedHash, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownKey, _, _, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &fakeKeys{
+		users:   map[string]string{hash: "alice", revokedHash: "bob"},
+		revoked: map[string]bool{revokedHash: true},
+	}
+	a := NewAuthenticator(func() string { return "secret" }, keys)
+
+	tests := []struct {
+		name    string
+		secret  string
+		want    Caller
+		wantErr error
+	}{
+		{name: "token", secret: "secret", want: Caller{ID: tokenID("secret")}},
+		{name: "api key", secret: key, want: Caller{ID: "key:" + prefix, UserID: "alice"}},
+		{name: "revoked key", secret: revokedKey, wantErr: ErrUnauthorized},
+		{name: "unknown key", secret: unknownKey, wantErr: ErrUnauthorized},
+		{name: "wrong token", secret: "other", wantErr: ErrUnauthorized},
+		{name: "empty", secret: "", wantErr: ErrUnauthorized},
+		{name: "bare prefix", secret: KeyPrefix, wantErr: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(context.Background(), tt.secret)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("caller = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRepositoryError(t *testing.T) {
+	key, _, _, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(func() string { return "secret" }, &fakeKeys{err: errors.New("connection refused")})
+
+	// Сбой хранилища - не отказ в доступе: клиент должен получить 500, а не 401
+	_, err = a.Authenticate(context.Background(), key)
+	if err == nil || errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want repository error", err)
+	}
+}
+
+func TestAuthenticateWithoutKeys(t *testing.T) {
+	key, _, _, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(func() string { return "secret" }, nil)
+
+	if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	key, prefix, hash, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, KeyPrefix) || len(key) != len(KeyPrefix)+2*keyBytes {
+		t.Errorf("key %q has wrong format", key)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != shownLen {
+		t.Errorf("prefix %q is not the start of the key", prefix)
+	}
+	if hash != Hash(key) || strings.Contains(hash, key) {
+		t.Errorf("hash %q does not match the key", hash)
+	}
+
+	other, _, _, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key {
+		t.Error("two keys are equal")
+	}
+}
@@ -0,0 +1,19 @@
+package board
+
+// Statuses - колонки доски слева направо
+var Statuses = []string{"new", "in_progress", "done"}
+
+// MoveRequest - новое место задачи на доске. Без соседей задача встает в конец колонки
+type MoveRequest struct {
+	// Status - колонка, пустой - текущая колонка задачи
+	Status string `json:"status" validate:"omitempty,oneof=new in_progress done"`
+	// AfterID - задача, под которой встает перемещаемая
+	AfterID *int64 `json:"after_id" validate:"omitempty,min=1"`
+	// BeforeID - задача, над которой встает перемещаемая
+	BeforeID *int64 `json:"before_id" validate:"omitempty,min=1"`
+}
+
+// WIPLimitRequest - наибольшее число задач в колонке
+type WIPLimitRequest struct {
+	WIPLimit int `json:"wip_limit" validate:"required,min=1,max=10000"`
+}
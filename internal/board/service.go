@@ -0,0 +1,180 @@
+package board
+
+import (
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type service struct {
+	log  *zap.SugaredLogger
+	repo db.BoardRepository
+}
+
+// Service - интерфейс доски: порядок задач в колонках и WIP-лимиты
+type Service interface {
+	MoveTask(ctx *fiber.Ctx) error
+	GetColumns(ctx *fiber.Ctx) error
+	SetWIPLimit(ctx *fiber.Ctx) error
+	DeleteWIPLimit(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, repo db.BoardRepository) Service {
+	return &service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (s *service) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), s.log)
+}
+
+// MoveTask - переносит задачу в колонку между соседями
+func (s *service) MoveTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req MoveRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	// Задача не может быть соседом самой себе
+	var fields []dto.FieldError
+	if req.AfterID != nil && *req.AfterID == int64(id) {
+		fields = append(fields, dto.FieldError{In: "body", Field: "after_id", Rule: "ne", Param: "id",
+			Message: "after_id must not be the moved task"})
+	}
+	if req.BeforeID != nil && *req.BeforeID == int64(id) {
+		fields = append(fields, dto.FieldError{In: "body", Field: "before_id", Rule: "ne", Param: "id",
+			Message: "before_id must not be the moved task"})
+	}
+	if len(fields) > 0 {
+		s.logger(ctx).Error("Invalid move: task is its own neighbor")
+		return dto.ValidationError(ctx, "Invalid request body", fields)
+	}
+
+	task, err := s.repo.MoveTask(ctx.UserContext(), int64(id), db.TaskMove{
+		Status:   req.Status,
+		AfterID:  req.AfterID,
+		BeforeID: req.BeforeID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return dto.NotFoundError(ctx, "Task not found")
+		case errors.Is(err, db.ErrWIPLimit):
+			return dto.ConflictError(ctx, "WIP limit of the column is reached")
+		case errors.Is(err, db.ErrConflict):
+			return dto.ConflictError(ctx, "Neighbor tasks are not in the target column or out of order")
+		}
+		s.logger(ctx).Errorf("Error moving task: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   task,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetColumns - возвращает колонки доски с числом задач и WIP-лимитами
+func (s *service) GetColumns(ctx *fiber.Ctx) error {
+	columns, err := s.repo.GetColumns(ctx.UserContext(), Statuses)
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting board columns: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   columns,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// SetWIPLimit - задает WIP-лимит колонки. Лимит проверяется, когда задача попадает в колонку
+func (s *service) SetWIPLimit(ctx *fiber.Ctx) error {
+	status, ok := s.status(ctx)
+	if !ok {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid column status")
+	}
+
+	var req WIPLimitRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	if err := s.repo.SetWIPLimit(ctx.UserContext(), status, req.WIPLimit); err != nil {
+		s.logger(ctx).Errorf("Error setting WIP limit: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteWIPLimit - снимает WIP-лимит колонки
+func (s *service) DeleteWIPLimit(ctx *fiber.Ctx) error {
+	status, ok := s.status(ctx)
+	if !ok {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid column status")
+	}
+
+	if err := s.repo.DeleteWIPLimit(ctx.UserContext(), status); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return dto.NotFoundError(ctx, "WIP limit not found")
+		}
+		s.logger(ctx).Errorf("Error deleting WIP limit: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// status - статус колонки из пути
+func (s *service) status(ctx *fiber.Ctx) (string, bool) {
+	status := ctx.Params("status")
+	if !slices.Contains(Statuses, status) {
+		s.logger(ctx).Error("Invalid column status")
+		return "", false
+	}
+
+	// Параметры Fiber ссылаются на буфер запроса
+	return string([]byte(status)), true
+}
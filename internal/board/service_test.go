@@ -0,0 +1,94 @@
+package board
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"restapi/internal/dto"
+	"restapi/internal/repo/db"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeRepo возвращает err или перемещенную задачу и запоминает перемещение.
+// Остальные методы хранилища не реализованы
+type fakeRepo struct {
+	db.BoardRepository
+
+	err   error
+	moved *db.TaskMove
+}
+
+func (r *fakeRepo) MoveTask(ctx context.Context, id int64, move db.TaskMove) (*db.Task, error) {
+	r.moved = &move
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &db.Task{ID: id, Status: move.Status, Rank: "a0V"}, nil
+}
+
+func TestMoveTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		repoErr  error
+		want     int
+		wantCode string
+		// wantFields - поля с нарушениями через запятую
+		wantFields string
+	}{
+		{name: "moved", body: `{"status":"done","after_id":3,"before_id":4}`, want: fiber.StatusOK},
+		{name: "end of column", body: `{}`, want: fiber.StatusOK},
+		{name: "unknown status", body: `{"status":"archived"}`, want: fiber.StatusBadRequest, wantCode: dto.FieldIncorrect, wantFields: "status"},
+		{name: "own neighbor", body: `{"after_id":7,"before_id":7}`, want: fiber.StatusBadRequest, wantCode: dto.FieldIncorrect, wantFields: "after_id,before_id"},
+		{name: "not found", body: `{}`, repoErr: errors.Wrap(db.ErrNotFound, "task not found"), want: fiber.StatusNotFound, wantCode: dto.FieldNotFound},
+		{name: "WIP limit", body: `{"status":"done"}`, repoErr: errors.Wrap(db.ErrWIPLimit, "column done has 3 of 3 tasks"), want: fiber.StatusConflict, wantCode: dto.Conflict},
+		{name: "neighbors out of order", body: `{"after_id":4,"before_id":3}`, repoErr: errors.Wrap(db.ErrConflict, "neighbors"), want: fiber.StatusConflict, wantCode: dto.Conflict},
+		{name: "repository error", body: `{}`, repoErr: errors.New("connection refused"), want: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{err: tt.repoErr}
+			app := fiber.New()
+			app.Post("/tasks/:id/move", NewService(zap.NewNop().Sugar(), repo).MoveTask)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/tasks/7/move", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body dto.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.wantCode != "" && (body.Error == nil || body.Error.Code != tt.wantCode) {
+				t.Errorf("error = %+v, want code %s", body.Error, tt.wantCode)
+			}
+			if tt.wantFields != "" {
+				var got []string
+				for _, f := range body.Error.Fields {
+					got = append(got, f.Field)
+				}
+				if strings.Join(got, ",") != tt.wantFields {
+					t.Errorf("fields = %v, want %s", got, tt.wantFields)
+				}
+				if repo.moved != nil {
+					t.Error("invalid move reached the repository")
+				}
+			}
+		})
+	}
+}
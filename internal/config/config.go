@@ -1,25 +1,76 @@
 package config
 
-import (
-	"time"
-
-	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
-)
+import "time"
 
 // AppConfig конфигурация приложения
 type AppConfig struct {
-	LogLevel string `envconfig:"LOG_LEVEL" default:"info"`
-	Rest     Rest
-	Database Database
+	Log        Log
+	Rest       Rest
+	Grpc       Grpc
+	Database   Database
+	Webhook    Webhook
+	Outbox     Outbox
+	Stream     Stream
+	Health     Health
+	Tracing    Tracing
+	RateLimit  RateLimit
+	Shutdown   Shutdown
+	Recurrence Recurrence
+	Reminder   Reminder
+}
+
+// Log конфигурация логгера
+type Log struct {
+	Level string `envconfig:"LOG_LEVEL" default:"info" reload:"true"`
+	// SamplingInitial - сколько одинаковых сообщений в секунду пишется полностью,
+	// из остальных пишется каждое SamplingThereafter. 0 отключает сэмплирование
+	SamplingInitial    int `envconfig:"LOG_SAMPLING_INITIAL" default:"0"`
+	SamplingThereafter int `envconfig:"LOG_SAMPLING_THEREAFTER" default:"100"`
+	// File - файл для логов в дополнение к stdout, пустой - только stdout
+	File       string `envconfig:"LOG_FILE" default:""`
+	MaxSize    int    `envconfig:"LOG_FILE_MAX_SIZE_MB" default:"100"`
+	MaxBackups int    `envconfig:"LOG_FILE_MAX_BACKUPS" default:"5"`
+	MaxAge     int    `envconfig:"LOG_FILE_MAX_AGE_DAYS" default:"30"`
+	Compress   bool   `envconfig:"LOG_FILE_COMPRESS" default:"true"`
 }
 
 // Rest конфигурация API
 type Rest struct {
 	ListenPort   string        `envconfig:"LISTEN_PORT" required:"true"`
+	ReadTimeout  time.Duration `envconfig:"READ_TIMEOUT" default:"15s"`
 	WriteTimeout time.Duration `envconfig:"WRITE_TIMEOUT" required:"true"`
-	ServerName   string        `envconfig:"SERVER_NAME" required:"true"`
-	Token        string        `envconfig:"TOKEN" required:"true"`
+	IdleTimeout  time.Duration `envconfig:"IDLE_TIMEOUT" default:"60s"`
+	// BodyLimit - наибольший размер тела запроса в байтах
+	BodyLimit int `envconfig:"BODY_LIMIT" default:"4194304"`
+	// ServerName - значение заголовка Server в ответах
+	ServerName string `envconfig:"SERVER_NAME" required:"true"`
+	// TrustedProxies - адреса или подсети прокси, которым разрешено передавать адрес
+	// клиента в ProxyHeader. Пустой список - адрес клиента берется из соединения
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES" default:""`
+	ProxyHeader    string   `envconfig:"PROXY_HEADER" default:"X-Forwarded-For"`
+	Token          string   `envconfig:"TOKEN" required:"true" secret:"true" reload:"true"`
+	// CORSOrigins - источники, которым разрешены запросы из браузера
+	CORSOrigins []string `envconfig:"CORS_ORIGINS" default:"http://localhost:3000" reload:"true"`
+	CORSMethods []string `envconfig:"CORS_METHODS" default:"GET,POST,PUT,DELETE"`
+	// ValidateRequests - проверять запросы по документу OpenAPI до обработчиков
+	ValidateRequests bool `envconfig:"VALIDATE_REQUESTS" default:"false"`
+	TLS              TLS
+}
+
+// TLS конфигурация HTTPS. Без сертификата сервер работает по HTTP
+type TLS struct {
+	CertFile string `envconfig:"TLS_CERT_FILE" default:""`
+	KeyFile  string `envconfig:"TLS_KEY_FILE" default:""`
+	// ClientCAFile - сертификаты УЦ клиентов, если задан - клиенты обязаны
+	// предъявить сертификат (mTLS)
+	ClientCAFile string `envconfig:"TLS_CLIENT_CA_FILE" default:""`
+	// ReloadInterval - период проверки обновления файлов сертификата
+	ReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"1m"`
+}
+
+// Grpc конфигурация gRPC API
+type Grpc struct {
+	ListenPort string `envconfig:"GRPC_LISTEN_PORT" default:"9090"`
 }
 
 // Database конфигурация базы данных
@@ -27,7 +78,7 @@ type Database struct {
 	Host                string        `envconfig:"DB_HOST" required:"true"`
 	Port                int           `envconfig:"DB_PORT" required:"true"`
 	User                string        `envconfig:"DB_USER" required:"true"`
-	Password            string        `envconfig:"DB_PWD" required:"true"`
+	Password            string        `envconfig:"DB_PWD" required:"true" secret:"true"`
 	DBName              string        `envconfig:"DB_NAME" required:"true"`
 	SSLMode             string        `envconfig:"DB_SSL_MODE" required:"true"`
 	PoolMaxConns        int           `envconfig:"DB_POOL_MAX_CONNS" required:"true"`
@@ -35,16 +86,107 @@ type Database struct {
 	PoolMaxConnIdleTime time.Duration `envconfig:"DB_POOL_MAX_CONN_IDLE_TIME" required:"true"`
 }
 
-// NewConfig загружает конфигурацию из файла .env
-func NewConfig() *AppConfig {
-	if err := godotenv.Load(".env"); err != nil {
-		panic("error loading .env file")
-	}
+// Webhook конфигурация доставки вебхуков
+type Webhook struct {
+	PollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"5s"`
+	Timeout      time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	BatchSize    int           `envconfig:"WEBHOOK_BATCH_SIZE" default:"50"`
+	MaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	BaseBackoff  time.Duration `envconfig:"WEBHOOK_BASE_BACKOFF" default:"30s"`
+	MaxBackoff   time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+}
+
+// Outbox конфигурация публикации событий из outbox
+type Outbox struct {
+	PollInterval    time.Duration `envconfig:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	BatchSize       int           `envconfig:"OUTBOX_BATCH_SIZE" default:"100"`
+	Publishers      []string      `envconfig:"OUTBOX_PUBLISHERS" default:"webhook"`
+	Timeout         time.Duration `envconfig:"OUTBOX_TIMEOUT" default:"10s"`
+	MaxAttempts     int           `envconfig:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+	BaseBackoff     time.Duration `envconfig:"OUTBOX_BASE_BACKOFF" default:"1s"`
+	MaxBackoff      time.Duration `envconfig:"OUTBOX_MAX_BACKOFF" default:"5m"`
+	Retention       time.Duration `envconfig:"OUTBOX_RETENTION" default:"168h"`
+	CleanupInterval time.Duration `envconfig:"OUTBOX_CLEANUP_INTERVAL" default:"1h"`
+	// KafkaRESTURL - адрес Kafka REST Proxy для получателя kafka
+	KafkaRESTURL string `envconfig:"OUTBOX_KAFKA_REST_URL" default:""`
+	// KafkaTopicPrefix - начало имени топика, за ним следует тип события
+	KafkaTopicPrefix string `envconfig:"OUTBOX_KAFKA_TOPIC_PREFIX" default:"tasks."`
+}
+
+// Stream конфигурация потоковой выдачи событий
+type Stream struct {
+	Buffer         int           `envconfig:"STREAM_BUFFER" default:"64"`
+	KeepAlive      time.Duration `envconfig:"STREAM_KEEPALIVE" default:"15s"`
+	ReplayLimit    int           `envconfig:"STREAM_REPLAY_LIMIT" default:"1000"`
+	ReconnectDelay time.Duration `envconfig:"STREAM_RECONNECT_DELAY" default:"5s"`
+	// PollInterval - период дочитывания ленты без уведомлений: событие становится
+	// видимым, когда завершатся все более старые транзакции, о чем NOTIFY не приходит
+	PollInterval time.Duration `envconfig:"STREAM_POLL_INTERVAL" default:"1s"`
+}
 
-	var cfg AppConfig
-	if err := envconfig.Process("", &cfg); err != nil {
-		panic("error loading environment variables")
-	}
+// Health конфигурация проверок состояния
+type Health struct {
+	CheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+}
+
+// Tracing конфигурация трассировки OpenTelemetry
+type Tracing struct {
+	Enabled     bool    `envconfig:"TRACING_ENABLED" default:"false"`
+	Endpoint    string  `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `envconfig:"OTEL_EXPORTER_OTLP_INSECURE" default:"true"`
+	ServiceName string  `envconfig:"OTEL_SERVICE_NAME" default:"restapi"`
+	SampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+}
+
+// RateLimit конфигурация ограничения запросов к /v1. Лимиты действуют
+// отдельно для каждого вызывающего и каждого IP
+type RateLimit struct {
+	Enabled bool `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+	// Store - хранилище корзин: memory или postgres для нескольких экземпляров
+	Store      string  `envconfig:"RATE_LIMIT_STORE" default:"memory"`
+	ReadRate   float64 `envconfig:"RATE_LIMIT_READ_RPS" default:"20" reload:"true"`
+	ReadBurst  int     `envconfig:"RATE_LIMIT_READ_BURST" default:"40" reload:"true"`
+	WriteRate  float64 `envconfig:"RATE_LIMIT_WRITE_RPS" default:"5" reload:"true"`
+	WriteBurst int     `envconfig:"RATE_LIMIT_WRITE_BURST" default:"10" reload:"true"`
+}
+
+// Shutdown конфигурация остановки сервиса
+type Shutdown struct {
+	// DrainPeriod - сколько ждать после снятия готовности, чтобы балансировщик
+	// перестал направлять запросы
+	DrainPeriod time.Duration `envconfig:"SHUTDOWN_DRAIN_PERIOD" default:"5s"`
+	// Timeout - ограничение каждого этапа остановки
+	Timeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
+}
+
+// Recurrence конфигурация создания повторяющихся задач по расписанию
+type Recurrence struct {
+	// PollInterval - период поиска правил, по которым пора создать задачу
+	PollInterval time.Duration `envconfig:"RECURRENCE_POLL_INTERVAL" default:"15s"`
+	BatchSize    int           `envconfig:"RECURRENCE_BATCH_SIZE" default:"100"`
+}
+
+// Reminder конфигурация отправки напоминаний о задачах
+type Reminder struct {
+	PollInterval time.Duration `envconfig:"REMINDER_POLL_INTERVAL" default:"30s"`
+	// Timeout - ограничение отправки одного уведомления
+	Timeout     time.Duration `envconfig:"REMINDER_TIMEOUT" default:"10s"`
+	BatchSize   int           `envconfig:"REMINDER_BATCH_SIZE" default:"50"`
+	MaxAttempts int           `envconfig:"REMINDER_MAX_ATTEMPTS" default:"5"`
+	BaseBackoff time.Duration `envconfig:"REMINDER_BASE_BACKOFF" default:"1m"`
+	MaxBackoff  time.Duration `envconfig:"REMINDER_MAX_BACKOFF" default:"30m"`
+	// DefaultChannels - каналы для пользователей без настроек уведомлений
+	DefaultChannels []string `envconfig:"REMINDER_DEFAULT_CHANNELS" default:"log"`
+	SMTP            SMTP
+}
 
-	return &cfg
+// SMTP конфигурация отправки почты. Без SMTP_HOST канал email недоступен
+type SMTP struct {
+	Host     string `envconfig:"SMTP_HOST" default:""`
+	Port     int    `envconfig:"SMTP_PORT" default:"587"`
+	Username string `envconfig:"SMTP_USERNAME" default:""`
+	Password string `envconfig:"SMTP_PASSWORD" default:"" secret:"true"`
+	From     string `envconfig:"SMTP_FROM" default:""`
+	// TLS - starttls, tls (SMTPS, обычно порт 465) или none
+	TLS string `envconfig:"SMTP_TLS" default:"starttls"`
 }
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// envFile - необязательный файл с переменными окружения
+const envFile = ".env"
+
+// fileEnv - переменная с путем к файлу конфигурации, если не задан флаг -config
+const fileEnv = "CONFIG_FILE"
+
+// Errors - все ошибки конфигурации
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e, "\n  ")
+}
+
+// field - параметр конфигурации с тегами
+type field struct {
+	key      string
+	def      string
+	required bool
+	secret   bool
+	// reload - параметр применяется при перезагрузке без перезапуска
+	reload bool
+	value  reflect.Value
+}
+
+// fields возвращает параметры конфигурации в порядке объявления
+func fields(cfg *AppConfig) []field {
+	var result []field
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			key, ok := sf.Tag.Lookup("envconfig")
+			if !ok {
+				if sf.Type.Kind() == reflect.Struct {
+					walk(v.Field(i))
+				}
+				continue
+			}
+
+			result = append(result, field{
+				key:      key,
+				def:      sf.Tag.Get("default"),
+				required: sf.Tag.Get("required") == "true",
+				secret:   sf.Tag.Get("secret") == "true",
+				reload:   sf.Tag.Get("reload") == "true",
+				value:    v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+
+	return result
+}
+
+// set разбирает строковое значение в поле
+func (f field) set(value string) error {
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetFloat(x)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return errors.Errorf("unsupported type %s", f.value.Type())
+	}
+
+	return nil
+}
+
+// flagName - имя флага для переменной: LISTEN_PORT -> listen-port
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// source - откуда читается конфигурация: файл и значения флагов
+type source struct {
+	file  string
+	flags map[string]string
+}
+
+// Flags - флаги конфигурации в наборе флагов команды
+type Flags struct {
+	fs   *flag.FlagSet
+	file *string
+	// keys - параметр по имени флага
+	keys map[string]string
+}
+
+// AddFlags добавляет в fs флаг -config и флаги параметров (-listen-port для LISTEN_PORT).
+// Конфигурация с их значениями собирается Load после разбора fs
+func AddFlags(fs *flag.FlagSet) *Flags {
+	var cfg AppConfig
+	params := fields(&cfg)
+
+	f := &Flags{
+		fs:   fs,
+		file: fs.String("config", os.Getenv(fileEnv), "config file, YAML or TOML"),
+		keys: make(map[string]string, len(params)),
+	}
+	for _, p := range params {
+		usage := "overrides " + p.key
+		if p.reload {
+			usage += " (reloadable)"
+		}
+		fs.String(flagName(p.key), "", usage)
+		f.keys[flagName(p.key)] = p.key
+	}
+
+	return f
+}
+
+// IsParam - задает ли флаг name параметр конфигурации
+func (f *Flags) IsParam(name string) bool {
+	_, ok := f.keys[name]
+	return ok
+}
+
+// Load собирает конфигурацию так же, как config.Load, с флагами из разобранного набора
+func (f *Flags) Load() (*AppConfig, error) {
+	return load(f.source())
+}
+
+// source - файл и флаги, заданные в командной строке
+func (f *Flags) source() source {
+	src := source{file: *f.file, flags: make(map[string]string)}
+	f.fs.Visit(func(fl *flag.Flag) {
+		if key, ok := f.keys[fl.Name]; ok {
+			src.flags[key] = fl.Value.String()
+		}
+	})
+
+	return src
+}
+
+// parseFlags разбирает -config и флаги параметров
+func parseFlags(args []string) (source, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags := AddFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return source{}, err
+	}
+
+	return flags.source(), nil
+}
+
+// Load собирает конфигурацию из слоев по возрастанию приоритета: значения по умолчанию,
+// файл YAML или TOML (-config или CONFIG_FILE), необязательный .env, переменные
+// окружения и флаги командной строки (-listen-port для LISTEN_PORT).
+// Возвращает все отсутствующие и неверные параметры одной ошибкой Errors
+func Load(args []string) (*AppConfig, error) {
+	src, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return load(src)
+}
+
+func load(src source) (*AppConfig, error) {
+	var cfg AppConfig
+	params := fields(&cfg)
+
+	known := make(map[string]bool, len(params))
+	for _, p := range params {
+		known[p.key] = true
+	}
+
+	var errs Errors
+	values := make(map[string]string)
+	if src.file != "" {
+		fileValues, err := readFile(src.file)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range fileValues {
+			if !known[key] {
+				errs = append(errs, fmt.Sprintf("%s: unknown parameter in %s", key, src.file))
+				continue
+			}
+			values[key] = value
+		}
+	}
+
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "error loading %s", envFile)
+	}
+	for key, value := range dotenv {
+		values[key] = value
+	}
+
+	for _, p := range params {
+		if value, ok := os.LookupEnv(p.key); ok {
+			values[p.key] = value
+		}
+	}
+	for key, value := range src.flags {
+		values[key] = value
+	}
+
+	for _, p := range params {
+		value, ok := values[p.key]
+		switch {
+		case ok:
+		case p.required:
+			errs = append(errs, p.key+": required")
+			continue
+		default:
+			value = p.def
+		}
+
+		if value == "" && p.value.Kind() != reflect.String && p.value.Kind() != reflect.Slice {
+			continue
+		}
+		if err := p.set(value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: invalid value %q", p.key, value))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// readFile читает плоский файл конфигурации с ключами как у переменных окружения:
+//
+//	LISTEN_PORT: 8080
+//	OUTBOX_PUBLISHERS: [webhook, log]
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading config file")
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, errors.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing config file %s", path)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			values[strings.ToUpper(key)] = ""
+		case map[string]any:
+			return nil, errors.Errorf("config file %s: %s: nested sections are not supported", path, key)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[strings.ToUpper(key)] = strings.Join(items, ",")
+		default:
+			values[strings.ToUpper(key)] = fmt.Sprint(v)
+		}
+	}
+
+	return values, nil
+}
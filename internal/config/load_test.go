@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		flag string
+		want int
+	}{
+		{name: "default", want: 50},
+		{name: "file over default", file: "10", want: 10},
+		{name: "env over file", file: "10", env: "20", want: 20},
+		{name: "flag over env", file: "10", env: "20", flag: "30", want: 30},
+		{name: "flag over file", file: "10", flag: "30", want: 30},
+		{name: "env without file", env: "20", want: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extra := "TOKEN: secret\n"
+			if tt.file != "" {
+				extra += "WEBHOOK_BATCH_SIZE: " + tt.file + "\n"
+			}
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			writeConfig(t, file, extra)
+
+			if tt.env != "" {
+				t.Setenv("WEBHOOK_BATCH_SIZE", tt.env)
+			}
+			args := []string{"-config", file}
+			if tt.flag != "" {
+				args = append(args, "-webhook-batch-size", tt.flag)
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Webhook.BatchSize != tt.want {
+				t.Errorf("WEBHOOK_BATCH_SIZE = %d, want %d", cfg.Webhook.BatchSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "yaml", file: "config.yaml", content: baseConfig + "TOKEN: secret\nOUTBOX_PUBLISHERS: [webhook, log]\n"},
+		{name: "toml", file: "config.toml", content: `LISTEN_PORT = "8080"
+WRITE_TIMEOUT = "15s"
+SERVER_NAME = "test"
+DB_HOST = "localhost"
+DB_PORT = 5432
+DB_USER = "user"
+DB_PWD = "secret"
+DB_NAME = "tasks"
+DB_SSL_MODE = "disable"
+DB_POOL_MAX_CONNS = 4
+DB_POOL_MAX_CONN_LIFETIME = "1h"
+DB_POOL_MAX_CONN_IDLE_TIME = "30m"
+TOKEN = "secret"
+OUTBOX_PUBLISHERS = ["webhook", "log"]
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := load(source{file: file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(cfg.Outbox.Publishers, []string{"webhook", "log"}) || cfg.Database.Port != 5432 {
+				t.Errorf("publishers = %v, db port = %d", cfg.Outbox.Publishers, cfg.Database.Port)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	// Нет TOKEN, неизвестный параметр и неверное значение - все ошибки в одном ответе
+	writeConfig(t, file, "UNKNOWN_PARAM: 1\nWEBHOOK_BATCH_SIZE: many\n")
+
+	_, err := load(source{file: file})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want Errors", err)
+	}
+	want := Errors{
+		"UNKNOWN_PARAM: unknown parameter in " + file,
+		"TOKEN: required",
+		`WEBHOOK_BATCH_SIZE: invalid value "many"`,
+	}
+	if !slices.Equal(errs, want) {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
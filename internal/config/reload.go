@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// watchInterval - период проверки изменения файлов конфигурации
+const watchInterval = 2 * time.Second
+
+// Change - изменение параметра, значения секретов скрыты
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Diff возвращает изменившиеся параметры в порядке объявления
+func Diff(old, new *AppConfig) []Change {
+	oldFields, newFields := fields(old), fields(new)
+
+	var changes []Change
+	for i, f := range oldFields {
+		if reflect.DeepEqual(f.value.Interface(), newFields[i].value.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{Key: f.key, Old: f.format(), New: newFields[i].format()})
+	}
+
+	return changes
+}
+
+// Reloader перечитывает конфигурацию по SIGHUP или при изменении файла конфигурации
+// и .env. Применяются только параметры с тегом reload, остальные требуют перезапуска.
+// Новая конфигурация с ошибками отклоняется, текущая остается в силе
+type Reloader struct {
+	log     *zap.SugaredLogger
+	src     source
+	current atomic.Pointer[AppConfig]
+
+	mu       sync.Mutex
+	handlers []func(old, new *AppConfig) error
+}
+
+// NewReloader создает перезагрузчик для конфигурации cfg, загруженной из args
+func NewReloader(log *zap.SugaredLogger, args []string, cfg *AppConfig) (*Reloader, error) {
+	src, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{log: log, src: src}
+	r.current.Store(cfg)
+
+	return r, nil
+}
+
+// Current возвращает действующую конфигурацию
+func (r *Reloader) Current() *AppConfig {
+	return r.current.Load()
+}
+
+// OnReload добавляет обработчик, применяющий новую конфигурацию. Обработчики
+// вызываются по порядку после проверки всей конфигурации. Обработчик, вернувший
+// ошибку, не должен ничего менять: перезагрузка отклоняется, а уже вызванные
+// обработчики получают прежнюю конфигурацию как новую
+func (r *Reloader) OnReload(handler func(old, new *AppConfig) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers = append(r.handlers, handler)
+}
+
+// Reload перечитывает конфигурацию и применяет изменения параметров с тегом reload
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := load(r.src)
+	if err != nil {
+		r.log.Errorf("Config reload rejected, keeping current config: %v", err)
+		return err
+	}
+
+	old := r.Current()
+	next := *old
+	nextFields := fields(&next)
+	for i, f := range fields(loaded) {
+		if reflect.DeepEqual(f.value.Interface(), nextFields[i].value.Interface()) {
+			continue
+		}
+		if !f.reload {
+			r.log.Warnf("Config parameter %s changed, restart to apply it", f.key)
+			continue
+		}
+		nextFields[i].value.Set(f.value)
+	}
+
+	changes := Diff(old, &next)
+	if len(changes) == 0 {
+		r.log.Info("Config reloaded, no changes")
+		return nil
+	}
+
+	// Параметры без тега reload остаются прежними и могут не сочетаться
+	// с новыми, поэтому проверяется итоговая конфигурация
+	if err := next.Validate(); err != nil {
+		r.log.Errorf("Config reload rejected, keeping current config: %v", err)
+		return err
+	}
+
+	if err := r.apply(old, &next); err != nil {
+		r.log.Errorf("Config reload rejected, keeping current config: %v", err)
+		return err
+	}
+	r.current.Store(&next)
+	r.log.Infow("Config reloaded", "changes", changes)
+
+	return nil
+}
+
+// apply вызывает обработчики по порядку. При ошибке обработчика уже вызванные
+// возвращают прежнюю конфигурацию в обратном порядке
+func (r *Reloader) apply(old, next *AppConfig) error {
+	for i, handler := range r.handlers {
+		err := handler(old, next)
+		if err == nil {
+			continue
+		}
+
+		for j := i - 1; j >= 0; j-- {
+			if restoreErr := r.handlers[j](next, old); restoreErr != nil {
+				r.log.Errorf("Error restoring config after rejected reload: %v", restoreErr)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Run перезагружает конфигурацию до отмены контекста
+func (r *Reloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	files := []string{envFile}
+	if r.src.file != "" {
+		files = append(files, r.src.file)
+	}
+	modified := modTimes(files)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.log.Info("SIGHUP received, reloading config")
+			_ = r.Reload()
+		case <-ticker.C:
+			current := modTimes(files)
+			if reflect.DeepEqual(current, modified) {
+				continue
+			}
+			modified = current
+			r.log.Info("Config file changed, reloading config")
+			_ = r.Reload()
+		}
+	}
+}
+
+// modTimes - время изменения файлов, отсутствующий файл дает нулевое время
+func modTimes(files []string) []time.Time {
+	times := make([]time.Time, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+
+	return times
+}
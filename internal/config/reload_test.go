@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// baseConfig - обязательные параметры файла конфигурации
+const baseConfig = `LISTEN_PORT: "8080"
+WRITE_TIMEOUT: 15s
+SERVER_NAME: test
+DB_HOST: localhost
+DB_PORT: 5432
+DB_USER: user
+DB_PWD: secret
+DB_NAME: tasks
+DB_SSL_MODE: disable
+DB_POOL_MAX_CONNS: 4
+DB_POOL_MAX_CONN_LIFETIME: 1h
+DB_POOL_MAX_CONN_IDLE_TIME: 30m
+`
+
+// newTestReloader загружает конфигурацию из файла с параметрами extra
+func newTestReloader(t *testing.T, extra string) (*Reloader, string) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, file, "TOKEN: old\n"+extra)
+
+	src := source{file: file}
+	cfg, err := load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Reloader{log: zap.NewNop().Sugar(), src: src}
+	r.current.Store(cfg)
+
+	return r, file
+}
+
+func writeConfig(t *testing.T, file, params string) {
+	t.Helper()
+
+	if err := os.WriteFile(file, []byte(baseConfig+params), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadAppliesReloadableParams(t *testing.T) {
+	r, file := newTestReloader(t, "")
+
+	var applied []string
+	r.OnReload(func(old, new *AppConfig) error {
+		// Обработчик видит обе конфигурации, текущая еще прежняя
+		if r.Current().Rest.Token != "old" {
+			t.Errorf("current token in handler = %q, want old", r.Current().Rest.Token)
+		}
+		applied = append(applied, old.Rest.Token+"->"+new.Rest.Token)
+		return nil
+	})
+
+	writeConfig(t, file, "TOKEN: new\n")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Current().Rest.Token; got != "new" {
+		t.Errorf("token = %q, want new", got)
+	}
+	if len(applied) != 1 || applied[0] != "old->new" {
+		t.Errorf("handler calls = %v, want [old->new]", applied)
+	}
+}
+
+func TestReloadValidatesMergedConfig(t *testing.T) {
+	r, file := newTestReloader(t, "RATE_LIMIT_ENABLED: true\n")
+
+	called := false
+	r.OnReload(func(old, new *AppConfig) error {
+		called = true
+		return nil
+	})
+
+	// Сам по себе файл верен: лимит отключен. Но RATE_LIMIT_ENABLED требует
+	// перезапуска, и действующий лимит получил бы нулевую скорость
+	writeConfig(t, file, "TOKEN: new\nRATE_LIMIT_ENABLED: false\nRATE_LIMIT_READ_RPS: 0\n")
+	if err := r.Reload(); err == nil {
+		t.Fatal("reload with an invalid rate limit succeeded")
+	}
+
+	if called {
+		t.Error("handlers ran for a rejected config")
+	}
+	if cfg := r.Current(); cfg.Rest.Token != "old" || cfg.RateLimit.ReadRate != 20 {
+		t.Errorf("config changed after rejected reload: token %q, read rate %v", cfg.Rest.Token, cfg.RateLimit.ReadRate)
+	}
+}
+
+func TestReloadRestoresOnHandlerError(t *testing.T) {
+	r, file := newTestReloader(t, "")
+
+	var token string
+	r.OnReload(func(old, new *AppConfig) error {
+		token = new.Rest.Token
+		return nil
+	})
+	r.OnReload(func(old, new *AppConfig) error {
+		return errors.New("cannot apply")
+	})
+	third := false
+	r.OnReload(func(old, new *AppConfig) error {
+		third = true
+		return nil
+	})
+
+	writeConfig(t, file, "TOKEN: new\n")
+	if err := r.Reload(); err == nil {
+		t.Fatal("reload succeeded despite a handler error")
+	}
+
+	if token != "old" {
+		t.Errorf("applied token = %q, want restored old", token)
+	}
+	if third {
+		t.Error("handler after the failed one was called")
+	}
+	if got := r.Current().Rest.Token; got != "old" {
+		t.Errorf("current token = %q, want old", got)
+	}
+}
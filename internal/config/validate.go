@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// kafkaTopic - допустимые символы имени топика Kafka
+var kafkaTopic = regexp.MustCompile(`^[a-zA-Z0-9._-]*$`)
+
+// checker собирает нарушения, чтобы сообщить обо всех сразу
+type checker struct {
+	errs Errors
+}
+
+func (c *checker) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		c.errs = append(c.errs, key+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (c *checker) positive(key string, d time.Duration) {
+	c.check(d > 0, key, "must be positive, got %s", d)
+}
+
+func (c *checker) port(key, value string) {
+	port, err := strconv.Atoi(value)
+	c.check(err == nil && port > 0 && port < 65536, key, "must be a port number, got %q", value)
+}
+
+func (c *checker) oneOf(key, value string, allowed ...string) {
+	c.check(slices.Contains(allowed, value), key, "must be one of %v, got %q", allowed, value)
+}
+
+// Validate проверяет значения параметров и их сочетания
+func (c *AppConfig) Validate() error {
+	v := &checker{}
+
+	_, err := zapcore.ParseLevel(c.Log.Level)
+	v.check(err == nil, "LOG_LEVEL", "unknown level %q", c.Log.Level)
+	v.check(c.Log.SamplingInitial >= 0, "LOG_SAMPLING_INITIAL", "must not be negative")
+	if c.Log.SamplingInitial > 0 {
+		v.check(c.Log.SamplingThereafter > 0, "LOG_SAMPLING_THEREAFTER", "must be positive when sampling is enabled")
+	}
+	if c.Log.File != "" {
+		v.check(c.Log.MaxSize > 0, "LOG_FILE_MAX_SIZE_MB", "must be positive")
+		v.check(c.Log.MaxBackups >= 0, "LOG_FILE_MAX_BACKUPS", "must not be negative")
+		v.check(c.Log.MaxAge >= 0, "LOG_FILE_MAX_AGE_DAYS", "must not be negative")
+	}
+
+	v.port("LISTEN_PORT", c.Rest.ListenPort)
+	v.positive("READ_TIMEOUT", c.Rest.ReadTimeout)
+	v.positive("WRITE_TIMEOUT", c.Rest.WriteTimeout)
+	v.positive("IDLE_TIMEOUT", c.Rest.IdleTimeout)
+	v.check(c.Rest.BodyLimit > 0, "BODY_LIMIT", "must be positive")
+	for _, proxy := range c.Rest.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		v.check(net.ParseIP(proxy) != nil || cidrErr == nil, "TRUSTED_PROXIES", "must be an IP address or CIDR, got %q", proxy)
+	}
+	if len(c.Rest.TrustedProxies) > 0 {
+		v.check(c.Rest.ProxyHeader != "", "PROXY_HEADER", "must not be empty when TRUSTED_PROXIES is set")
+	}
+	for _, method := range c.Rest.CORSMethods {
+		v.oneOf("CORS_METHODS", method, "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS")
+	}
+	tls := c.Rest.TLS
+	v.check((tls.CertFile == "") == (tls.KeyFile == ""), "TLS_KEY_FILE", "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	if tls.ClientCAFile != "" {
+		v.check(tls.CertFile != "", "TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if tls.CertFile != "" {
+		v.positive("TLS_RELOAD_INTERVAL", tls.ReloadInterval)
+	}
+	v.check(c.Rest.Token != "", "TOKEN", "must not be empty")
+	for _, origin := range c.Rest.CORSOrigins {
+		u, err := url.Parse(origin)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "",
+			"CORS_ORIGINS", "must be an origin like https://example.com, got %q", origin)
+	}
+	v.port("GRPC_LISTEN_PORT", c.Grpc.ListenPort)
+	v.check(c.Rest.ListenPort != c.Grpc.ListenPort, "GRPC_LISTEN_PORT", "must differ from LISTEN_PORT")
+
+	v.port("DB_PORT", strconv.Itoa(c.Database.Port))
+	v.check(c.Database.Host != "", "DB_HOST", "must not be empty")
+	v.oneOf("DB_SSL_MODE", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	v.check(c.Database.PoolMaxConns > 0, "DB_POOL_MAX_CONNS", "must be positive")
+	v.positive("DB_POOL_MAX_CONN_LIFETIME", c.Database.PoolMaxConnLifetime)
+	v.positive("DB_POOL_MAX_CONN_IDLE_TIME", c.Database.PoolMaxConnIdleTime)
+
+	v.positive("WEBHOOK_POLL_INTERVAL", c.Webhook.PollInterval)
+	v.positive("WEBHOOK_TIMEOUT", c.Webhook.Timeout)
+	v.check(c.Webhook.BatchSize > 0, "WEBHOOK_BATCH_SIZE", "must be positive")
+	v.check(c.Webhook.MaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS", "must be positive")
+	v.positive("WEBHOOK_BASE_BACKOFF", c.Webhook.BaseBackoff)
+	v.check(c.Webhook.MaxBackoff >= c.Webhook.BaseBackoff, "WEBHOOK_MAX_BACKOFF", "must not be less than WEBHOOK_BASE_BACKOFF")
+
+	v.positive("OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+	v.check(c.Outbox.BatchSize > 0, "OUTBOX_BATCH_SIZE", "must be positive")
+	for _, name := range c.Outbox.Publishers {
+		v.oneOf("OUTBOX_PUBLISHERS", name, "webhook", "log", "kafka")
+	}
+	if slices.Contains(c.Outbox.Publishers, "kafka") {
+		u, err := url.Parse(c.Outbox.KafkaRESTURL)
+		v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"OUTBOX_KAFKA_REST_URL", "must be an http(s) URL when OUTBOX_PUBLISHERS has kafka, got %q", c.Outbox.KafkaRESTURL)
+		v.check(kafkaTopic.MatchString(c.Outbox.KafkaTopicPrefix), "OUTBOX_KAFKA_TOPIC_PREFIX",
+			"may contain only letters, digits, '.', '_' and '-', got %q", c.Outbox.KafkaTopicPrefix)
+	}
+	v.positive("OUTBOX_TIMEOUT", c.Outbox.Timeout)
+	v.check(c.Outbox.MaxAttempts > 0, "OUTBOX_MAX_ATTEMPTS", "must be positive")
+	v.positive("OUTBOX_BASE_BACKOFF", c.Outbox.BaseBackoff)
+	v.check(c.Outbox.MaxBackoff >= c.Outbox.BaseBackoff, "OUTBOX_MAX_BACKOFF", "must not be less than OUTBOX_BASE_BACKOFF")
+	v.positive("OUTBOX_RETENTION", c.Outbox.Retention)
+	v.positive("OUTBOX_CLEANUP_INTERVAL", c.Outbox.CleanupInterval)
+
+	v.check(c.Stream.Buffer > 0, "STREAM_BUFFER", "must be positive")
+	v.positive("STREAM_KEEPALIVE", c.Stream.KeepAlive)
+	v.check(c.Stream.ReplayLimit >= 0, "STREAM_REPLAY_LIMIT", "must not be negative")
+	v.positive("STREAM_RECONNECT_DELAY", c.Stream.ReconnectDelay)
+	v.positive("STREAM_POLL_INTERVAL", c.Stream.PollInterval)
+
+	v.positive("HEALTH_CHECK_TIMEOUT", c.Health.CheckTimeout)
+
+	if c.Tracing.Enabled {
+		v.check(c.Tracing.Endpoint != "", "OTEL_EXPORTER_OTLP_ENDPOINT", "must not be empty when tracing is enabled")
+	}
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+
+	if c.RateLimit.Enabled {
+		v.oneOf("RATE_LIMIT_STORE", c.RateLimit.Store, "memory", "postgres")
+		v.check(c.RateLimit.ReadRate > 0, "RATE_LIMIT_READ_RPS", "must be positive")
+		v.check(c.RateLimit.ReadBurst > 0, "RATE_LIMIT_READ_BURST", "must be positive")
+		v.check(c.RateLimit.WriteRate > 0, "RATE_LIMIT_WRITE_RPS", "must be positive")
+		v.check(c.RateLimit.WriteBurst > 0, "RATE_LIMIT_WRITE_BURST", "must be positive")
+	}
+
+	v.positive("RECURRENCE_POLL_INTERVAL", c.Recurrence.PollInterval)
+	v.check(c.Recurrence.BatchSize > 0, "RECURRENCE_BATCH_SIZE", "must be positive")
+
+	v.positive("REMINDER_POLL_INTERVAL", c.Reminder.PollInterval)
+	v.positive("REMINDER_TIMEOUT", c.Reminder.Timeout)
+	v.check(c.Reminder.BatchSize > 0, "REMINDER_BATCH_SIZE", "must be positive")
+	v.check(c.Reminder.MaxAttempts > 0, "REMINDER_MAX_ATTEMPTS", "must be positive")
+	v.positive("REMINDER_BASE_BACKOFF", c.Reminder.BaseBackoff)
+	v.check(c.Reminder.MaxBackoff >= c.Reminder.BaseBackoff, "REMINDER_MAX_BACKOFF", "must not be less than REMINDER_BASE_BACKOFF")
+	for _, channel := range c.Reminder.DefaultChannels {
+		v.oneOf("REMINDER_DEFAULT_CHANNELS", channel, "email", "webhook", "log")
+	}
+	smtp := c.Reminder.SMTP
+	if smtp.Host != "" {
+		v.port("SMTP_PORT", strconv.Itoa(smtp.Port))
+		v.check(smtp.From != "", "SMTP_FROM", "must not be empty when SMTP_HOST is set")
+		v.oneOf("SMTP_TLS", smtp.TLS, "starttls", "tls", "none")
+	} else {
+		v.check(!slices.Contains(c.Reminder.DefaultChannels, "email"), "REMINDER_DEFAULT_CHANNELS", "email requires SMTP_HOST")
+	}
+
+	v.check(c.Shutdown.DrainPeriod >= 0, "SHUTDOWN_DRAIN_PERIOD", "must not be negative")
+	v.positive("SHUTDOWN_TIMEOUT", c.Shutdown.Timeout)
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// validConfig - конфигурация, которая проходит проверку
+func validConfig(t *testing.T) *AppConfig {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, file, "TOKEN: secret\n")
+
+	cfg, err := load(source{file: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *AppConfig)
+		want   Errors
+	}{
+		{name: "valid", modify: func(cfg *AppConfig) {}},
+		{
+			name: "all violations are reported",
+			modify: func(cfg *AppConfig) {
+				cfg.Rest.ListenPort = "0"
+				cfg.Database.SSLMode = "bogus"
+				cfg.Webhook.MaxBackoff = cfg.Webhook.BaseBackoff - 1
+			},
+			want: Errors{
+				`LISTEN_PORT: must be a port number, got "0"`,
+				`DB_SSL_MODE: must be one of [disable allow prefer require verify-ca verify-full], got "bogus"`,
+				"WEBHOOK_MAX_BACKOFF: must not be less than WEBHOOK_BASE_BACKOFF",
+			},
+		},
+		{
+			name: "same ports",
+			modify: func(cfg *AppConfig) {
+				cfg.Grpc.ListenPort = cfg.Rest.ListenPort
+			},
+			want: Errors{"GRPC_LISTEN_PORT: must differ from LISTEN_PORT"},
+		},
+		{
+			name: "kafka without proxy",
+			modify: func(cfg *AppConfig) {
+				cfg.Outbox.Publishers = []string{"kafka", "queue"}
+				cfg.Outbox.KafkaTopicPrefix = "tasks/"
+			},
+			want: Errors{
+				`OUTBOX_PUBLISHERS: must be one of [webhook log kafka], got "queue"`,
+				`OUTBOX_KAFKA_REST_URL: must be an http(s) URL when OUTBOX_PUBLISHERS has kafka, got ""`,
+				`OUTBOX_KAFKA_TOPIC_PREFIX: may contain only letters, digits, '.', '_' and '-', got "tasks/"`,
+			},
+		},
+		{
+			name: "kafka",
+			modify: func(cfg *AppConfig) {
+				cfg.Outbox.Publishers = []string{"kafka"}
+				cfg.Outbox.KafkaRESTURL = "http://kafka-rest:8082"
+			},
+		},
+		{
+			name: "smtp",
+			modify: func(cfg *AppConfig) {
+				cfg.Reminder.SMTP.Host = "smtp.example.com"
+				cfg.Reminder.SMTP.TLS = "ssl"
+			},
+			want: Errors{
+				"SMTP_FROM: must not be empty when SMTP_HOST is set",
+				`SMTP_TLS: must be one of [starttls tls none], got "ssl"`,
+			},
+		},
+		{
+			name: "email without smtp",
+			modify: func(cfg *AppConfig) {
+				cfg.Reminder.DefaultChannels = []string{"email"}
+			},
+			want: Errors{"REMINDER_DEFAULT_CHANNELS: email requires SMTP_HOST"},
+		},
+		{
+			name: "tls key without cert",
+			modify: func(cfg *AppConfig) {
+				cfg.Rest.TLS.KeyFile = "server.key"
+			},
+			want: Errors{"TLS_KEY_FILE: TLS_CERT_FILE and TLS_KEY_FILE must be set together"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate = %v, want Errors", err)
+			}
+			if !slices.Equal(errs, tt.want) {
+				t.Errorf("errors = %q, want %q", errs, tt.want)
+			}
+		})
+	}
+}
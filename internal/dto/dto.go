@@ -1,7 +1,10 @@
 package dto
 
 import (
+	"restapi/pkg/validator"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
 )
 
 // Коды ошибок
@@ -10,6 +13,10 @@ const (
 	FieldIncorrect     = "FIELD_INCORRECT"
 	ServiceUnavailable = "SERVICE_UNAVAILABLE"
 	FieldNotFound      = "FIELD_NOT_FOUND"
+	Unauthorized       = "UNAUTHORIZED"
+	Forbidden          = "FORBIDDEN"
+	RateLimited        = "RATE_LIMITED"
+	Conflict           = "CONFLICT"
 	InternalError      = "Service is currently unavailable. Please try again later."
 )
 
@@ -22,8 +29,24 @@ type Response struct {
 
 // Error - структура ошибки
 type Error struct {
-	Code string `json:"code"`
-	Desc string `json:"desc"`
+	Code   string       `json:"code"`
+	Desc   string       `json:"desc"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError - нарушение правила валидации в поле запроса
+type FieldError struct {
+	// In - часть запроса: path, query или body
+	In      string `json:"in,omitempty"`
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Created - данные ответа на создание ресурса
+type Created struct {
+	ID int64 `json:"id"`
 }
 
 // BadResponseError - возвращает ошибку
@@ -37,6 +60,44 @@ func BadResponseError(ctx *fiber.Ctx, code, desc string) error {
 	})
 }
 
+// ValidationError - возвращает ошибку со списком всех нарушений
+func ValidationError(ctx *fiber.Ctx, desc string, fields []FieldError) error {
+	return ctx.Status(fiber.StatusBadRequest).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code:   FieldIncorrect,
+			Desc:   desc,
+			Fields: fields,
+		},
+	})
+}
+
+// InvalidRequestError - возвращает ошибку валидации тела запроса. Если err содержит
+// *validator.Errors, перечисляет все поля с сообщениями на языке из Accept-Language
+func InvalidRequestError(ctx *fiber.Ctx, err error) error {
+	var vErrors *validator.Errors
+	if !errors.As(err, &vErrors) {
+		return BadResponseError(ctx, FieldIncorrect, "Invalid request body")
+	}
+
+	return ValidationError(ctx, "Invalid request body", FieldErrors(vErrors.Fields(ctx.Get(fiber.HeaderAcceptLanguage))))
+}
+
+// FieldErrors - переводит нарушения валидатора в поля ответа
+func FieldErrors(vFields []validator.FieldError) []FieldError {
+	fields := make([]FieldError, 0, len(vFields))
+	for _, f := range vFields {
+		fields = append(fields, FieldError{
+			Field:   f.Field,
+			Rule:    f.Rule,
+			Param:   f.Param,
+			Message: f.Message,
+		})
+	}
+
+	return fields
+}
+
 // InternalServerError - возвращает ошибку
 func InternalServerError(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusInternalServerError).JSON(Response{
@@ -47,3 +108,58 @@ func InternalServerError(ctx *fiber.Ctx) error {
 		},
 	})
 }
+
+// NotFoundError - возвращает ошибку отсутствия ресурса
+func NotFoundError(ctx *fiber.Ctx, desc string) error {
+	return ctx.Status(fiber.StatusNotFound).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: FieldNotFound,
+			Desc: desc,
+		},
+	})
+}
+
+// ConflictError - возвращает ошибку противоречия текущему состоянию ресурса
+func ConflictError(ctx *fiber.Ctx, desc string) error {
+	return ctx.Status(fiber.StatusConflict).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: Conflict,
+			Desc: desc,
+		},
+	})
+}
+
+// UnauthorizedError - возвращает ошибку отсутствующего или неверного токена
+func UnauthorizedError(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusUnauthorized).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: Unauthorized,
+			Desc: "Invalid or missing bearer token",
+		},
+	})
+}
+
+// ForbiddenError - возвращает ошибку запроса к данным, недоступным вызывающему
+func ForbiddenError(ctx *fiber.Ctx, desc string) error {
+	return ctx.Status(fiber.StatusForbidden).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: Forbidden,
+			Desc: desc,
+		},
+	})
+}
+
+// TooManyRequestsError - возвращает ошибку превышения лимита запросов
+func TooManyRequestsError(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusTooManyRequests).JSON(Response{
+		Status: "error",
+		Error: &Error{
+			Code: RateLimited,
+			Desc: "Too many requests, retry later",
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package event
+
+import (
+	"time"
+)
+
+// Типы событий жизненного цикла задачи
+const (
+	TaskCreated       = "task.created"
+	TaskUpdated       = "task.updated"
+	TaskStatusChanged = "task.status_changed"
+	TaskDeleted       = "task.deleted"
+)
+
+// Types - все поддерживаемые типы событий
+var Types = []string{TaskCreated, TaskUpdated, TaskStatusChanged, TaskDeleted}
+
+// Event - событие задачи. ID присваивается при записи в outbox и возрастает
+// в порядке записи, но не фиксации транзакций: событие с меньшим ID может
+// стать видимым позже. Порядок ленты задает Position
+type Event struct {
+	ID         int64     `json:"id,omitempty"`
+	Type       string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       TaskData  `json:"data"`
+	// Position - место события в ленте, заполняется при чтении из outbox
+	Position Position `json:"-"`
+}
+
+// Position - место события в ленте: транзакция, записавшая событие, затем ID
+type Position struct {
+	TxID int64
+	ID   int64
+}
+
+// Less - позиция p в ленте раньше other
+func (p Position) Less(other Position) bool {
+	if p.TxID != other.TxID {
+		return p.TxID < other.TxID
+	}
+	return p.ID < other.ID
+}
+
+// TaskData - данные события задачи
+type TaskData struct {
+	Task           *Task  `json:"task"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+}
+
+// Task - состояние задачи в событии. Отделено от сущности хранилища,
+// чтобы формат событий не менялся вместе со схемой БД
+type Task struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// RecurrenceID - правило повторения, по которому создана задача
+	RecurrenceID *int64 `json:"recurrence_id,omitempty"`
+	// DueAt - срок задачи
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Rank - ключ порядка задачи в колонке доски
+	Rank      string    `json:"rank,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New создает событие задачи
+func New(eventType string, task *Task) Event {
+	return Event{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       TaskData{Task: task},
+	}
+}
+
+// Changed возвращает события обновления задачи: task.updated и,
+// если статус изменился, task.status_changed
+func Changed(previous, current *Task) []Event {
+	events := []Event{New(TaskUpdated, current)}
+
+	if previous.Status != current.Status {
+		e := New(TaskStatusChanged, current)
+		e.Data.PreviousStatus = previous.Status
+		events = append(events, e)
+	}
+
+	return events
+}
@@ -0,0 +1,72 @@
+package gql
+
+import (
+	"context"
+	"restapi/internal/event"
+	"restapi/internal/service"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// Ожидание ключей перед запросом порции
+const batchWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders - загрузчики одного запроса. Ключи, запрошенные резолверами
+// в пределах batchWait, загружаются одним запросом к репозиторию
+type loaders struct {
+	tasks  *dataloader.Loader[int64, service.Task]
+	events *dataloader.Loader[int64, []event.Event]
+}
+
+func newLoaders(svc service.TaskService) *loaders {
+	return &loaders{
+		tasks:  dataloader.NewBatchedLoader(taskBatch(svc), dataloader.WithWait[int64, service.Task](batchWait)),
+		events: dataloader.NewBatchedLoader(eventsBatch(svc), dataloader.WithWait[int64, []event.Event](batchWait)),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// taskBatch - загружает задачи порцией, отсутствующим задачам соответствует ErrNotFound
+func taskBatch(svc service.TaskService) dataloader.BatchFunc[int64, service.Task] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[service.Task] {
+		results := make([]*dataloader.Result[service.Task], len(ids))
+
+		tasks, err := svc.GetTasks(ctx, ids)
+		for i, id := range ids {
+			switch task, ok := tasks[id]; {
+			case err != nil:
+				results[i] = &dataloader.Result[service.Task]{Error: err}
+			case !ok:
+				results[i] = &dataloader.Result[service.Task]{Error: service.ErrNotFound}
+			default:
+				results[i] = &dataloader.Result[service.Task]{Data: task}
+			}
+		}
+
+		return results
+	}
+}
+
+// eventsBatch - загружает историю событий задач порцией
+func eventsBatch(svc service.TaskService) dataloader.BatchFunc[int64, []event.Event] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[[]event.Event] {
+		results := make([]*dataloader.Result[[]event.Event], len(ids))
+
+		events, err := svc.GetTaskEvents(ctx, ids)
+		for i, id := range ids {
+			results[i] = &dataloader.Result[[]event.Event]{Data: events[id], Error: err}
+		}
+
+		return results
+	}
+}
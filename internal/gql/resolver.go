@@ -0,0 +1,285 @@
+package gql
+
+import (
+	"context"
+	"restapi/internal/dto"
+	"restapi/internal/event"
+	"restapi/internal/logger"
+	"restapi/internal/service"
+	"restapi/pkg/validator"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// taskInput - поля задачи в мутациях
+type taskInput struct {
+	Title       string
+	Description string
+	Status      string
+	DueAt       *graphql.Time
+}
+
+// dueAt - срок из мутации, nil если не задан
+func (i taskInput) dueAt() *time.Time {
+	if i.DueAt == nil {
+		return nil
+	}
+
+	return &i.DueAt.Time
+}
+
+type resolver struct {
+	log     *zap.SugaredLogger
+	service service.TaskService
+}
+
+// Task - задача по id, null если задача не найдена
+func (r *resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := loadersFrom(ctx).tasks.Load(ctx, id)()
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, r.serviceError(ctx, err, "Error getting task")
+	}
+
+	return &taskResolver{task: task}, nil
+}
+
+// Tasks - страница задач с фильтром по статусу
+func (r *resolver) Tasks(ctx context.Context, args struct {
+	Status *[]string
+	Page   int32
+	Limit  *int32
+}) ([]*taskResolver, error) {
+	input := service.ListTasksInput{Page: int(args.Page)}
+	if args.Status != nil {
+		input.Statuses = *args.Status
+	}
+	if args.Limit != nil {
+		input.Limit = int(*args.Limit)
+	}
+
+	tasks, err := r.service.ListTasks(ctx, input)
+	if err != nil {
+		return nil, r.serviceError(ctx, err, "Error getting tasks")
+	}
+
+	resolvers := make([]*taskResolver, 0, len(tasks))
+	for _, task := range tasks {
+		resolvers = append(resolvers, &taskResolver{task: task})
+	}
+
+	return resolvers, nil
+}
+
+// CreateTask - создает задачу
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Input taskInput }) (*taskResolver, error) {
+	task, err := r.service.CreateTask(ctx, service.CreateTaskInput{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Status:      args.Input.Status,
+		DueAt:       args.Input.dueAt(),
+	})
+	if err != nil {
+		return nil, r.serviceError(ctx, err, "Error creating task")
+	}
+
+	return &taskResolver{task: task}, nil
+}
+
+// UpdateTask - обновляет задачу
+func (r *resolver) UpdateTask(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input taskInput
+}) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := r.service.UpdateTask(ctx, id, service.UpdateTaskInput{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Status:      args.Input.Status,
+		DueAt:       args.Input.dueAt(),
+	})
+	if err != nil {
+		return nil, r.serviceError(ctx, err, "Error updating task")
+	}
+
+	return &taskResolver{task: task}, nil
+}
+
+// DeleteTask - удаляет задачу
+func (r *resolver) DeleteTask(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.service.DeleteTask(ctx, id); err != nil {
+		return false, r.serviceError(ctx, err, "Error deleting task")
+	}
+
+	return true, nil
+}
+
+// serviceError - преобразует ошибку TaskService в ошибку GraphQL с кодом REST API.
+// Нарушения валидации перечисляются в extensions.fields на языке запроса
+func (r *resolver) serviceError(ctx context.Context, err error, msg string) error {
+	var validationErr *service.ValidationError
+	var vErrors *validator.Errors
+	switch {
+	case errors.As(err, &vErrors):
+		return &queryError{
+			code:   dto.FieldIncorrect,
+			desc:   vErrors.Error(),
+			fields: dto.FieldErrors(vErrors.Fields(validator.Language(ctx))),
+		}
+	case errors.As(err, &validationErr):
+		return newError(dto.FieldIncorrect, validationErr.Error())
+	case errors.Is(err, service.ErrNotFound):
+		return newError(dto.FieldNotFound, "Task not found")
+	case errors.Is(err, service.ErrWIPLimit):
+		return newError(dto.Conflict, "WIP limit of the column is reached")
+	default:
+		logger.FromContext(ctx, r.log).Errorf("%s: %v", msg, zap.Error(err))
+		return newError(dto.ServiceUnavailable, dto.InternalError)
+	}
+}
+
+type taskResolver struct {
+	task service.Task
+}
+
+func (t *taskResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(t.task.ID, 10))
+}
+
+func (t *taskResolver) Title() string {
+	return t.task.Title
+}
+
+func (t *taskResolver) Description() string {
+	return t.task.Description
+}
+
+func (t *taskResolver) Status() string {
+	return t.task.Status
+}
+
+func (t *taskResolver) RecurrenceID() *graphql.ID {
+	if t.task.RecurrenceID == nil {
+		return nil
+	}
+
+	id := graphql.ID(strconv.FormatInt(*t.task.RecurrenceID, 10))
+	return &id
+}
+
+func (t *taskResolver) DueAt() *graphql.Time {
+	if t.task.DueAt == nil {
+		return nil
+	}
+
+	return &graphql.Time{Time: *t.task.DueAt}
+}
+
+func (t *taskResolver) Rank() string {
+	return t.task.Rank
+}
+
+func (t *taskResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: t.task.CreatedAt}
+}
+
+func (t *taskResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: t.task.UpdatedAt}
+}
+
+// Events - история событий задачи, загружается порцией для всех задач ответа
+func (t *taskResolver) Events(ctx context.Context) ([]*eventResolver, error) {
+	events, err := loadersFrom(ctx).events.Load(ctx, t.task.ID)()
+	if err != nil {
+		return nil, newError(dto.ServiceUnavailable, dto.InternalError)
+	}
+
+	resolvers := make([]*eventResolver, 0, len(events))
+	for _, e := range events {
+		resolvers = append(resolvers, &eventResolver{event: e})
+	}
+
+	return resolvers, nil
+}
+
+type eventResolver struct {
+	event event.Event
+}
+
+func (e *eventResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(e.event.ID, 10))
+}
+
+func (e *eventResolver) Type() string {
+	return e.event.Type
+}
+
+func (e *eventResolver) OccurredAt() graphql.Time {
+	return graphql.Time{Time: e.event.OccurredAt}
+}
+
+func (e *eventResolver) Status() string {
+	return e.event.Data.Task.Status
+}
+
+func (e *eventResolver) PreviousStatus() *string {
+	if e.event.Data.PreviousStatus == "" {
+		return nil
+	}
+
+	return &e.event.Data.PreviousStatus
+}
+
+// parseID - разбирает id задачи
+func parseID(id graphql.ID) (int64, error) {
+	value, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || value <= 0 {
+		return 0, newError(dto.FieldBadFormat, "Invalid task id")
+	}
+
+	return value, nil
+}
+
+// queryError - ошибка GraphQL с кодом REST API в extensions.code
+type queryError struct {
+	code   string
+	desc   string
+	fields []dto.FieldError
+}
+
+func newError(code, desc string) error {
+	return &queryError{code: code, desc: desc}
+}
+
+func (e *queryError) Error() string {
+	return e.desc
+}
+
+func (e *queryError) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.code}
+	if len(e.fields) > 0 {
+		extensions["fields"] = e.fields
+	}
+
+	return extensions
+}
@@ -0,0 +1,216 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"restapi/internal/event"
+	"restapi/internal/service"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// fakeService - задачи в памяти. Запоминает вызовы порционной загрузки и входные
+// данные мутаций. Остальные методы не реализованы
+type fakeService struct {
+	service.TaskService
+
+	mu    sync.Mutex
+	tasks map[int64]service.Task
+	// batches - id задач в каждом вызове GetTasks
+	batches [][]int64
+	// eventBatches - id задач в каждом вызове GetTaskEvents
+	eventBatches [][]int64
+	created      service.CreateTaskInput
+	updated      service.UpdateTaskInput
+}
+
+func (s *fakeService) GetTasks(ctx context.Context, ids []int64) (map[int64]service.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, slices.Sorted(slices.Values(ids)))
+	result := make(map[int64]service.Task)
+	for _, id := range ids {
+		if task, ok := s.tasks[id]; ok {
+			result[id] = task
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeService) GetTaskEvents(ctx context.Context, ids []int64) (map[int64][]event.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.eventBatches = append(s.eventBatches, slices.Sorted(slices.Values(ids)))
+	result := make(map[int64][]event.Event)
+	for _, id := range ids {
+		result[id] = []event.Event{{ID: id * 10, Type: event.TaskCreated, Data: event.TaskData{Task: &event.Task{ID: id, Status: "new"}}}}
+	}
+	return result, nil
+}
+
+func (s *fakeService) ListTasks(ctx context.Context, input service.ListTasksInput) ([]service.Task, error) {
+	var tasks []service.Task
+	for _, id := range slices.Sorted(func(yield func(int64) bool) {
+		for id := range s.tasks {
+			if !yield(id) {
+				return
+			}
+		}
+	}) {
+		tasks = append(tasks, s.tasks[id])
+	}
+	return tasks, nil
+}
+
+func (s *fakeService) CreateTask(ctx context.Context, input service.CreateTaskInput) (service.Task, error) {
+	s.created = input
+	return service.Task{ID: 100, Title: input.Title, Status: input.Status, DueAt: input.DueAt}, nil
+}
+
+func (s *fakeService) UpdateTask(ctx context.Context, id int64, input service.UpdateTaskInput) (service.Task, error) {
+	s.updated = input
+	return service.Task{ID: id, Title: input.Title, Status: input.Status, DueAt: input.DueAt}, nil
+}
+
+// query выполняет запрос через обработчик и возвращает data и errors ответа
+func query(t *testing.T, svc *fakeService, q string) (map[string]json.RawMessage, []json.RawMessage) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/graphql", NewService(zap.NewNop().Sugar(), svc).Query)
+
+	body, err := json.Marshal(Request{Query: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []json.RawMessage          `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result.Data, result.Errors
+}
+
+func TestTaskFields(t *testing.T) {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	recurrenceID := int64(3)
+	svc := &fakeService{tasks: map[int64]service.Task{
+		1: {ID: 1, Title: "scheduled", Status: "new", DueAt: &due, RecurrenceID: &recurrenceID, Rank: "a0"},
+		2: {ID: 2, Title: "plain", Status: "done", Rank: "a1"},
+	}}
+
+	data, errs := query(t, svc, `{
+		a: task(id: 1) { id dueAt recurrenceId rank }
+		b: task(id: 2) { id dueAt recurrenceId rank }
+	}`)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %s", errs)
+	}
+
+	tests := []struct {
+		alias string
+		want  string
+	}{
+		{alias: "a", want: `{"id":"1","dueAt":"2026-05-01T09:00:00Z","recurrenceId":"3","rank":"a0"}`},
+		{alias: "b", want: `{"id":"2","dueAt":null,"recurrenceId":null,"rank":"a1"}`},
+	}
+	for _, tt := range tests {
+		if got := string(data[tt.alias]); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.alias, got, tt.want)
+		}
+	}
+}
+
+func TestMutationDueAt(t *testing.T) {
+	svc := &fakeService{}
+
+	data, errs := query(t, svc, `mutation {
+		created: createTask(input: {title: "t", description: "d", status: new, dueAt: "2026-05-01T09:00:00Z"}) { id dueAt }
+		updated: updateTask(id: 7, input: {title: "t", description: "d", status: done}) { id dueAt }
+	}`)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %s", errs)
+	}
+
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	if svc.created.DueAt == nil || !svc.created.DueAt.Equal(due) {
+		t.Errorf("created due = %v, want %v", svc.created.DueAt, due)
+	}
+	if got := string(data["created"]); got != `{"id":"100","dueAt":"2026-05-01T09:00:00Z"}` {
+		t.Errorf("created = %s", got)
+	}
+
+	// Без dueAt срок не меняется
+	if svc.updated.DueAt != nil {
+		t.Errorf("updated due = %v, want unchanged", svc.updated.DueAt)
+	}
+}
+
+func TestTaskLoaderBatches(t *testing.T) {
+	svc := &fakeService{tasks: map[int64]service.Task{}}
+	for id := int64(1); id <= 5; id++ {
+		svc.tasks[id] = service.Task{ID: id, Status: "new"}
+	}
+
+	// Пять полей task и отсутствующая задача загружаются одним запросом
+	data, errs := query(t, svc, `{
+		t1: task(id: 1) { id }
+		t2: task(id: 2) { id }
+		t3: task(id: 3) { id }
+		t4: task(id: 4) { id }
+		t5: task(id: 5) { id }
+		t6: task(id: 6) { id }
+		again: task(id: 1) { id }
+	}`)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %s", errs)
+	}
+
+	if want := [][]int64{{1, 2, 3, 4, 5, 6}}; !slices.EqualFunc(svc.batches, want, slices.Equal) {
+		t.Errorf("GetTasks calls = %v, want %v", svc.batches, want)
+	}
+	if string(data["t6"]) != "null" || string(data["again"]) != `{"id":"1"}` {
+		t.Errorf("t6 = %s, again = %s, want null and task 1", data["t6"], data["again"])
+	}
+}
+
+func TestEventsLoaderBatches(t *testing.T) {
+	svc := &fakeService{tasks: map[int64]service.Task{
+		1: {ID: 1, Status: "new"},
+		2: {ID: 2, Status: "new"},
+		3: {ID: 3, Status: "new"},
+	}}
+
+	data, errs := query(t, svc, `{ tasks { id events { id type } } }`)
+	if len(errs) > 0 {
+		t.Fatalf("errors = %s", errs)
+	}
+
+	if want := [][]int64{{1, 2, 3}}; !slices.EqualFunc(svc.eventBatches, want, slices.Equal) {
+		t.Errorf("GetTaskEvents calls = %v, want %v", svc.eventBatches, want)
+	}
+	if !strings.Contains(string(data["tasks"]), `"events":[{"id":"30","type":"task.created"}]`) {
+		t.Errorf("tasks = %s, want events of task 3", data["tasks"])
+	}
+}
@@ -0,0 +1,62 @@
+package gql
+
+import (
+	_ "embed"
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/service"
+	"restapi/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Request - тело запроса GraphQL
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type gqlService struct {
+	log     *zap.SugaredLogger
+	service service.TaskService
+	schema  *graphql.Schema
+}
+
+// Service - интерфейс GraphQL API
+type Service interface {
+	Query(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, svc service.TaskService) Service {
+	schema := graphql.MustParseSchema(schemaSDL, &resolver{log: log, service: svc})
+
+	return &gqlService{
+		log:     log,
+		service: svc,
+		schema:  schema,
+	}
+}
+
+// Query - выполняет запрос GraphQL. Ошибки выполнения возвращаются в поле errors
+// ответа GraphQL со статусом 200
+func (s *gqlService) Query(ctx *fiber.Ctx) error {
+	var req Request
+
+	if err := ctx.BodyParser(&req); err != nil || req.Query == "" {
+		logger.FromContext(ctx.UserContext(), s.log).Error("Invalid GraphQL request body")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	resolveCtx := withLoaders(ctx.UserContext(), newLoaders(s.service))
+	resolveCtx = validator.WithLanguage(resolveCtx, ctx.Get(fiber.HeaderAcceptLanguage))
+
+	resp := s.schema.Exec(resolveCtx, req.Query, req.OperationName, req.Variables)
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,180 @@
+package health
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/dto"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Статусы проверок
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown - сервис завершает работу и не принимает новые запросы
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// Check - проверка зависимости, nil означает, что зависимость работает
+type Check func(ctx context.Context) error
+
+// Result - результат одной проверки
+type Result struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report - результаты всех проверок по именам
+type Report struct {
+	Checks map[string]Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Service - интерфейс проверок состояния для оркестратора
+type Service interface {
+	Live(ctx *fiber.Ctx) error
+	Ready(ctx *fiber.Ctx) error
+}
+
+// Checker выполняет проверки живости и готовности. Живость говорит, что процесс
+// не завис и его не нужно перезапускать, готовность - что он может принимать запросы
+type Checker struct {
+	log          *zap.SugaredLogger
+	cfg          config.Health
+	mu           sync.RWMutex
+	liveness     []namedCheck
+	readiness    []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает набор проверок. Готовность всегда включает проверку завершения работы
+func NewChecker(log *zap.SugaredLogger, cfg config.Health) *Checker {
+	c := &Checker{
+		log: log,
+		cfg: cfg,
+	}
+	c.AddReadiness("shutdown", c.checkShutdown)
+
+	return c
+}
+
+// AddLiveness добавляет проверку живости
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+// AddReadiness добавляет проверку готовности
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+}
+
+// Shutdown переводит сервис в состояние завершения: готовность перестает проходить,
+// чтобы балансировщик снял экземпляр до остановки сервера
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) checkShutdown(context.Context) error {
+	if c.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+
+	return nil
+}
+
+// Live - проверка живости, GET /healthz
+func (c *Checker) Live(ctx *fiber.Ctx) error {
+	c.mu.RLock()
+	checks := c.liveness
+	c.mu.RUnlock()
+
+	return c.respond(ctx, checks, "Service is not alive")
+}
+
+// Ready - проверка готовности, GET /readyz
+func (c *Checker) Ready(ctx *fiber.Ctx) error {
+	c.mu.RLock()
+	checks := c.readiness
+	c.mu.RUnlock()
+
+	return c.respond(ctx, checks, "Service is not ready")
+}
+
+// respond выполняет проверки и отвечает 200 или 503 с результатами в data
+func (c *Checker) respond(ctx *fiber.Ctx, checks []namedCheck, desc string) error {
+	report, ok := c.run(ctx.UserContext(), checks)
+	if ok {
+		return ctx.Status(fiber.StatusOK).JSON(dto.Response{
+			Status: "success",
+			Data:   report,
+		})
+	}
+
+	return ctx.Status(fiber.StatusServiceUnavailable).JSON(dto.Response{
+		Status: "error",
+		Error: &dto.Error{
+			Code: dto.ServiceUnavailable,
+			Desc: desc,
+		},
+		Data: report,
+	})
+}
+
+// run выполняет проверки параллельно, каждую не дольше HEALTH_CHECK_TIMEOUT
+func (c *Checker) run(ctx context.Context, checks []namedCheck) (Report, bool) {
+	report := Report{Checks: make(map[string]Result, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+		ok = true
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.cfg.CheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(checkCtx)
+			result := Result{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				ok = false
+				if !errors.Is(err, ErrShuttingDown) {
+					c.log.Warnf("Health check %s failed: %v", nc.name, err)
+				}
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report, ok
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"restapi/internal/config"
+	"restapi/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// healthResponse - ответ проверки с отчетом в data
+type healthResponse struct {
+	Status string     `json:"status"`
+	Error  *dto.Error `json:"error"`
+	Data   Report     `json:"data"`
+}
+
+func newTestChecker() *Checker {
+	return NewChecker(zap.NewNop().Sugar(), config.Health{CheckTimeout: 20 * time.Millisecond})
+}
+
+func get(t *testing.T, c *Checker, path string) (int, healthResponse) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Get("/healthz", c.Live)
+	app.Get("/readyz", c.Ready)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, body
+}
+
+func ok(context.Context) error {
+	return nil
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   map[string]Check
+		shutdown bool
+		want     int
+		// wantFailed - ошибки проваленных проверок по именам
+		wantFailed map[string]string
+	}{
+		{
+			name:   "all dependencies ok",
+			checks: map[string]Check{"postgres": ok, "outbox": ok},
+			want:   fiber.StatusOK,
+		},
+		{
+			name: "failed dependency",
+			checks: map[string]Check{
+				"postgres": func(context.Context) error { return errors.New("connection refused") },
+				"outbox":   ok,
+			},
+			want:       fiber.StatusServiceUnavailable,
+			wantFailed: map[string]string{"postgres": "connection refused"},
+		},
+		{
+			name: "check timeout",
+			checks: map[string]Check{
+				"postgres": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			want:       fiber.StatusServiceUnavailable,
+			wantFailed: map[string]string{"postgres": context.DeadlineExceeded.Error()},
+		},
+		{
+			name:       "shutting down",
+			checks:     map[string]Check{"postgres": ok},
+			shutdown:   true,
+			want:       fiber.StatusServiceUnavailable,
+			wantFailed: map[string]string{"shutdown": ErrShuttingDown.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestChecker()
+			for name, check := range tt.checks {
+				c.AddReadiness(name, check)
+			}
+			if tt.shutdown {
+				c.Shutdown()
+			}
+
+			status, body := get(t, c, "/readyz")
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d", status, tt.want)
+			}
+			if tt.want == fiber.StatusServiceUnavailable && (body.Error == nil || body.Error.Code != dto.ServiceUnavailable) {
+				t.Errorf("error = %+v, want %s", body.Error, dto.ServiceUnavailable)
+			}
+
+			// В отчете все проверки, включая shutdown
+			if len(body.Data.Checks) != len(tt.checks)+1 {
+				t.Errorf("checks = %v, want %d", body.Data.Checks, len(tt.checks)+1)
+			}
+			for name, result := range body.Data.Checks {
+				wantErr, failed := tt.wantFailed[name]
+				switch {
+				case failed && (result.Status != StatusFail || result.Error != wantErr):
+					t.Errorf("%s = %+v, want fail with %q", name, result, wantErr)
+				case !failed && (result.Status != StatusOK || result.Error != ""):
+					t.Errorf("%s = %+v, want ok", name, result)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveHeartbeat(t *testing.T) {
+	c := newTestChecker()
+	heartbeat := NewHeartbeat(time.Second)
+	c.AddLiveness("outbox", heartbeat.Check)
+
+	if status, _ := get(t, c, "/healthz"); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+
+	// Обработчик не отмечался дольше минуты
+	heartbeat.last.Store(time.Now().Add(-2 * minHeartbeatAge).UnixNano())
+	status, body := get(t, c, "/healthz")
+	if status != fiber.StatusServiceUnavailable || body.Data.Checks["outbox"].Status != StatusFail {
+		t.Errorf("status = %d, outbox = %+v, want %d and fail", status, body.Data.Checks["outbox"], fiber.StatusServiceUnavailable)
+	}
+
+	// Завершение работы не влияет на живость
+	heartbeat.Beat()
+	c.Shutdown()
+	if status, _ := get(t, c, "/healthz"); status != fiber.StatusOK {
+		t.Errorf("status after shutdown = %d, want %d", status, fiber.StatusOK)
+	}
+}
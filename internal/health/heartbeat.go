@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minHeartbeatAge - обработчик с коротким периодом не считается зависшим
+// из-за одной долгой итерации
+const minHeartbeatAge = time.Minute
+
+// Heartbeat - отметка живости фонового обработчика
+type Heartbeat struct {
+	last   atomic.Int64
+	maxAge time.Duration
+}
+
+// NewHeartbeat создает отметку для обработчика с периодом interval. Обработчик
+// считается зависшим после трех пропущенных периодов, но не раньше чем через минуту
+func NewHeartbeat(interval time.Duration) *Heartbeat {
+	maxAge := 3 * interval
+	if maxAge < minHeartbeatAge {
+		maxAge = minHeartbeatAge
+	}
+
+	h := &Heartbeat{maxAge: maxAge}
+	h.Beat()
+
+	return h
+}
+
+// Beat отмечает, что обработчик работает
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Check - проверка живости: последняя отметка не старше допустимого
+func (h *Heartbeat) Check(context.Context) error {
+	age := time.Since(time.Unix(0, h.last.Load()))
+	if age > h.maxAge {
+		return errors.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+
+	return nil
+}
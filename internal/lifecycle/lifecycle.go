@@ -0,0 +1,170 @@
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"restapi/internal/config"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Phase - этап остановки сервиса
+type Phase int
+
+// Этапы остановки в порядке выполнения
+const (
+	// PhaseDrain - снять экземпляр с балансировки, после него выдерживается SHUTDOWN_DRAIN_PERIOD
+	PhaseDrain Phase = iota
+	// PhaseServers - прекратить прием запросов и дождаться текущих
+	PhaseServers
+	// PhaseWorkers - остановить фоновые обработчики, запущенные через Go
+	PhaseWorkers
+	// PhaseResources - сбросить буферы и закрыть соединения
+	PhaseResources
+)
+
+var phaseNames = map[Phase]string{
+	PhaseDrain:     "drain",
+	PhaseServers:   "servers",
+	PhaseWorkers:   "workers",
+	PhaseResources: "resources",
+}
+
+// hook - действие при остановке
+type hook struct {
+	phase Phase
+	name  string
+	stop  func(ctx context.Context) error
+}
+
+// Manager запускает фоновые обработчики и останавливает компоненты по этапам.
+// Внутри этапа действия выполняются в порядке добавления
+type Manager struct {
+	log     *zap.SugaredLogger
+	cfg     config.Shutdown
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	hooks   []hook
+	failed  chan error
+	signals chan os.Signal
+}
+
+// New создает менеджер жизненного цикла. SIGINT и SIGTERM перехватываются сразу,
+// чтобы сигнал во время запуска тоже приводил к штатной остановке
+func New(log *zap.SugaredLogger, cfg config.Shutdown) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &Manager{
+		log:     log,
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		failed:  make(chan error, 1),
+		signals: make(chan os.Signal, 1),
+	}
+	signal.Notify(m.signals, os.Interrupt, syscall.SIGTERM)
+
+	return m
+}
+
+// Go запускает фоновый обработчик. Контекст отменяется на этапе PhaseWorkers,
+// после чего менеджер ждет возврата из run
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		run(m.ctx)
+		m.log.Debugf("Worker %s stopped", name)
+	}()
+}
+
+// OnStop добавляет действие на этап phase
+func (m *Manager) OnStop(phase Phase, name string, stop func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, hook{phase: phase, name: name, stop: stop})
+}
+
+// Fail сообщает о неустранимой ошибке компонента, например сервера, который
+// не смог начать прием соединений. Wait завершается, и сервис останавливается
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Wait ждет SIGINT, SIGTERM или ошибку из Fail. Возвращает ошибку из Fail
+func (m *Manager) Wait() error {
+	defer signal.Stop(m.signals)
+
+	select {
+	case sig := <-m.signals:
+		m.log.Infof("Received %s, shutting down", sig)
+		return nil
+	case err := <-m.failed:
+		m.log.Errorf("Shutting down after failure: %v", err)
+		return err
+	}
+}
+
+// Shutdown выполняет этапы остановки. Каждый этап после PhaseDrain ограничен
+// SHUTDOWN_TIMEOUT; ошибки не прерывают остановку, а возвращаются вместе
+func (m *Manager) Shutdown() error {
+	var errs []string
+
+	for _, phase := range []Phase{PhaseDrain, PhaseServers, PhaseWorkers, PhaseResources} {
+		ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+
+		if phase == PhaseWorkers {
+			if err := m.stopWorkers(ctx); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+
+		for _, h := range m.hooks {
+			if h.phase != phase {
+				continue
+			}
+			if err := h.stop(ctx); err != nil {
+				m.log.Errorf("Error stopping %s: %v", h.name, err)
+				errs = append(errs, h.name+": "+err.Error())
+			}
+		}
+		cancel()
+		m.log.Infof("Shutdown phase %s done", phaseNames[phase])
+
+		if phase == PhaseDrain && m.cfg.DrainPeriod > 0 {
+			m.log.Infof("Draining for %s", m.cfg.DrainPeriod)
+			time.Sleep(m.cfg.DrainPeriod)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Errorf("shutdown failed: %v", errs)
+	}
+
+	return nil
+}
+
+// stopWorkers отменяет контекст обработчиков и ждет их завершения
+func (m *Manager) stopWorkers(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("workers did not stop in time")
+	}
+}
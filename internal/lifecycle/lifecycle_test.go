@@ -0,0 +1,164 @@
+package lifecycle
+
+import (
+	"context"
+	"restapi/internal/config"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// recorder - порядок остановки компонентов
+type recorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *recorder) add(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) hook(step string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.add(step)
+		return nil
+	}
+}
+
+func newTestManager(cfg config.Shutdown) *Manager {
+	return New(zap.NewNop().Sugar(), cfg)
+}
+
+func TestShutdownPhaseOrder(t *testing.T) {
+	m := newTestManager(config.Shutdown{Timeout: time.Second})
+	r := &recorder{}
+
+	// Действия добавляются не по порядку этапов
+	m.OnStop(PhaseResources, "db", r.hook("resources: db"))
+	m.OnStop(PhaseServers, "http", r.hook("servers: http"))
+	m.OnStop(PhaseWorkers, "flush", r.hook("workers: flush"))
+	m.OnStop(PhaseDrain, "readiness", r.hook("drain: readiness"))
+	m.OnStop(PhaseServers, "grpc", r.hook("servers: grpc"))
+	m.OnStop(PhaseResources, "logger", r.hook("resources: logger"))
+	m.Go("outbox", func(ctx context.Context) {
+		<-ctx.Done()
+		r.add("worker: outbox")
+	})
+
+	if err := m.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"drain: readiness",
+		"servers: http",
+		"servers: grpc",
+		"worker: outbox",
+		"workers: flush",
+		"resources: db",
+		"resources: logger",
+	}
+	if !slices.Equal(r.steps, want) {
+		t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(r.steps, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestShutdownPhaseDeadlines(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	m := newTestManager(config.Shutdown{Timeout: timeout})
+
+	// Зависший сервер исчерпывает только свой этап
+	m.OnStop(PhaseServers, "http", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var resourcesErr error
+	var resourcesLeft time.Duration
+	m.OnStop(PhaseResources, "db", func(ctx context.Context) error {
+		resourcesErr = ctx.Err()
+		deadline, _ := ctx.Deadline()
+		resourcesLeft = time.Until(deadline)
+		return errors.New("connection reset")
+	})
+
+	err := m.Shutdown()
+	if err == nil {
+		t.Fatal("Shutdown = nil, want errors of http and db")
+	}
+	for _, want := range []string{"http: context deadline exceeded", "db: connection reset"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Shutdown = %v, want %q", err, want)
+		}
+	}
+
+	if resourcesErr != nil || resourcesLeft <= 0 || resourcesLeft > timeout {
+		t.Errorf("resources phase context: err %v, %s left, want fresh deadline of %s", resourcesErr, resourcesLeft, timeout)
+	}
+}
+
+func TestShutdownWorkersTimeout(t *testing.T) {
+	m := newTestManager(config.Shutdown{Timeout: 20 * time.Millisecond})
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	m.Go("stuck", func(ctx context.Context) {
+		<-release
+	})
+
+	closed := false
+	m.OnStop(PhaseResources, "db", func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown()
+	if err == nil || !strings.Contains(err.Error(), "workers did not stop in time") {
+		t.Errorf("Shutdown = %v, want workers timeout", err)
+	}
+	if !closed {
+		t.Error("resources were not closed after workers timeout")
+	}
+}
+
+func TestShutdownDrainPeriod(t *testing.T) {
+	const drain = 30 * time.Millisecond
+	m := newTestManager(config.Shutdown{Timeout: time.Second, DrainPeriod: drain})
+
+	var drained, stopped time.Time
+	m.OnStop(PhaseDrain, "readiness", func(ctx context.Context) error {
+		drained = time.Now()
+		return nil
+	})
+	m.OnStop(PhaseServers, "http", func(ctx context.Context) error {
+		stopped = time.Now()
+		return nil
+	})
+
+	if err := m.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if gap := stopped.Sub(drained); gap < drain {
+		t.Errorf("servers stopped %s after drain, want at least %s", gap, drain)
+	}
+}
+
+func TestWaitFail(t *testing.T) {
+	m := newTestManager(config.Shutdown{Timeout: time.Second})
+
+	failure := errors.New("listen tcp :8080: address already in use")
+	m.Fail(failure)
+	// Повторная ошибка не блокирует компонент
+	m.Fail(errors.New("second failure"))
+
+	if err := m.Wait(); err != failure {
+		t.Errorf("Wait = %v, want %v", err, failure)
+	}
+}
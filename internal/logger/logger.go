@@ -1,7 +1,13 @@
 package logger
 
 import (
+	"os"
+	"restapi/internal/config"
+	"syscall"
+	"time"
+
 	"github.com/pkg/errors"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -9,26 +15,62 @@ import (
 
 const tsKey = "timestamp"
 
-func NewLogger(level string) (*zap.SugaredLogger, error) {
-	logLevel, err := zap.ParseAtomicLevel(level)
+// NewLogger создает JSON-логгер в stdout и, если задан LOG_FILE, в файл с ротацией.
+// Возвращает уровень, который можно менять без перезапуска
+func NewLogger(cfg config.Log) (*zap.SugaredLogger, zap.AtomicLevel, error) {
+	logLevel, err := zap.ParseAtomicLevel(cfg.Level)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error ParseAtomicLevel %s", level)
+		return nil, logLevel, errors.Wrapf(err, "error ParseAtomicLevel %s", cfg.Level)
 	}
 
-	logger, err := zap.Config{
-		Level:       logLevel,
-		Encoding:    "json",
-		OutputPaths: []string{"stdout"},
-		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey: "message",
-			TimeKey:    tsKey,
-			EncodeTime: zapcore.RFC3339NanoTimeEncoder,
-		},
-		DisableStacktrace: true,
-	}.Build()
-	if err != nil {
-		return nil, errors.Wrap(err, "error logConfig.Build")
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:  "message",
+		LevelKey:    "level",
+		TimeKey:     tsKey,
+		EncodeLevel: zapcore.LowercaseLevelEncoder,
+		EncodeTime:  zapcore.RFC3339NanoTimeEncoder,
+	})
+
+	output := zapcore.Lock(os.Stdout)
+	if cfg.File != "" {
+		output = zapcore.NewMultiWriteSyncer(output, zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		}))
+	}
+
+	core := zapcore.NewCore(encoder, output, logLevel)
+	if cfg.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+
+	return zap.New(core, zap.ErrorOutput(zapcore.Lock(os.Stderr))).Sugar(), logLevel, nil
+}
+
+// NewConsole создает логгер для команд администрирования: текст в stderr,
+// чтобы не смешиваться с выводом команды в stdout
+func NewConsole(level zapcore.Level) *zap.SugaredLogger {
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		MessageKey:  "message",
+		LevelKey:    "level",
+		TimeKey:     tsKey,
+		EncodeLevel: zapcore.CapitalLevelEncoder,
+		EncodeTime:  zapcore.ISO8601TimeEncoder,
+	})
+
+	return zap.New(zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), level)).Sugar()
+}
+
+// Sync сбрасывает буферы логгера. Ошибки sync для терминала и каналов, которые
+// не поддерживают fsync, не считаются ошибками
+func Sync(log *zap.SugaredLogger) error {
+	err := log.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
 	}
 
-	return logger.Sugar(), nil
+	return err
 }
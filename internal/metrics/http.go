@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// unmatchedRoute - метка запросов, не попавших ни в один маршрут
+const unmatchedRoute = "unmatched"
+
+// Middleware учитывает запросы по шаблону маршрута (/v1/tasks/:id), а не по пути,
+// чтобы число рядов не зависело от id в запросах. Шаблон возвращает route до маршрутизации:
+// ответ middleware группы (401 авторизации, 429 лимита) тоже учитывается по маршруту
+func Middleware(route func(method, path string) (string, bool)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		// Строки Fiber ссылаются на буфер запроса, метка должна пережить запрос
+		method := utils.CopyString(ctx.Method())
+		label, ok := route(method, ctx.Path())
+		if !ok {
+			label = unmatchedRoute
+		}
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		labels := []string{method, label, strconv.Itoa(status)}
+		httpRequests.WithLabelValues(labels...).Inc()
+		httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
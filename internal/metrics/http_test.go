@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+// testRoutes - шаблоны маршрутов тестового приложения, как их знает документ OpenAPI
+var testRoutes = map[string]string{
+	"GET /v1/tasks/1":  "/v1/tasks/:id",
+	"GET /v1/tasks/2":  "/v1/tasks/:id",
+	"GET /v1/tasks/42": "/v1/tasks/:id",
+}
+
+func testRoute(method, path string) (string, bool) {
+	template, ok := testRoutes[method+" "+path]
+	return template, ok
+}
+
+// newTestApp - приложение как в API: метрики, группа /v1 с авторизацией и /metrics
+func newTestApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Middleware(testRoute))
+	app.Get("/metrics", Handler())
+
+	v1 := app.Group("/v1", func(ctx *fiber.Ctx) error {
+		if ctx.Get(fiber.HeaderAuthorization) == "" {
+			return ctx.SendStatus(fiber.StatusUnauthorized)
+		}
+		return ctx.Next()
+	})
+	v1.Get("/tasks/:id", func(ctx *fiber.Ctx) error {
+		switch ctx.Params("id") {
+		case "2":
+			return fiber.NewError(fiber.StatusConflict, "conflict")
+		case "42":
+			return errors.New("connection refused")
+		}
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	return app
+}
+
+// requests - значение restapi_http_requests_total с метками method, route и status
+func requests(t *testing.T, app *fiber.App, method, route string, status int) float64 {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	series := `restapi_http_requests_total{method="` + method + `",route="` + route + `",status="` + strconv.Itoa(status) + `"} `
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return 0
+}
+
+func TestMiddlewareRouteLabels(t *testing.T) {
+	app := newTestApp()
+
+	tests := []struct {
+		name       string
+		path       string
+		auth       bool
+		wantRoute  string
+		wantStatus int
+	}{
+		{name: "route template instead of path", path: "/v1/tasks/1", auth: true, wantRoute: "/v1/tasks/:id", wantStatus: fiber.StatusOK},
+		{name: "fiber error status", path: "/v1/tasks/2", auth: true, wantRoute: "/v1/tasks/:id", wantStatus: fiber.StatusConflict},
+		{name: "handler error", path: "/v1/tasks/42", auth: true, wantRoute: "/v1/tasks/:id", wantStatus: fiber.StatusInternalServerError},
+		{name: "rejected by group middleware", path: "/v1/tasks/1", wantRoute: "/v1/tasks/:id", wantStatus: fiber.StatusUnauthorized},
+		{name: "unknown path in group", path: "/v1/unknown", auth: true, wantRoute: unmatchedRoute, wantStatus: fiber.StatusNotFound},
+		{name: "unknown path", path: "/unknown/7", wantRoute: unmatchedRoute, wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := requests(t, app, fiber.MethodGet, tt.wantRoute, tt.wantStatus)
+
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			if tt.auth {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer token")
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if got := requests(t, app, fiber.MethodGet, tt.wantRoute, tt.wantStatus) - before; got != 1 {
+				t.Errorf("requests{route=%q,status=%d} grew by %v, want 1", tt.wantRoute, tt.wantStatus, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareNoPathLabels(t *testing.T) {
+	app := newTestApp()
+
+	for _, path := range []string{"/v1/tasks/1", "/unknown/7"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// Путь с id не становится меткой, иначе число рядов растет с числом задач
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, `route="/v1/tasks/1"`) || strings.Contains(line, `route="/unknown/7"`) {
+			t.Errorf("path used as label: %s", line)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"restapi/internal/event"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "restapi"
+
+// Registry - реестр метрик сервиса, отдается по /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository method latency.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"method"})
+
+	tasksCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Tasks created.",
+	})
+
+	taskEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_events_total",
+		Help:      "Task events written to the outbox by type.",
+	}, []string{"event"})
+
+	statusTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_status_transitions_total",
+		Help:      "Task status changes by previous and new status.",
+	}, []string{"from", "to"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts by result.",
+	}, []string{"result"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminder_notifications_total",
+		Help:      "Reminder notifications by channel and result.",
+	}, []string{"channel", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		queryDuration,
+		tasksCreated,
+		taskEvents,
+		statusTransitions,
+		webhookDeliveries,
+		notifications,
+	)
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveQuery учитывает длительность метода репозитория, вызывается через defer
+func ObserveQuery(method string, start time.Time) {
+	queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveEvents учитывает события задач, записанные в outbox
+func ObserveEvents(events ...event.Event) {
+	for _, e := range events {
+		taskEvents.WithLabelValues(e.Type).Inc()
+
+		switch e.Type {
+		case event.TaskCreated:
+			tasksCreated.Inc()
+		case event.TaskStatusChanged:
+			statusTransitions.WithLabelValues(e.Data.PreviousStatus, e.Data.Task.Status).Inc()
+		}
+	}
+}
+
+// ObserveDelivery учитывает попытку доставки вебхука: success, retry или failed
+func ObserveDelivery(result string) {
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+// ObserveNotification учитывает отправку напоминания в канал: success или error
+func ObserveNotification(channel, result string) {
+	notifications.WithLabelValues(channel, result).Inc()
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"restapi/internal/dto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+const jsonContent = fiber.MIMEApplicationJSON
+
+// Operation - описание маршрута API. Путь записывается в стиле Fiber (/v1/tasks/:id),
+// параметры пути по умолчанию считаются целыми числами
+type Operation struct {
+	Method  string
+	Path    string
+	ID      string
+	Summary string
+	Tag     string
+	// PathParams - типы параметров пути, отличные от integer (например, string)
+	PathParams map[string]string
+	Query      []Param
+	// Request - тип тела запроса JSON, nil - без тела
+	Request any
+	// Status - код успешного ответа, по умолчанию 200
+	Status int
+	// Response - тип поля data ответа dto.Response, nil - ответ без data
+	Response any
+	// Raw - Response описывает все тело ответа, а не поле data
+	Raw bool
+	// ContentType - тип содержимого успешного ответа, по умолчанию application/json
+	ContentType string
+	// Public - маршрут доступен без авторизации
+	Public bool
+	// Errors - коды ошибок операции помимо общих (400, 401, 404, 500)
+	Errors []int
+}
+
+// Param - параметр строки запроса
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Enum        []string
+}
+
+// Build строит документ по таблице маршрутов Fiber и описаниям операций.
+// Маршрут без описания и описание без маршрута считаются расхождением и возвращаются ошибкой
+func Build(info Info, routes []fiber.Route, ops []Operation) (*Document, error) {
+	if err := Verify(routes, ops); err != nil {
+		return nil, err
+	}
+
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     info,
+		Paths:    make(map[string]PathItem),
+		Security: []map[string][]string{{"bearerAuth": {}}},
+	}
+
+	errorResponse := ResponseObject{
+		Description: "Error",
+		Content:     map[string]MediaType{jsonContent: {Schema: g.Of(dto.Response{})}},
+	}
+
+	for _, op := range ops {
+		path, params := convertPath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+
+		obj := &OperationObject{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Responses:   make(map[string]ResponseObject),
+		}
+		if op.Tag != "" {
+			obj.Tags = []string{op.Tag}
+		}
+		if op.Public {
+			obj.Security = &[]map[string][]string{}
+		}
+
+		for _, name := range params {
+			schema := &Schema{Type: "integer", Format: "int64"}
+			if typ, ok := op.PathParams[name]; ok {
+				schema = &Schema{Type: typ}
+			}
+			obj.Parameters = append(obj.Parameters, Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   schema,
+			})
+		}
+
+		for _, q := range op.Query {
+			schema := &Schema{Type: q.Type}
+			for _, value := range q.Enum {
+				schema.Enum = append(schema.Enum, value)
+			}
+			obj.Parameters = append(obj.Parameters, Parameter{
+				Name:        q.Name,
+				In:          "query",
+				Description: q.Description,
+				Schema:      schema,
+			})
+		}
+
+		if op.Request != nil {
+			obj.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{jsonContent: {Schema: g.Of(op.Request)}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		obj.Responses[strconv.Itoa(status)] = successResponse(g, op, status)
+
+		obj.Responses[strconv.Itoa(http.StatusBadRequest)] = errorResponse
+		obj.Responses[strconv.Itoa(http.StatusInternalServerError)] = errorResponse
+		if len(params) > 0 {
+			obj.Responses[strconv.Itoa(http.StatusNotFound)] = errorResponse
+		}
+		if !op.Public {
+			obj.Responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse
+		}
+		for _, code := range op.Errors {
+			obj.Responses[strconv.Itoa(code)] = errorResponse
+		}
+
+		doc.Paths[path][strings.ToLower(op.Method)] = obj
+	}
+
+	doc.Components = Components{
+		Schemas: g.schemas,
+		SecuritySchemes: map[string]SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer"},
+		},
+	}
+
+	return doc, nil
+}
+
+// Verify сравнивает таблицу маршрутов с описаниями операций
+func Verify(routes []fiber.Route, ops []Operation) error {
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	registered := make(map[string]bool, len(routes))
+	var problems []string
+	for _, route := range routes {
+		if !documentedMethod(route.Method) {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		if registered[key] {
+			continue
+		}
+		registered[key] = true
+
+		if !documented[key] {
+			problems = append(problems, "route without spec: "+key)
+		}
+	}
+
+	for key := range documented {
+		if !registered[key] {
+			problems = append(problems, "spec without route: "+key)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("routes and OpenAPI spec diverge:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// documentedMethod - HEAD и OPTIONS Fiber и CORS обрабатывают сами
+func documentedMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// successResponse - схема успешного ответа в обертке dto.Response
+func successResponse(g *generator, op Operation, status int) ResponseObject {
+	resp := ResponseObject{Description: http.StatusText(status)}
+
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = jsonContent
+	}
+
+	switch {
+	case status == http.StatusSwitchingProtocols:
+		return resp
+	case op.Raw || contentType != jsonContent:
+		resp.Content = map[string]MediaType{contentType: {Schema: g.Of(op.Response)}}
+	default:
+		envelope := &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: []any{"success"}},
+			},
+			Required: []string{"status"},
+		}
+		if op.Response != nil {
+			envelope.Properties["data"] = g.Of(op.Response)
+			envelope.Required = append(envelope.Required, "data")
+		}
+		resp.Content = map[string]MediaType{contentType: {Schema: envelope}}
+	}
+
+	return resp
+}
+
+// convertPath - переводит путь Fiber в шаблон OpenAPI и возвращает имена параметров
+func convertPath(path string) (string, []string) {
+	var params []string
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+			params = append(params, name)
+			segments[i] = fmt.Sprintf("{%s}", name)
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
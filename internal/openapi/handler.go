@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Spec - документ, который отдается по /openapi.json. Маршруты регистрируются
+// до построения документа, поэтому Build вызывается после регистрации всех маршрутов
+type Spec struct {
+	doc    *Document
+	body   []byte
+	routes []route
+}
+
+// Build строит документ по таблице маршрутов, см. Build
+func (s *Spec) Build(info Info, routes []fiber.Route, ops []Operation) error {
+	doc, err := Build(info, routes, ops)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal OpenAPI document")
+	}
+
+	s.doc = doc
+	s.body = body
+	s.routes = newRoutes(doc, ops)
+
+	return nil
+}
+
+// Document возвращает построенный документ
+func (s *Spec) Document() *Document {
+	return s.doc
+}
+
+// Handler отдает документ в JSON
+func (s *Spec) Handler(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(s.body)
+}
+
+// DocsHandler отдает страницу Swagger UI для /openapi.json
+func DocsHandler(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return ctx.Send(docsPage)
+}
@@ -0,0 +1,378 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"restapi/internal/dto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Места параметров в нарушениях
+const (
+	inPath  = "path"
+	inQuery = "query"
+	inBody  = "body"
+)
+
+// route - операция документа с разобранным путем Fiber
+type route struct {
+	method   string
+	path     string
+	segments []string
+	op       *OperationObject
+}
+
+// match сравнивает путь запроса с шаблоном и возвращает число совпавших
+// литеральных сегментов, -1 - путь не подходит
+func (r route) match(segments []string) int {
+	if len(segments) != len(r.segments) {
+		return -1
+	}
+
+	literal := 0
+	for i, segment := range r.segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+		case segment == segments[i]:
+			literal++
+		default:
+			return -1
+		}
+	}
+
+	return literal
+}
+
+// newRoutes разбирает пути операций для поиска операции по запросу
+func newRoutes(doc *Document, ops []Operation) []route {
+	routes := make([]route, 0, len(ops))
+	for _, op := range ops {
+		path, _ := convertPath(op.Path)
+		routes = append(routes, route{
+			method:   op.Method,
+			path:     op.Path,
+			segments: splitPath(op.Path),
+			op:       doc.Paths[path][strings.ToLower(op.Method)],
+		})
+	}
+
+	return routes
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// params возвращает значения параметров пути по подходящему шаблону
+func (r route) params(segments []string) map[string]string {
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, ":") {
+			params[strings.TrimSuffix(segment[1:], "?")], _ = url.PathUnescape(segments[i])
+		}
+	}
+
+	return params
+}
+
+// ValidateRequest - middleware, проверяющее параметры пути, строки запроса и тело JSON
+// по документу до вызова обработчика. Запросы к маршрутам вне документа пропускаются.
+// Документ строится после регистрации маршрутов, поэтому операция ищется при запросе,
+// а параметры пути берутся из найденного шаблона: middleware группы их не видит
+func (s *Spec) ValidateRequest(ctx *fiber.Ctx) error {
+	segments := splitPath(ctx.Path())
+
+	r, ok := s.route(ctx.Method(), segments)
+	if !ok {
+		return ctx.Next()
+	}
+
+	v := &validator{schemas: s.doc.Components.Schemas}
+	v.params(ctx, r.op, r.params(segments))
+	v.body(ctx, r.op)
+
+	if len(v.violations) > 0 {
+		return dto.ValidationError(ctx, "Request does not match API specification", v.violations)
+	}
+
+	return ctx.Next()
+}
+
+// Route возвращает шаблон маршрута Fiber (/v1/tasks/:id) для метода и пути запроса
+// до маршрутизации, например для логгера запроса
+func (s *Spec) Route(method, path string) (string, bool) {
+	r, ok := s.route(method, splitPath(path))
+	return r.path, ok
+}
+
+// route ищет операцию по методу и пути. Литеральные сегменты важнее параметров,
+// поэтому /v1/tasks/stream не принимается за /v1/tasks/:id
+func (s *Spec) route(method string, segments []string) (route, bool) {
+	var found route
+	best := -1
+	for _, r := range s.routes {
+		if r.method != method {
+			continue
+		}
+		if n := r.match(segments); n > best {
+			found, best = r, n
+		}
+	}
+
+	return found, best >= 0
+}
+
+// validator - собирает все нарушения запроса
+type validator struct {
+	schemas    map[string]*Schema
+	violations []dto.FieldError
+}
+
+func (v *validator) add(in, field, rule, param, message string) {
+	v.violations = append(v.violations, dto.FieldError{
+		In:      in,
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: message,
+	})
+}
+
+// params проверяет параметры пути и строки запроса
+func (v *validator) params(ctx *fiber.Ctx, op *OperationObject, path map[string]string) {
+	for _, p := range op.Parameters {
+		var raw string
+		switch p.In {
+		case inPath:
+			raw = path[p.Name]
+		case inQuery:
+			raw = ctx.Query(p.Name)
+		}
+
+		if raw == "" {
+			if p.Required {
+				v.add(p.In, p.Name, "required", "", "Parameter is required")
+			}
+			continue
+		}
+
+		value, ok := parseParam(raw, p.Schema.Type)
+		if !ok {
+			v.add(p.In, p.Name, "type", p.Schema.Type, "Parameter must be "+article(p.Schema.Type))
+			continue
+		}
+
+		v.value(p.In, p.Name, p.Schema, value)
+	}
+}
+
+// parseParam приводит строковое значение параметра к типу схемы
+func parseParam(raw, typ string) (any, bool) {
+	switch typ {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		return float64(n), err == nil
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		return n, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	default:
+		return raw, true
+	}
+}
+
+// body проверяет тело запроса JSON
+func (v *validator) body(ctx *fiber.Ctx, op *OperationObject) {
+	if op.RequestBody == nil {
+		return
+	}
+
+	raw := bytes.TrimSpace(ctx.Body())
+	if len(raw) == 0 {
+		if op.RequestBody.Required {
+			v.add(inBody, "", "required", "", "Request body is required")
+		}
+		return
+	}
+
+	if !ctx.Is("json") {
+		v.add(inBody, "", "contentType", jsonContent, "Request body must be "+jsonContent)
+		return
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		v.add(inBody, "", "json", "", "Request body is not valid JSON")
+		return
+	}
+
+	v.value(inBody, "", op.RequestBody.Content[jsonContent].Schema, value)
+}
+
+// value проверяет значение, разобранное из JSON, по схеме
+func (v *validator) value(in, field string, s *Schema, value any) {
+	s = v.resolve(s)
+	if s == nil || value == nil {
+		return
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		v.add(in, field, "type", s.Type, "Field must be "+article(s.Type))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		v.add(in, field, "enum", enumParam(s.Enum), "Field must be one of: "+enumParam(s.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.string(in, field, s, value)
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			v.add(in, field, "minimum", formatNumber(*s.Minimum), "Field is below minimum value")
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			v.add(in, field, "maximum", formatNumber(*s.Maximum), "Field exceeds maximum value")
+		}
+	case []any:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			v.add(in, field, "minItems", strconv.Itoa(*s.MinItems), "Field has too few items")
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			v.add(in, field, "maxItems", strconv.Itoa(*s.MaxItems), "Field has too many items")
+		}
+		for i, item := range value {
+			v.value(in, fmt.Sprintf("%s[%d]", field, i), s.Items, item)
+		}
+	case map[string]any:
+		v.object(in, field, s, value)
+	}
+}
+
+func (v *validator) string(in, field string, s *Schema, value string) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.add(in, field, "minLength", strconv.Itoa(*s.MinLength), "Field is below minimum length")
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.add(in, field, "maxLength", strconv.Itoa(*s.MaxLength), "Field exceeds maximum length")
+	}
+
+	switch s.Format {
+	case "uri":
+		if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" || u.Host == "" {
+			v.add(in, field, "format", s.Format, "Field must be a valid URL")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			v.add(in, field, "format", s.Format, "Field must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (v *validator) object(in, field string, s *Schema, value map[string]any) {
+	for _, name := range s.Required {
+		if value[name] == nil {
+			v.add(in, join(field, name), "required", "", "Field is required")
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			prop = s.AdditionalProperties
+		}
+		v.value(in, join(field, name), prop, value[name])
+	}
+}
+
+// resolve заменяет ссылку на схему из components
+func (v *validator) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = v.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+
+	return s
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	default:
+		return true
+	}
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumParam(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		values = append(values, fmt.Sprint(value))
+	}
+
+	return strings.Join(values, " ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	default:
+		return "a " + typ
+	}
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+
+	return field + "." + name
+}
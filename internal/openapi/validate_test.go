@@ -0,0 +1,198 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"restapi/internal/dto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type testLabel struct {
+	Name string `json:"name" validate:"required,max=5"`
+}
+
+type testTask struct {
+	Title  string      `json:"title" validate:"required,min=3,max=10"`
+	Status string      `json:"status" validate:"required,oneof=new done"`
+	Points int         `json:"points" validate:"min=0,max=100"`
+	URL    string      `json:"url" validate:"omitempty,url"`
+	DueAt  *time.Time  `json:"due_at"`
+	Tags   []string    `json:"tags" validate:"max=2,dive,min=2"`
+	Labels []testLabel `json:"labels"`
+}
+
+// newValidatingApp - приложение с проверкой запросов по документу из ops
+func newValidatingApp(t *testing.T) (*fiber.App, *Spec) {
+	t.Helper()
+
+	spec := &Spec{}
+	app := fiber.New()
+	app.Use(spec.ValidateRequest)
+
+	ok := func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusOK)
+	}
+	app.Post("/v1/tasks", ok)
+	app.Get("/v1/tasks", ok)
+	app.Get("/v1/tasks/stream", ok)
+	app.Get("/v1/tasks/:id", ok)
+	app.Get("/v1/webhooks/:name", ok)
+
+	ops := []Operation{
+		{Method: fiber.MethodPost, Path: "/v1/tasks", ID: "createTask", Request: testTask{}},
+		{Method: fiber.MethodGet, Path: "/v1/tasks", ID: "listTasks", Query: []Param{
+			{Name: "page", Type: "integer"},
+			{Name: "status", Type: "string", Enum: []string{"new", "done"}},
+		}},
+		{Method: fiber.MethodGet, Path: "/v1/tasks/stream", ID: "streamTasks"},
+		{Method: fiber.MethodGet, Path: "/v1/tasks/:id", ID: "getTask"},
+		{Method: fiber.MethodGet, Path: "/v1/webhooks/:name", ID: "getWebhook", PathParams: map[string]string{"name": "string"}},
+	}
+	if err := spec.Build(Info{Title: "test", Version: "1"}, app.GetRoutes(true), ops); err != nil {
+		t.Fatal(err)
+	}
+
+	return app, spec
+}
+
+func TestValidateRequest(t *testing.T) {
+	app, _ := newValidatingApp(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		// want - нарушения в виде in:field:rule:param, пустой - запрос проходит
+		want []string
+	}{
+		{
+			name: "valid body", method: fiber.MethodPost, target: "/v1/tasks",
+			body: `{"title":"Buy milk","status":"new","points":5,"url":"https://example.com","due_at":"2026-05-01T09:00:00Z","tags":["ab"],"labels":[{"name":"home"}]}`,
+		},
+		{
+			name: "all body violations with paths", method: fiber.MethodPost, target: "/v1/tasks",
+			body: `{"title":"ab","status":"closed","points":101,"url":"nope","due_at":"tomorrow","tags":["a","bb","c"],"labels":[{"name":"toolong"},{}]}`,
+			want: []string{
+				"body:due_at:format:date-time",
+				"body:labels[0].name:maxLength:5",
+				"body:labels[1].name:required:",
+				"body:points:maximum:100",
+				"body:status:enum:new done",
+				"body:tags:maxItems:2",
+				"body:tags[0]:minLength:2",
+				"body:tags[2]:minLength:2",
+				"body:title:minLength:3",
+				"body:url:format:uri",
+			},
+		},
+		{
+			name: "required fields", method: fiber.MethodPost, target: "/v1/tasks", body: `{}`,
+			want: []string{"body:title:required:", "body:status:required:"},
+		},
+		{
+			name: "wrong types", method: fiber.MethodPost, target: "/v1/tasks",
+			body: `{"title":5,"status":"new","points":1.5,"tags":"a"}`,
+			want: []string{"body:points:type:integer", "body:tags:type:array", "body:title:type:string"},
+		},
+		{
+			name: "no body", method: fiber.MethodPost, target: "/v1/tasks",
+			want: []string{"body::required:"},
+		},
+		{
+			name: "invalid json", method: fiber.MethodPost, target: "/v1/tasks", body: `{"title":`,
+			want: []string{"body::json:"},
+		},
+		{
+			name: "not json", method: fiber.MethodPost, target: "/v1/tasks", contentType: fiber.MIMETextPlain, body: `title`,
+			want: []string{"body::contentType:application/json"},
+		},
+		{
+			name: "query parameters", method: fiber.MethodGet, target: "/v1/tasks?page=first&status=closed",
+			want: []string{"query:page:type:integer", "query:status:enum:new done"},
+		},
+		{name: "valid query", method: fiber.MethodGet, target: "/v1/tasks?page=2&status=done"},
+		{
+			name: "path parameter", method: fiber.MethodGet, target: "/v1/tasks/abc",
+			want: []string{"path:id:type:integer"},
+		},
+		{name: "literal segment wins over parameter", method: fiber.MethodGet, target: "/v1/tasks/stream"},
+		{name: "string path parameter", method: fiber.MethodGet, target: "/v1/webhooks/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = fiber.MIMEApplicationJSON
+			}
+			req.Header.Set(fiber.HeaderContentType, contentType)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if len(tt.want) == 0 {
+				if resp.StatusCode != fiber.StatusOK {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+				}
+				return
+			}
+
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusBadRequest)
+			}
+			var body dto.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error == nil || body.Error.Code != dto.FieldIncorrect {
+				t.Fatalf("error = %+v, want %s", body.Error, dto.FieldIncorrect)
+			}
+
+			var got []string
+			for _, f := range body.Error.Fields {
+				if f.Message == "" {
+					t.Errorf("%s: empty message", f.Field)
+				}
+				got = append(got, f.In+":"+f.Field+":"+f.Rule+":"+f.Param)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	_, spec := newValidatingApp(t)
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{method: fiber.MethodGet, path: "/v1/tasks/42", want: "/v1/tasks/:id", wantOK: true},
+		{method: fiber.MethodGet, path: "/v1/tasks/stream", want: "/v1/tasks/stream", wantOK: true},
+		{method: fiber.MethodPost, path: "/v1/tasks/", want: "/v1/tasks", wantOK: true},
+		{method: fiber.MethodDelete, path: "/v1/tasks/42"},
+		{method: fiber.MethodGet, path: "/v1/tasks/42/events"},
+	}
+
+	for _, tt := range tests {
+		got, ok := spec.Route(tt.method, tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Route(%s %s) = %q, %v, want %q, %v", tt.method, tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
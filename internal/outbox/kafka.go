@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Типы содержимого Kafka REST Proxy API v2
+const (
+	kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept          = "application/vnd.kafka.v2+json"
+)
+
+// maxErrorBody - сколько байт тела ответа с ошибкой попадает в текст ошибки
+const maxErrorBody = 1024
+
+// KafkaRESTProducer - клиент Kafka REST Proxy (API v2). Сообщения отправляются
+// в формате JSON: ключ - строка, значение - событие как есть
+type KafkaRESTProducer struct {
+	client *http.Client
+	url    string
+}
+
+// NewKafkaRESTProducer создает клиент прокси по адресу baseURL. Время запроса
+// ограничивается контекстом публикации
+func NewKafkaRESTProducer(baseURL string) *KafkaRESTProducer {
+	return &KafkaRESTProducer{
+		client: &http.Client{},
+		url:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// kafkaRecords - тело запроса записи в топик
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaOffsets - ответ прокси: результат записи каждого сообщения
+type kafkaOffsets struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Produce записывает сообщение в топик. Ошибка прокси или брокера для сообщения - ошибка
+func (p *KafkaRESTProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	payload, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: string(key), Value: value}}})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal records")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/topics/"+url.PathEscape(topic), bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", kafkaAccept)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return errors.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(resp.Body).Decode(&offsets); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	if len(offsets.Offsets) != 1 {
+		return errors.Errorf("expected 1 offset, got %d", len(offsets.Offsets))
+	}
+	if o := offsets.Offsets[0]; o.ErrorCode != nil || o.Error != nil {
+		var code int
+		var msg string
+		if o.ErrorCode != nil {
+			code = *o.ErrorCode
+		}
+		if o.Error != nil {
+			msg = *o.Error
+		}
+		return errors.Errorf("message rejected with code %d: %s", code, msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restapi/internal/event"
+	"strings"
+	"testing"
+)
+
+// kafkaRequest - запрос, полученный прокси
+type kafkaRequest struct {
+	path        string
+	contentType string
+	body        kafkaRecords
+}
+
+// newKafkaProxy - Kafka REST Proxy, отвечающий status и телом response
+func newKafkaProxy(t *testing.T, status int, response string) (*httptest.Server, *[]kafkaRequest) {
+	t.Helper()
+
+	var requests []kafkaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := kafkaRequest{path: r.URL.EscapedPath(), contentType: r.Header.Get("Content-Type")}
+		if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", kafkaAccept)
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestBrokerPublisherKafka(t *testing.T) {
+	srv, requests := newKafkaProxy(t, http.StatusOK, `{"offsets":[{"partition":2,"offset":17,"error_code":null,"error":null}]}`)
+	publisher := NewBrokerPublisher(NewKafkaRESTProducer(srv.URL+"/"), "tasks.")
+
+	e := event.New(event.TaskCreated, &event.Task{ID: 42, Title: "t", Status: "new"})
+	if err := publisher.Publish(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.path != "/topics/tasks.task.created" {
+		t.Errorf("path = %s, want /topics/tasks.task.created", req.path)
+	}
+	if req.contentType != kafkaJSONContentType {
+		t.Errorf("content type = %s, want %s", req.contentType, kafkaJSONContentType)
+	}
+	if len(req.body.Records) != 1 || req.body.Records[0].Key != "42" {
+		t.Fatalf("records = %+v, want one record with key 42", req.body.Records)
+	}
+
+	var got event.Event
+	if err := json.Unmarshal(req.body.Records[0].Value, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != e.ID || got.Type != event.TaskCreated || got.Data.Task.ID != 42 {
+		t.Errorf("value = %+v, want event %d", got, e.ID)
+	}
+}
+
+func TestKafkaRESTProducerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantErr  string
+	}{
+		{name: "proxy error", status: http.StatusNotFound, response: `{"error_code":40401,"message":"Topic not found"}`, wantErr: "unexpected status 404"},
+		{name: "broker rejected message", status: http.StatusOK, response: `{"offsets":[{"partition":null,"offset":null,"error_code":1,"error":"Message too large"}]}`, wantErr: "Message too large"},
+		{name: "no offsets", status: http.StatusOK, response: `{"offsets":[]}`, wantErr: "expected 1 offset"},
+		{name: "invalid response", status: http.StatusOK, response: `<html>`, wantErr: "failed to decode response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newKafkaProxy(t, tt.status, tt.response)
+
+			err := NewKafkaRESTProducer(srv.URL).Produce(context.Background(), "tasks.task.created", []byte("1"), []byte(`{}`))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Produce = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
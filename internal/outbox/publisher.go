@@ -0,0 +1,84 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"restapi/internal/event"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Publisher - получатель событий из outbox. Доставка выполняется не менее
+// одного раза, поэтому Publish должен быть идемпотентным по event.ID
+type Publisher interface {
+	Publish(ctx context.Context, e event.Event) error
+}
+
+// Publishers - публикует событие во все получатели по очереди
+type Publishers []Publisher
+
+// Publish передает событие каждому получателю, первая ошибка прерывает публикацию
+func (p Publishers) Publish(ctx context.Context, e event.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogPublisher - пишет события в лог
+type LogPublisher struct {
+	log *zap.SugaredLogger
+}
+
+// NewLogPublisher создает получатель, пишущий события в лог
+func NewLogPublisher(log *zap.SugaredLogger) *LogPublisher {
+	return &LogPublisher{log: log}
+}
+
+// Publish пишет событие в лог
+func (p *LogPublisher) Publish(ctx context.Context, e event.Event) error {
+	p.log.Infow("Task event", "event_id", e.ID, "event", e.Type, "task_id", e.Data.Task.ID)
+	return nil
+}
+
+// Producer - клиент брокера сообщений. Подходит для адаптеров NATS, Kafka
+// и совместимых брокеров
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// BrokerPublisher - публикует события в брокер сообщений.
+// Топик - prefix + тип события, ключ - id задачи, чтобы события одной задачи
+// попадали в одну партицию
+type BrokerPublisher struct {
+	producer Producer
+	prefix   string
+}
+
+// NewBrokerPublisher создает получатель для брокера сообщений
+func NewBrokerPublisher(producer Producer, prefix string) *BrokerPublisher {
+	return &BrokerPublisher{
+		producer: producer,
+		prefix:   prefix,
+	}
+}
+
+// Publish отправляет событие в брокер
+func (p *BrokerPublisher) Publish(ctx context.Context, e event.Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	key := []byte(strconv.FormatInt(e.Data.Task.ID, 10))
+	if err := p.producer.Produce(ctx, p.prefix+e.Type, key, value); err != nil {
+		return errors.Wrap(err, "failed to produce event")
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/health"
+	"restapi/internal/repo/db"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Relay переносит события из outbox в Publisher
+type Relay struct {
+	log       *zap.SugaredLogger
+	repo      db.OutboxRepository
+	publisher Publisher
+	cfg       config.Outbox
+	beat      *health.Heartbeat
+}
+
+// NewRelay создает обработчик outbox
+func NewRelay(log *zap.SugaredLogger, repo db.OutboxRepository, publisher Publisher, cfg config.Outbox) *Relay {
+	return &Relay{
+		log:       log,
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		beat:      health.NewHeartbeat(cfg.PollInterval),
+	}
+}
+
+// Check - проверка живости: outbox разбирается
+func (r *Relay) Check(ctx context.Context) error {
+	return r.beat.Check(ctx)
+}
+
+// Run публикует события до отмены контекста. Полная порция обрабатывается
+// без ожидания, чтобы быстрее разбирать накопившиеся события
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		r.beat.Beat()
+		if r.process(ctx) == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			r.clean(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// process публикует до BatchSize готовых событий и возвращает число обработанных.
+// События забираются по одной: аренда покрывает только текущую публикацию.
+// Неудачное событие откладывается и не задерживает следующие
+func (r *Relay) process(ctx context.Context) int {
+	lease := r.cfg.Timeout + r.cfg.PollInterval
+
+	for n := 0; n < r.cfg.BatchSize; n++ {
+		if ctx.Err() != nil {
+			return n
+		}
+
+		entries, err := r.repo.ClaimOutbox(ctx, 1, lease)
+		if err != nil {
+			r.log.Errorf("Error claiming outbox events: %v", err)
+			return n
+		}
+		if len(entries) == 0 {
+			return n
+		}
+
+		r.beat.Beat()
+		r.publish(ctx, entries[0])
+	}
+
+	return r.cfg.BatchSize
+}
+
+// publish передает событие получателям и сохраняет результат. После MaxAttempts
+// неудачных попыток событие больше не публикуется
+func (r *Relay) publish(ctx context.Context, entry db.OutboxEntry) {
+	id := entry.Event.ID
+
+	publishCtx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	err := r.publisher.Publish(publishCtx, entry.Event)
+	cancel()
+	if err == nil {
+		if err := r.repo.PublishOutbox(ctx, id); err != nil {
+			r.log.Errorf("Error saving outbox event %d: %v", id, err)
+		}
+		return
+	}
+
+	attempts := entry.Attempts + 1
+	if attempts >= r.cfg.MaxAttempts {
+		r.log.Errorf("Outbox event %d dropped after %d attempts: %v", id, attempts, err)
+		err = r.repo.FailOutbox(ctx, id, err.Error())
+	} else {
+		r.log.Warnf("Outbox event %d attempt %d failed: %v", id, attempts, err)
+		err = r.repo.RetryOutbox(ctx, id, err.Error(), time.Now().Add(r.backoff(attempts)))
+	}
+	if err != nil {
+		r.log.Errorf("Error saving outbox event %d: %v", id, err)
+	}
+}
+
+// backoff возвращает задержку перед следующей попыткой: base * 2^(attempt-1), не больше max
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+// clean удаляет опубликованные и отброшенные события старше срока хранения
+func (r *Relay) clean(ctx context.Context) {
+	deleted, err := r.repo.CleanOutbox(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		r.log.Errorf("Error cleaning outbox: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		r.log.Infof("Removed %d processed outbox events", deleted)
+	}
+}
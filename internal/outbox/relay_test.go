@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/event"
+	"restapi/internal/repo/db"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeOutbox - outbox в памяти
+type fakeOutbox struct {
+	entries   []*fakeEntry
+	claims    []int
+	published []int64
+}
+
+type fakeEntry struct {
+	db.OutboxEntry
+	next   time.Time
+	done   bool
+	failed bool
+}
+
+func (o *fakeOutbox) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]db.OutboxEntry, error) {
+	o.claims = append(o.claims, limit)
+
+	var claimed []db.OutboxEntry
+	for _, e := range o.entries {
+		if len(claimed) == limit {
+			break
+		}
+		if !e.done && !e.failed && !e.next.After(time.Now()) {
+			e.next = time.Now().Add(lease)
+			claimed = append(claimed, e.OutboxEntry)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (o *fakeOutbox) PublishOutbox(ctx context.Context, id int64) error {
+	o.get(id).done = true
+	o.published = append(o.published, id)
+	return nil
+}
+
+func (o *fakeOutbox) RetryOutbox(ctx context.Context, id int64, lastError string, next time.Time) error {
+	e := o.get(id)
+	e.Attempts++
+	e.next = next
+	return nil
+}
+
+func (o *fakeOutbox) FailOutbox(ctx context.Context, id int64, lastError string) error {
+	e := o.get(id)
+	e.Attempts++
+	e.failed = true
+	return nil
+}
+
+func (o *fakeOutbox) CleanOutbox(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (o *fakeOutbox) get(id int64) *fakeEntry {
+	for _, e := range o.entries {
+		if e.Event.ID == id {
+			return e
+		}
+	}
+	panic("unknown outbox event")
+}
+
+// due делает отложенные события готовыми к публикации
+func (o *fakeOutbox) due() {
+	for _, e := range o.entries {
+		e.next = time.Time{}
+	}
+}
+
+type publisherFunc func(ctx context.Context, e event.Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, e event.Event) error {
+	return f(ctx, e)
+}
+
+func newTestRelay(repo db.OutboxRepository, publisher Publisher) *Relay {
+	return NewRelay(zap.NewNop().Sugar(), repo, publisher, config.Outbox{
+		PollInterval:    10 * time.Millisecond,
+		BatchSize:       10,
+		Timeout:         time.Second,
+		MaxAttempts:     3,
+		BaseBackoff:     time.Minute,
+		MaxBackoff:      time.Hour,
+		Retention:       time.Hour,
+		CleanupInterval: time.Hour,
+	})
+}
+
+func TestRelaySkipsFailingEvent(t *testing.T) {
+	repo := &fakeOutbox{}
+	for id := int64(1); id <= 3; id++ {
+		repo.entries = append(repo.entries, &fakeEntry{OutboxEntry: db.OutboxEntry{Event: event.Event{ID: id}}})
+	}
+
+	poison := errors.New("broker rejected event")
+	relay := newTestRelay(repo, publisherFunc(func(ctx context.Context, e event.Event) error {
+		if e.ID == 1 {
+			return poison
+		}
+		return nil
+	}))
+
+	if n := relay.process(context.Background()); n != 3 {
+		t.Fatalf("processed = %d, want 3", n)
+	}
+	if len(repo.published) != 2 || repo.published[0] != 2 || repo.published[1] != 3 {
+		t.Fatalf("published = %v, want [2 3]", repo.published)
+	}
+
+	first := repo.get(1)
+	if first.Attempts != 1 || first.failed {
+		t.Fatalf("failing event: attempts %d, failed %v", first.Attempts, first.failed)
+	}
+	if delay := time.Until(first.next); delay < 59*time.Second || delay > time.Minute {
+		t.Errorf("retry delay = %v, want base backoff 1m", delay)
+	}
+
+	// После MaxAttempts событие отбрасывается и больше не забирается
+	for range 2 {
+		repo.due()
+		relay.process(context.Background())
+	}
+	if !first.failed || first.Attempts != 3 {
+		t.Fatalf("failing event: attempts %d, failed %v, want dropped after 3", first.Attempts, first.failed)
+	}
+
+	repo.due()
+	claims := len(repo.claims)
+	if n := relay.process(context.Background()); n != 0 {
+		t.Fatalf("processed after drop = %d, want 0", n)
+	}
+	if len(repo.claims) != claims+1 {
+		t.Errorf("claims = %d, want one empty claim", len(repo.claims)-claims)
+	}
+}
+
+func TestRelayClaimsOneEventAtATime(t *testing.T) {
+	repo := &fakeOutbox{}
+	for id := int64(1); id <= 15; id++ {
+		repo.entries = append(repo.entries, &fakeEntry{OutboxEntry: db.OutboxEntry{Event: event.Event{ID: id}}})
+	}
+
+	relay := newTestRelay(repo, publisherFunc(func(ctx context.Context, e event.Event) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("event %d published without a deadline", e.ID)
+		}
+		return nil
+	}))
+
+	if n := relay.process(context.Background()); n != 10 {
+		t.Fatalf("processed = %d, want batch size 10", n)
+	}
+	for _, limit := range repo.claims {
+		if limit != 1 {
+			t.Fatalf("claim limits = %v, want 1", repo.claims)
+		}
+	}
+
+	if n := relay.process(context.Background()); n != 5 {
+		t.Fatalf("processed = %d, want the remaining 5", n)
+	}
+}
+
+func TestRelayStopsOnCancel(t *testing.T) {
+	repo := &fakeOutbox{entries: []*fakeEntry{{OutboxEntry: db.OutboxEntry{Event: event.Event{ID: 1}}}}}
+	relay := newTestRelay(repo, publisherFunc(func(ctx context.Context, e event.Event) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if n := relay.process(ctx); n != 0 || len(repo.claims) != 0 {
+		t.Fatalf("processed = %d, claims = %d after cancel", n, len(repo.claims))
+	}
+}
@@ -0,0 +1,244 @@
+// Package rank - ключи порядка задач в колонке доски (fractional indexing).
+// Между любыми двумя ключами можно получить новый, не меняя остальные.
+//
+// Ключ - целая часть и дробная часть в base62. Первый символ целой части задает ее длину:
+// 'a'..'z' - положительные числа из 1..26 цифр, 'A'..'Z' - отрицательные. Дробная часть
+// не заканчивается нулем. Ключи сравниваются побайтно (COLLATE "C" в Postgres)
+package rank
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// digits - цифры base62 в порядке байтов
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// First - ключ первой задачи пустой колонки
+const First = "a0"
+
+// smallestInteger - наименьшая целая часть, перед ней ключ получается только дробной частью
+var smallestInteger = "A" + strings.Repeat(digits[:1], 26)
+
+// ErrInvalidKey - ключ не в формате rank
+var ErrInvalidKey = errors.New("invalid rank key")
+
+// Between возвращает ключ строго между after и before. Пустой after - в начало,
+// пустой before - в конец колонки
+func Between(after, before string) (string, error) {
+	if after != "" {
+		if err := Validate(after); err != nil {
+			return "", err
+		}
+	}
+	if before != "" {
+		if err := Validate(before); err != nil {
+			return "", err
+		}
+	}
+	if after != "" && before != "" && after >= before {
+		return "", errors.Errorf("rank %q is not before %q", after, before)
+	}
+
+	switch {
+	case after == "" && before == "":
+		return First, nil
+
+	case after == "":
+		ib := integerPart(before)
+		fb := before[len(ib):]
+		if ib == smallestInteger {
+			return ib + midpoint("", fb), nil
+		}
+		if ib < before {
+			return ib, nil
+		}
+		prev, ok := decrementInteger(ib)
+		if !ok {
+			return "", errors.New("cannot rank before the smallest key")
+		}
+		return prev, nil
+
+	case before == "":
+		ia := integerPart(after)
+		next, ok := incrementInteger(ia)
+		if !ok {
+			return ia + midpoint(after[len(ia):], ""), nil
+		}
+		return next, nil
+	}
+
+	ia := integerPart(after)
+	ib := integerPart(before)
+	if ia == ib {
+		return ia + midpoint(after[len(ia):], before[len(ib):]), nil
+	}
+
+	next, ok := incrementInteger(ia)
+	if ok && next < before {
+		return next, nil
+	}
+
+	return ia + midpoint(after[len(ia):], ""), nil
+}
+
+// Validate проверяет формат ключа
+func Validate(key string) error {
+	if key == "" || key == smallestInteger {
+		return errors.Wrapf(ErrInvalidKey, "%q", key)
+	}
+
+	n, ok := integerLength(key[0])
+	if !ok || n > len(key) {
+		return errors.Wrapf(ErrInvalidKey, "%q", key)
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return errors.Wrapf(ErrInvalidKey, "%q", key)
+		}
+	}
+	if len(key) > n && key[len(key)-1] == digits[0] {
+		return errors.Wrapf(ErrInvalidKey, "%q: trailing zero", key)
+	}
+
+	return nil
+}
+
+// integerLength - длина целой части с первым символом head
+func integerLength(head byte) (int, bool) {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2, true
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2, true
+	default:
+		return 0, false
+	}
+}
+
+// integerPart - целая часть проверенного ключа
+func integerPart(key string) string {
+	n, _ := integerLength(key[0])
+	return key[:n]
+}
+
+// midpoint - дробная часть строго между a и b. Пустой b - верхняя граница 1
+func midpoint(a, b string) string {
+	if b != "" {
+		// Общий префикс переносится как есть, a дополняется нулями
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(tail(a, n), b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(digits, a[0])
+	}
+	digitB := len(digits)
+	if b != "" {
+		digitB = strings.IndexByte(digits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(digits[(digitA+digitB+1)/2])
+	}
+
+	// Соседние цифры: первая цифра b меньше самого b, иначе нужна следующая цифра a
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	return string(digits[digitA]) + midpoint(tail(a, 1), "")
+}
+
+// digitAt - цифра a в позиции i, за концом строки - ноль
+func digitAt(a string, i int) byte {
+	if i < len(a) {
+		return a[i]
+	}
+	return digits[0]
+}
+
+// tail - остаток строки после n символов
+func tail(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+	return s[n:]
+}
+
+// incrementInteger - следующая целая часть, false - x наибольшая
+func incrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+
+	carry := true
+	for i := len(digs) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) + 1
+		if d == len(digits) {
+			digs[i] = digits[0]
+		} else {
+			digs[i] = digits[d]
+			carry = false
+		}
+	}
+	if !carry {
+		return string(head) + string(digs), true
+	}
+
+	switch head {
+	case 'Z':
+		return "a" + digits[:1], true
+	case 'z':
+		return "", false
+	}
+
+	head++
+	if head > 'a' {
+		digs = append(digs, digits[0])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+
+	return string(head) + string(digs), true
+}
+
+// decrementInteger - предыдущая целая часть, false - x наименьшая
+func decrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+
+	borrow := true
+	for i := len(digs) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) - 1
+		if d == -1 {
+			digs[i] = digits[len(digits)-1]
+		} else {
+			digs[i] = digits[d]
+			borrow = false
+		}
+	}
+	if !borrow {
+		return string(head) + string(digs), true
+	}
+
+	switch head {
+	case 'a':
+		return "Z" + digits[len(digits)-1:], true
+	case 'A':
+		return "", false
+	}
+
+	head--
+	if head < 'Z' {
+		digs = append(digs, digits[len(digits)-1])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+
+	return string(head) + string(digs), true
+}
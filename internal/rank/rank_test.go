@@ -0,0 +1,140 @@
+package rank
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		after, before string
+		want          string
+	}{
+		{name: "empty column", want: First},
+		{name: "head", before: "a0", want: "Zz"},
+		{name: "tail", after: "a0", want: "a1"},
+		{name: "tail carries into longer integer", after: "az", want: "b00"},
+		{name: "head borrows into shorter integer", before: "b00", want: "az"},
+		{name: "head before fraction", before: "a0V", want: "a0"},
+		{name: "between integers", after: "a0", before: "a2", want: "a1"},
+		{name: "adjacent integers", after: "a0", before: "a1", want: "a0V"},
+		{name: "adjacent fractions", after: "a0V", before: "a0W", want: "a0VV"},
+		{name: "adjacent last digits", after: "a0y", before: "a0z", want: "a0yV"},
+		{name: "prefix of before", after: "a0", before: "a01", want: "a00V"},
+		{name: "fraction to next integer", after: "a0z", before: "a1", want: "a0zV"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.after, tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Between(%q, %q) = %q, want %q", tt.after, tt.before, got, tt.want)
+			}
+			checkBetween(t, tt.after, got, tt.before)
+		})
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		after, before string
+	}{
+		{name: "equal keys", after: "a1", before: "a1"},
+		{name: "reversed keys", after: "a2", before: "a1"},
+		{name: "invalid after", after: "a"},
+		{name: "invalid before", before: "a0!"},
+		{name: "trailing zero", after: "a10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if key, err := Between(tt.after, tt.before); err == nil {
+				t.Errorf("Between(%q, %q) = %q, want error", tt.after, tt.before, key)
+			}
+		})
+	}
+}
+
+func TestBetweenRepeated(t *testing.T) {
+	tests := []struct {
+		name string
+		// next возвращает границы нового ключа по текущему порядку ключей
+		next func(keys []string) (string, string)
+		// maxLen - наибольшая длина ключа после всех вставок
+		maxLen int
+	}{
+		{
+			name:   "head",
+			next:   func(keys []string) (string, string) { return "", keys[0] },
+			maxLen: 3,
+		},
+		{
+			name:   "tail",
+			next:   func(keys []string) (string, string) { return keys[len(keys)-1], "" },
+			maxLen: 3,
+		},
+		{
+			name: "after head",
+			// Между одними и теми же соседями ключ удлиняется на цифру за несколько вставок
+			next:   func(keys []string) (string, string) { return keys[0], keys[1] },
+			maxLen: 210,
+		},
+		{
+			name:   "before tail",
+			next:   func(keys []string) (string, string) { return keys[len(keys)-2], keys[len(keys)-1] },
+			maxLen: 210,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := []string{"a0", "a1"}
+			for i := 0; i < 1000; i++ {
+				after, before := tt.next(keys)
+				key, err := Between(after, before)
+				if err != nil {
+					t.Fatalf("insert %d: %v", i, err)
+				}
+				checkBetween(t, after, key, before)
+
+				keys = insert(keys, key)
+			}
+
+			for _, key := range keys {
+				if len(key) > tt.maxLen {
+					t.Fatalf("key %q is %d bytes long, want at most %d", key, len(key), tt.maxLen)
+				}
+			}
+		})
+	}
+}
+
+// checkBetween проверяет, что key - верный ключ строго между after и before
+func checkBetween(t *testing.T, after, key, before string) {
+	t.Helper()
+
+	if err := Validate(key); err != nil {
+		t.Fatalf("Between(%q, %q) = %q: %v", after, before, key, err)
+	}
+	if after != "" && key <= after {
+		t.Fatalf("Between(%q, %q) = %q, not after %q", after, before, key, after)
+	}
+	if before != "" && key >= before {
+		t.Fatalf("Between(%q, %q) = %q, not before %q", after, before, key, before)
+	}
+}
+
+// insert вставляет key в упорядоченный список keys
+func insert(keys []string, key string) []string {
+	i := 0
+	for i < len(keys) && strings.Compare(keys[i], key) < 0 {
+		i++
+	}
+
+	return append(keys[:i], append([]string{key}, keys[i:]...)...)
+}
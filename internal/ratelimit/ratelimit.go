@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"restapi/internal/config"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Хранилища корзин
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// cleanupInterval - период удаления неиспользуемых корзин
+const cleanupInterval = time.Minute
+
+// Limit - корзина на Burst токенов, пополняемая на Rate токенов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// full - время, за которое пустая корзина наполняется
+func (l Limit) full() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Result - результат проверки для заголовков RateLimit-*
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset - через сколько корзина наполнится
+	Reset time.Duration
+	// RetryAfter - через сколько появится токен, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Store - хранилище корзин токенов
+type Store interface {
+	// Take пополняет корзину key и забирает из нее токен, если он есть.
+	// Возвращает остаток токенов и разрешен ли запрос
+	Take(ctx context.Context, key string, limit Limit) (float64, bool, error)
+	// Clean удаляет корзины, не использовавшиеся с before
+	Clean(ctx context.Context, before time.Time) error
+}
+
+// limits - лимиты чтения и записи
+type limits struct {
+	read  Limit
+	write Limit
+}
+
+// Limiter ограничивает запросы на чтение и запись отдельными корзинами
+type Limiter struct {
+	log    *zap.SugaredLogger
+	store  Store
+	limits atomic.Pointer[limits]
+}
+
+// NewLimiter создает ограничитель. Скорость и размер корзин должны быть положительными
+func NewLimiter(log *zap.SugaredLogger, store Store, cfg config.RateLimit) (*Limiter, error) {
+	l := &Limiter{
+		log:   log,
+		store: store,
+	}
+	if err := l.SetLimits(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// SetLimits меняет лимиты без перезапуска. Уже наполненные корзины
+// сохраняются и пополняются с новой скоростью
+func (l *Limiter) SetLimits(cfg config.RateLimit) error {
+	next := &limits{
+		read:  Limit{Rate: cfg.ReadRate, Burst: cfg.ReadBurst},
+		write: Limit{Rate: cfg.WriteRate, Burst: cfg.WriteBurst},
+	}
+	for _, limit := range []Limit{next.read, next.write} {
+		if limit.Rate <= 0 || limit.Burst < 1 {
+			return errors.Errorf("invalid rate limit: rate %v, burst %d", limit.Rate, limit.Burst)
+		}
+	}
+	l.limits.Store(next)
+
+	return nil
+}
+
+// Allow забирает токен из корзины key для чтения или записи. При ошибке хранилища
+// запрос разрешается: недоступное хранилище не должно останавливать API
+func (l *Limiter) Allow(ctx context.Context, key string, write bool) Result {
+	current := l.limits.Load()
+	limit, scope := current.read, "read:"
+	if write {
+		limit, scope = current.write, "write:"
+	}
+
+	tokens, allowed, err := l.store.Take(ctx, scope+key, limit)
+	if err != nil {
+		l.log.Warnf("Rate limit store unavailable, request allowed: %v", err)
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}
+	}
+
+	return newResult(limit, tokens, allowed)
+}
+
+// newResult считает заголовки по остатку токенов
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	tokens = math.Max(tokens, 0)
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
+
+// Run удаляет неиспользуемые корзины до отмены контекста. Корзина, которая
+// не использовалась дольше времени наполнения, полна и ничем не отличается от новой
+func (l *Limiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := l.limits.Load()
+			idle := max(current.read.full(), current.write.full())
+			if err := l.store.Clean(ctx, time.Now().Add(-idle)); err != nil {
+				l.log.Errorf("Error cleaning rate limits: %v", err)
+			}
+		}
+	}
+}
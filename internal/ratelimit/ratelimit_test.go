@@ -0,0 +1,232 @@
+package ratelimit
+
+import (
+	"context"
+	"restapi/internal/config"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeClock - время, которое двигает тест
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore() (*memoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	return &memoryStore{buckets: make(map[string]*bucket), now: clock.Now}, clock
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+
+	// Шаги выполняются по порядку над одной корзиной
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		wantTokens  float64
+		wantAllowed bool
+	}{
+		{name: "new bucket is full", wantTokens: 2, wantAllowed: true},
+		{name: "burst", wantTokens: 1, wantAllowed: true},
+		{name: "last token", wantTokens: 0, wantAllowed: true},
+		{name: "empty", wantTokens: 0, wantAllowed: false},
+		{name: "partial refill is not enough", advance: 250 * time.Millisecond, wantTokens: 0.5, wantAllowed: false},
+		{name: "refill reaches one token", advance: 250 * time.Millisecond, wantTokens: 0, wantAllowed: true},
+		{name: "refill is capped at burst", advance: time.Hour, wantTokens: 2, wantAllowed: true},
+	}
+
+	store, clock := newTestStore()
+	for _, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+
+		tokens, allowed, err := store.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens != step.wantTokens || allowed != step.wantAllowed {
+			t.Fatalf("%s: Take = %v, %v, want %v, %v", step.name, tokens, allowed, step.wantTokens, step.wantAllowed)
+		}
+	}
+}
+
+func TestMemoryStoreKeys(t *testing.T) {
+	store, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	for _, key := range []string{"a", "b"} {
+		if _, allowed, _ := store.Take(context.Background(), key, limit); !allowed {
+			t.Errorf("first request for %s rejected", key)
+		}
+	}
+	if _, allowed, _ := store.Take(context.Background(), "a", limit); allowed {
+		t.Error("second request for a allowed, buckets are shared")
+	}
+}
+
+func TestMemoryStoreClean(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if _, _, err := store.Take(context.Background(), "old", limit); err != nil {
+		t.Fatal(err)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if _, _, err := store.Take(context.Background(), "recent", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Clean(context.Background(), clock.now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.buckets["old"]; ok {
+		t.Error("idle bucket was not removed")
+	}
+	if _, ok := store.buckets["recent"]; !ok {
+		t.Error("recent bucket was removed")
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    Result
+	}{
+		{
+			name: "full bucket", tokens: 9, allowed: true,
+			want: Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 500 * time.Millisecond},
+		},
+		{
+			name: "fraction is not a request", tokens: 2.75, allowed: true,
+			want: Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 3625 * time.Millisecond},
+		},
+		{
+			name: "rejected", tokens: 0.5, allowed: false,
+			want: Result{Limit: 10, Remaining: 0, Reset: 4750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+		{
+			name: "negative tokens", tokens: -1, allowed: false,
+			want: Result{Limit: 10, Remaining: 0, Reset: 5 * time.Second, RetryAfter: 500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newResult(limit, tt.tokens, tt.allowed); got != tt.want {
+				t.Errorf("newResult = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeStore отвечает err или запоминает ключи
+type fakeStore struct {
+	keys []string
+	err  error
+}
+
+func (s *fakeStore) Take(_ context.Context, key string, limit Limit) (float64, bool, error) {
+	s.keys = append(s.keys, key)
+	return float64(limit.Burst) - 1, true, s.err
+}
+
+func (s *fakeStore) Clean(context.Context, time.Time) error {
+	return nil
+}
+
+func TestLimiterAllow(t *testing.T) {
+	cfg := config.RateLimit{ReadRate: 10, ReadBurst: 20, WriteRate: 1, WriteBurst: 5}
+
+	store := &fakeStore{}
+	l, err := NewLimiter(zap.NewNop().Sugar(), store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res := l.Allow(context.Background(), "ip:1.2.3.4", false); res.Limit != 20 || res.Remaining != 19 {
+		t.Errorf("read result = %+v, want read burst 20", res)
+	}
+	if res := l.Allow(context.Background(), "ip:1.2.3.4", true); res.Limit != 5 || res.Remaining != 4 {
+		t.Errorf("write result = %+v, want write burst 5", res)
+	}
+	if want := []string{"read:ip:1.2.3.4", "write:ip:1.2.3.4"}; len(store.keys) != 2 || store.keys[0] != want[0] || store.keys[1] != want[1] {
+		t.Errorf("keys = %v, want %v", store.keys, want)
+	}
+
+	// Недоступное хранилище не останавливает API
+	store.err = errors.New("connection refused")
+	if res := l.Allow(context.Background(), "ip:1.2.3.4", true); !res.Allowed || res.Remaining != 5 {
+		t.Errorf("result on store error = %+v, want allowed with full bucket", res)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.RateLimit
+		wantErr bool
+	}{
+		{name: "valid", cfg: config.RateLimit{ReadRate: 1, ReadBurst: 1, WriteRate: 0.5, WriteBurst: 1}},
+		{name: "zero read rate", cfg: config.RateLimit{ReadRate: 0, ReadBurst: 1, WriteRate: 1, WriteBurst: 1}, wantErr: true},
+		{name: "zero write burst", cfg: config.RateLimit{ReadRate: 1, ReadBurst: 1, WriteRate: 1, WriteBurst: 0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLimiter(zap.NewNop().Sugar(), &fakeStore{}, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewLimiter error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeRateLimits - таблица rate_limits, как ее видит хранилище
+type fakeRateLimits struct {
+	key    string
+	rate   float64
+	burst  int
+	before time.Time
+}
+
+func (r *fakeRateLimits) TakeToken(_ context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	r.key, r.rate, r.burst = key, rate, burst
+	return 0.5, false, nil
+}
+
+func (r *fakeRateLimits) CleanRateLimits(_ context.Context, before time.Time) (int64, error) {
+	r.before = before
+	return 1, nil
+}
+
+func TestPostgresStore(t *testing.T) {
+	repo := &fakeRateLimits{}
+	store := NewPostgresStore(repo)
+
+	tokens, allowed, err := store.Take(context.Background(), "write:key:alice", Limit{Rate: 0.5, Burst: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 0.5 || allowed {
+		t.Errorf("Take = %v, %v, want 0.5, false", tokens, allowed)
+	}
+	if repo.key != "write:key:alice" || repo.rate != 0.5 || repo.burst != 4 {
+		t.Errorf("TakeToken got %q, %v, %d", repo.key, repo.rate, repo.burst)
+	}
+
+	before := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Clean(context.Background(), before); err != nil || !repo.before.Equal(before) {
+		t.Errorf("Clean = %v, cleaned before %v, want %v", err, repo.before, before)
+	}
+}
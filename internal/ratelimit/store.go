@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"restapi/internal/repo/db"
+	"sync"
+	"time"
+)
+
+// bucket - корзина токенов в памяти
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// memoryStore - корзины одного экземпляра сервиса
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// now - текущее время, в тестах подменяется
+	now func() time.Time
+}
+
+// NewMemoryStore создает хранилище в памяти. Каждый экземпляр сервиса
+// ограничивает запросы независимо
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+
+	return b.tokens, true, nil
+}
+
+func (s *memoryStore) Clean(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+// postgresStore - корзины в Postgres, общие для всех экземпляров сервиса
+type postgresStore struct {
+	repo db.RateLimitRepository
+}
+
+// NewPostgresStore создает хранилище в таблице rate_limits
+func NewPostgresStore(repo db.RateLimitRepository) Store {
+	return &postgresStore{repo: repo}
+}
+
+func (s *postgresStore) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	return s.repo.TakeToken(ctx, key, limit.Rate, limit.Burst)
+}
+
+func (s *postgresStore) Clean(ctx context.Context, before time.Time) error {
+	_, err := s.repo.CleanRateLimits(ctx, before)
+	return err
+}
@@ -0,0 +1,26 @@
+package recurrence
+
+import (
+	"restapi/internal/repo/db"
+	"time"
+)
+
+// RecurrenceRequest - запрос на создание или замену правила повторения задачи
+type RecurrenceRequest struct {
+	// Rule - правило RRULE, например FREQ=WEEKLY;BYDAY=MO,FR
+	Rule string `json:"rule" validate:"required,max=500"`
+	// Start - первое повторение и время суток всех повторений, по умолчанию - сейчас
+	Start *time.Time `json:"start"`
+	// Timezone - часовой пояс IANA, в котором считаются дни повторений, по умолчанию UTC
+	Timezone string `json:"timezone" validate:"max=64"`
+	// Mode - schedule (по умолчанию) или completion
+	Mode   string `json:"mode" validate:"omitempty,oneof=schedule completion"`
+	Active *bool  `json:"active"`
+}
+
+// RecurrenceResponse - правило повторения и ближайшие сроки задач по нему
+type RecurrenceResponse struct {
+	Recurrence *db.Recurrence `json:"recurrence"`
+	// Upcoming - до пяти ближайших сроков, пустой - правило неактивно или исчерпано
+	Upcoming []time.Time `json:"upcoming"`
+}
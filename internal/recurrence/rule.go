@@ -0,0 +1,399 @@
+package recurrence
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Частоты повторения
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+const (
+	// maxInterval - наибольший INTERVAL
+	maxInterval = 1000
+	// maxEmptyPeriods - сколько периодов подряд без повторений перебирается,
+	// прежде чем правило считается исчерпанным
+	maxEmptyPeriods = 1000
+)
+
+// weekdays - дни недели в записи RRULE
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Weekday - день недели из BYDAY. N - номер дня в месяце для MONTHLY: 1 - первый,
+// -1 - последний, 0 - каждый
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+func (w Weekday) String() string {
+	for name, day := range weekdays {
+		if day == w.Day {
+			if w.N != 0 {
+				return strconv.Itoa(w.N) + name
+			}
+			return name
+		}
+	}
+
+	return ""
+}
+
+// Rule - правило повторения, подмножество RRULE из RFC 5545:
+// FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, BYDAY, UNTIL и COUNT
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []Weekday
+	// Until - последний возможный момент повторения, нулевой - без ограничения
+	Until time.Time
+	// Count - число повторений, 0 - без ограничения
+	Count int
+}
+
+// ParseRule разбирает правило вида FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10.
+// UNTIL без времени или без Z понимается в часовом поясе loc
+func ParseRule(value string, loc *time.Location) (Rule, error) {
+	rule := Rule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return Rule{}, errors.New("rule is empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !ok || val == "" {
+			return Rule{}, errors.Errorf("invalid rule part %q", part)
+		}
+		if seen[name] {
+			return Rule{}, errors.Errorf("%s is set twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return Rule{}, errors.Errorf("unsupported FREQ %q, use DAILY, WEEKLY or MONTHLY", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 || rule.Interval > maxInterval {
+				return Rule{}, errors.Errorf("INTERVAL must be from 1 to %d, got %q", maxInterval, val)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 {
+				return Rule{}, errors.Errorf("COUNT must be a positive number, got %q", val)
+			}
+		case "UNTIL":
+			if rule.Until, err = parseUntil(val, loc); err != nil {
+				return Rule{}, err
+			}
+		case "BYDAY":
+			if rule.ByDay, err = parseByDay(val); err != nil {
+				return Rule{}, err
+			}
+		default:
+			return Rule{}, errors.Errorf("unsupported rule part %s", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, errors.New("FREQ is required")
+	}
+	if seen["COUNT"] && seen["UNTIL"] {
+		return Rule{}, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return Rule{}, errors.Errorf("BYDAY %s with a number is only allowed for MONTHLY", day)
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+// parseUntil разбирает UNTIL: 20261231, 20261231T235959 или 20261231T235959Z.
+// Дата без времени включает весь день
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return time.Time{}, errors.Errorf("invalid UNTIL %q, use YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+// parseByDay разбирает BYDAY: MO,WE или 1MO,-1FR
+func parseByDay(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, errors.Errorf("invalid BYDAY %q", item)
+		}
+
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, errors.Errorf("invalid BYDAY %q", item)
+		}
+
+		var n int
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, errors.Errorf("invalid BYDAY %q, the number must be from -5 to 5", item)
+			}
+		}
+
+		days = append(days, Weekday{Day: day, N: n})
+	}
+
+	return days, nil
+}
+
+// String возвращает правило в каноническом виде
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Schedule - правило, привязанное к началу. Повторения не раньше Start, в его
+// времени суток и часовом поясе. Start - первое повторение, если подходит под правило
+type Schedule struct {
+	Rule  Rule
+	Start time.Time
+}
+
+// Next возвращает первое повторение не раньше t
+func (s Schedule) Next(t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	s.each(func(occurrence time.Time) bool {
+		if occurrence.Before(t) {
+			return true
+		}
+		next, found = occurrence, true
+		return false
+	})
+
+	return next, found
+}
+
+// After возвращает первое повторение позже t
+func (s Schedule) After(t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	s.each(func(occurrence time.Time) bool {
+		if !occurrence.After(t) {
+			return true
+		}
+		next, found = occurrence, true
+		return false
+	})
+
+	return next, found
+}
+
+// Latest возвращает последнее повторение не позже t
+func (s Schedule) Latest(t time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	s.each(func(occurrence time.Time) bool {
+		if occurrence.After(t) {
+			return false
+		}
+		latest, found = occurrence, true
+		return true
+	})
+
+	return latest, found
+}
+
+// Upcoming возвращает до n повторений не раньше t
+func (s Schedule) Upcoming(t time.Time, n int) []time.Time {
+	var result []time.Time
+	s.each(func(occurrence time.Time) bool {
+		if occurrence.Before(t) {
+			return true
+		}
+		result = append(result, occurrence)
+		return len(result) < n
+	})
+
+	return result
+}
+
+// each перебирает повторения по порядку, пока yield возвращает true
+func (s Schedule) each(yield func(time.Time) bool) {
+	start := s.Start
+	count := 0
+	empty := 0
+
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		days := s.periodDays(period)
+		if len(days) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		for _, day := range days {
+			occurrence := time.Date(day.Year(), day.Month(), day.Day(),
+				start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+			if occurrence.Before(start) {
+				continue
+			}
+			if !s.Rule.Until.IsZero() && occurrence.After(s.Rule.Until) {
+				return
+			}
+
+			count++
+			if !yield(occurrence) {
+				return
+			}
+			if s.Rule.Count > 0 && count >= s.Rule.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodDays возвращает дни повторений в периоде с номером period по порядку.
+// Время суток в результате не важно
+func (s Schedule) periodDays(period int) []time.Time {
+	start := s.Start
+	step := period * s.Rule.Interval
+	// Полдень не дает переходу на летнее время сдвинуть дату
+	base := time.Date(start.Year(), start.Month(), start.Day(), 12, 0, 0, 0, start.Location())
+
+	switch s.Rule.Freq {
+	case Daily:
+		day := base.AddDate(0, 0, step)
+		if len(s.Rule.ByDay) > 0 && !s.hasWeekday(day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+
+	case Weekly:
+		// Недели начинаются с понедельника (WKST=MO)
+		monday := base.AddDate(0, 0, -((int(base.Weekday())+6)%7)+7*step)
+		if len(s.Rule.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, (int(start.Weekday())+6)%7)}
+		}
+
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if s.hasWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+		return days
+
+	case Monthly:
+		first := time.Date(base.Year(), base.Month()+time.Month(step), 1, 12, 0, 0, 0, start.Location())
+		if len(s.Rule.ByDay) == 0 {
+			// Месяцы без нужного числа пропускаются, как в RFC 5545
+			day := first.AddDate(0, 0, start.Day()-1)
+			if day.Month() != first.Month() {
+				return nil
+			}
+			return []time.Time{day}
+		}
+		return monthDays(first, s.Rule.ByDay)
+	}
+
+	return nil
+}
+
+// hasWeekday - есть ли день недели в BYDAY
+func (s Schedule) hasWeekday(day time.Weekday) bool {
+	return slices.ContainsFunc(s.Rule.ByDay, func(w Weekday) bool { return w.Day == day })
+}
+
+// monthDays возвращает дни месяца, начинающегося с first, подходящие под BYDAY
+func monthDays(first time.Time, byDay []Weekday) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+
+	var days []time.Time
+	for d := 1; d <= last; d++ {
+		day := first.AddDate(0, 0, d-1)
+		for _, w := range byDay {
+			if w.Day != day.Weekday() {
+				continue
+			}
+			// Номер дня недели в месяце с начала и с конца
+			fromStart := (d-1)/7 + 1
+			fromEnd := -((last-d)/7 + 1)
+			if w.N == 0 || w.N == fromStart || w.N == fromEnd {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+
+	return days
+}
+
+// firstDue возвращает срок первой задачи по расписанию: первое повторение
+// не раньше начала и не раньше now
+func firstDue(s Schedule, now time.Time) (time.Time, error) {
+	from := s.Start
+	if now.After(from) {
+		from = now
+	}
+
+	due, ok := s.Next(from)
+	if !ok {
+		return time.Time{}, errors.Errorf("rule %s has no occurrences after %s", s.Rule, from.Format(time.RFC3339))
+	}
+
+	return due, nil
+}
@@ -0,0 +1,217 @@
+package recurrence
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseRule(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "daily", value: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{name: "prefix and case", value: "RRULE:freq=weekly;byday=mo,we", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{name: "interval and count", value: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;COUNT=10", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;COUNT=10"},
+		{name: "monthly ordinal", value: "FREQ=MONTHLY;BYDAY=1MO,-1FR", want: "FREQ=MONTHLY;BYDAY=1MO,-1FR"},
+		// Дата без времени включает весь день в часовом поясе правила
+		{name: "until date", value: "FREQ=DAILY;UNTIL=20261231", want: "FREQ=DAILY;UNTIL=20261231T205959Z"},
+		{name: "until local", value: "FREQ=DAILY;UNTIL=20261231T120000", want: "FREQ=DAILY;UNTIL=20261231T090000Z"},
+		{name: "until utc", value: "FREQ=DAILY;UNTIL=20261231T120000Z", want: "FREQ=DAILY;UNTIL=20261231T120000Z"},
+
+		{name: "empty", value: " ", wantErr: true},
+		{name: "no freq", value: "INTERVAL=2", wantErr: true},
+		{name: "yearly", value: "FREQ=YEARLY", wantErr: true},
+		{name: "zero interval", value: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "zero count", value: "FREQ=DAILY;COUNT=0", wantErr: true},
+		{name: "count and until", value: "FREQ=DAILY;COUNT=3;UNTIL=20261231", wantErr: true},
+		{name: "twice", value: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{name: "ordinal not monthly", value: "FREQ=WEEKLY;BYDAY=2MO", wantErr: true},
+		{name: "ordinal out of range", value: "FREQ=MONTHLY;BYDAY=6MO", wantErr: true},
+		{name: "unknown day", value: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "unsupported part", value: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		{name: "bad until", value: "FREQ=DAILY;UNTIL=2026-12-31", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.value, moscow)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRule(%q) = %s, want error", tt.value, rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("ParseRule(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleOccurrences(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	date := func(loc *time.Location, year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []string
+	}{
+		{
+			name:  "second tuesday",
+			rule:  "FREQ=MONTHLY;BYDAY=2TU",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-13T09:00:00Z", "2026-02-10T09:00:00Z", "2026-03-10T09:00:00Z"},
+		},
+		{
+			name:  "last friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-30T09:00:00Z", "2026-02-27T09:00:00Z", "2026-03-27T09:00:00Z"},
+		},
+		{
+			// Месяцы без 31-го числа пропускаются, а не сдвигаются на последний день
+			name:  "skips months without the day",
+			rule:  "FREQ=MONTHLY",
+			start: date(time.UTC, 2026, time.January, 31, 9, 0),
+			want:  []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			name:  "skips february 29",
+			rule:  "FREQ=MONTHLY;INTERVAL=12",
+			start: date(time.UTC, 2028, time.February, 29, 9, 0),
+			want:  []string{"2028-02-29T09:00:00Z", "2032-02-29T09:00:00Z", "2036-02-29T09:00:00Z"},
+		},
+		{
+			name:  "weekly interval",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			start: date(time.UTC, 2026, time.January, 7, 9, 0),
+			want:  []string{"2026-01-07T09:00:00Z", "2026-01-19T09:00:00Z", "2026-01-21T09:00:00Z"},
+		},
+		{
+			name:  "count",
+			rule:  "FREQ=DAILY;COUNT=2",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-01T09:00:00Z", "2026-01-02T09:00:00Z"},
+		},
+		{
+			// COUNT считает повторения с начала, а не с первого запрошенного
+			name:  "count with byday",
+			rule:  "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=3",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-01T09:00:00Z", "2026-01-06T09:00:00Z", "2026-01-08T09:00:00Z"},
+		},
+		{
+			name:  "until date includes the day",
+			rule:  "FREQ=DAILY;UNTIL=20260102",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-01T09:00:00Z", "2026-01-02T09:00:00Z"},
+		},
+		{
+			name:  "until time excludes later occurrence",
+			rule:  "FREQ=DAILY;UNTIL=20260102T085959Z",
+			start: date(time.UTC, 2026, time.January, 1, 9, 0),
+			want:  []string{"2026-01-01T09:00:00Z"},
+		},
+		{
+			// Переход на летнее время 29 марта: время суток сохраняется, смещение меняется
+			name:  "dst spring",
+			rule:  "FREQ=DAILY",
+			start: date(berlin, 2026, time.March, 28, 9, 0),
+			want:  []string{"2026-03-28T09:00:00+01:00", "2026-03-29T09:00:00+02:00", "2026-03-30T09:00:00+02:00"},
+		},
+		{
+			name:  "dst autumn",
+			rule:  "FREQ=WEEKLY",
+			start: date(berlin, 2026, time.October, 18, 23, 30),
+			want:  []string{"2026-10-18T23:30:00+02:00", "2026-10-25T23:30:00+01:00", "2026-11-01T23:30:00+01:00"},
+		},
+		{
+			// Полночь в день перехода не сдвигает дату
+			name:  "dst midnight",
+			rule:  "FREQ=MONTHLY;BYDAY=-1SU",
+			start: date(berlin, 2026, time.March, 1, 0, 0),
+			want:  []string{"2026-03-29T00:00:00+01:00", "2026-04-26T00:00:00+02:00", "2026-05-31T00:00:00+02:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule, tt.start.Location())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, occurrence := range (Schedule{Rule: rule, Start: tt.start}).Upcoming(tt.start, len(tt.want)+1) {
+				got = append(got, occurrence.Format(time.RFC3339))
+			}
+			if len(got) > len(tt.want) && (rule.Count > 0 || !rule.Until.IsZero()) {
+				t.Fatalf("occurrences = %v, want only %v", got, tt.want)
+			}
+			if got = got[:min(len(got), len(tt.want))]; !slices.Equal(got, tt.want) {
+				t.Errorf("occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextLatest(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, moscow)
+	}
+
+	// Понедельник 1 июня, затем понедельники и пятницы: последнее, десятое, - пятница 3 июля
+	rule, err := ParseRule("FREQ=WEEKLY;BYDAY=MO,FR;COUNT=10", moscow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched := Schedule{Rule: rule, Start: at(time.June, 1, 9)}
+
+	tests := []struct {
+		name   string
+		method func(time.Time) (time.Time, bool)
+		t      time.Time
+		want   time.Time
+	}{
+		{name: "next before start", method: sched.Next, t: at(time.May, 1, 0), want: at(time.June, 1, 9)},
+		{name: "next at occurrence", method: sched.Next, t: at(time.June, 1, 9), want: at(time.June, 1, 9)},
+		{name: "next between", method: sched.Next, t: at(time.June, 1, 10), want: at(time.June, 5, 9)},
+		{name: "next after last", method: sched.Next, t: at(time.July, 3, 10)},
+		{name: "after occurrence", method: sched.After, t: at(time.June, 5, 9), want: at(time.June, 8, 9)},
+		{name: "after last", method: sched.After, t: at(time.July, 3, 9)},
+		{name: "latest before start", method: sched.Latest, t: at(time.June, 1, 8)},
+		{name: "latest at occurrence", method: sched.Latest, t: at(time.June, 5, 9), want: at(time.June, 5, 9)},
+		{name: "latest between", method: sched.Latest, t: at(time.June, 10, 12), want: at(time.June, 8, 9)},
+		{name: "latest after last", method: sched.Latest, t: at(time.December, 31, 0), want: at(time.July, 3, 9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.method(tt.t)
+			if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Errorf("got %v, %v; want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
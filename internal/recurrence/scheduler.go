@@ -0,0 +1,141 @@
+package recurrence
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/health"
+	"restapi/internal/repo/db"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Scheduler создает задачи по правилам повторения. Несколько экземпляров сервиса
+// могут работать одновременно: каждое правило обрабатывает тот, кто получил его
+// advisory-блокировку, а уникальный срок задачи не дает создать ее дважды
+type Scheduler struct {
+	log  *zap.SugaredLogger
+	repo db.RecurrenceRepository
+	cfg  config.Recurrence
+	beat *health.Heartbeat
+}
+
+// NewScheduler создает планировщик повторяющихся задач
+func NewScheduler(log *zap.SugaredLogger, repo db.RecurrenceRepository, cfg config.Recurrence) *Scheduler {
+	return &Scheduler{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+		beat: health.NewHeartbeat(cfg.PollInterval),
+	}
+}
+
+// Check - проверка живости: правила повторения обрабатываются
+func (s *Scheduler) Check(ctx context.Context) error {
+	return s.beat.Check(ctx)
+}
+
+// Run создает задачи по правилам до отмены контекста
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.beat.Beat()
+		s.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process создает задачи по одной порции правил, которым пора сработать
+func (s *Scheduler) process(ctx context.Context) {
+	now := time.Now()
+
+	ids, err := s.repo.DueRecurrences(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		s.log.Errorf("Error getting due recurrences: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.beat.Beat()
+		task, err := s.repo.MaterializeRecurrence(ctx, id, now, func(rec *db.Recurrence) (db.RecurrencePlan, error) {
+			return Plan(rec, now)
+		})
+		if err != nil {
+			// Правило остается к исполнению и сработает, когда в колонке освободится место
+			if errors.Is(err, db.ErrWIPLimit) {
+				s.log.Warnf("Recurrence %d postponed: %v", id, err)
+				continue
+			}
+			s.log.Errorf("Error materializing recurrence %d: %v", id, err)
+			continue
+		}
+
+		if task != nil {
+			s.log.Infow("Recurring task created", "recurrence_id", id, "task_id", task.ID, "due_at", task.DueAt)
+		}
+	}
+}
+
+// Plan рассчитывает задачу, которую пора создать по правилу rec в момент now.
+// По расписанию создается только последнее наступившее повторение: пропущенные,
+// пока сервис не работал, не создаются. После выполнения предыдущей задачи
+// создается задача с ближайшим сроком не раньше now. Первая задача по правилу
+// completion получает срок первого повторения, даже если обработчик опоздал
+func Plan(rec *db.Recurrence, now time.Time) (db.RecurrencePlan, error) {
+	sched, err := schedule(rec)
+	if err != nil {
+		return db.RecurrencePlan{}, err
+	}
+
+	var (
+		due time.Time
+		ok  bool
+	)
+	switch rec.Mode {
+	case db.RecurrenceCompletion:
+		from := now
+		if rec.NextAt != nil && (rec.LastTaskID == nil || rec.NextAt.After(from)) {
+			from = *rec.NextAt
+		}
+		due, ok = sched.Next(from)
+	default:
+		due, ok = sched.Latest(now)
+	}
+	if !ok {
+		return db.RecurrencePlan{}, nil
+	}
+
+	plan := db.RecurrencePlan{Due: &due}
+	if next, ok := sched.After(due); ok {
+		plan.Next = &next
+	}
+
+	return plan, nil
+}
+
+// schedule возвращает расписание сохраненного правила
+func schedule(rec *db.Recurrence) (Schedule, error) {
+	loc, err := time.LoadLocation(rec.Timezone)
+	if err != nil {
+		return Schedule{}, errors.Wrapf(err, "invalid time zone of recurrence %d", rec.ID)
+	}
+
+	rule, err := ParseRule(rec.Rule, loc)
+	if err != nil {
+		return Schedule{}, errors.Wrapf(err, "invalid rule of recurrence %d", rec.ID)
+	}
+
+	return Schedule{Rule: rule, Start: rec.StartAt.In(loc)}, nil
+}
@@ -0,0 +1,118 @@
+package recurrence
+
+import (
+	"restapi/internal/repo/db"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	// Каждый день в 09:00 UTC с 1 июня, обработчик опрашивает правила раз в минуту
+	start := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	ptr := func(t time.Time) *time.Time { return &t }
+	taskID := int64(7)
+
+	tests := []struct {
+		name     string
+		rule     string
+		mode     string
+		nextAt   *time.Time
+		lastTask *int64
+		now      time.Time
+		wantDue  *time.Time
+		wantNext *time.Time
+	}{
+		{
+			name:     "schedule",
+			mode:     db.RecurrenceSchedule,
+			nextAt:   ptr(start),
+			now:      start.Add(time.Minute),
+			wantDue:  ptr(start),
+			wantNext: ptr(start.Add(day)),
+		},
+		{
+			// Пропущенные повторения не создаются, только последнее наступившее
+			name:     "schedule after downtime",
+			mode:     db.RecurrenceSchedule,
+			nextAt:   ptr(start),
+			now:      start.Add(3*day + time.Hour),
+			wantDue:  ptr(start.Add(3 * day)),
+			wantNext: ptr(start.Add(4 * day)),
+		},
+		{
+			// Первая задача получает срок первого повторения, хотя обработчик опоздал
+			name:     "completion first task",
+			mode:     db.RecurrenceCompletion,
+			nextAt:   ptr(start),
+			now:      start.Add(time.Minute),
+			wantDue:  ptr(start),
+			wantNext: ptr(start.Add(day)),
+		},
+		{
+			name:     "completion done before next",
+			mode:     db.RecurrenceCompletion,
+			nextAt:   ptr(start.Add(day)),
+			lastTask: &taskID,
+			now:      start.Add(time.Hour),
+			wantDue:  ptr(start.Add(day)),
+			wantNext: ptr(start.Add(2 * day)),
+		},
+		{
+			name:     "completion done late",
+			mode:     db.RecurrenceCompletion,
+			nextAt:   ptr(start.Add(day)),
+			lastTask: &taskID,
+			now:      start.Add(2*day + time.Hour),
+			wantDue:  ptr(start.Add(3 * day)),
+			wantNext: ptr(start.Add(4 * day)),
+		},
+		{
+			name:    "last occurrence",
+			rule:    "FREQ=DAILY;COUNT=2",
+			mode:    db.RecurrenceSchedule,
+			nextAt:  ptr(start.Add(day)),
+			now:     start.Add(day),
+			wantDue: ptr(start.Add(day)),
+		},
+		{
+			name:     "exhausted",
+			rule:     "FREQ=DAILY;COUNT=2",
+			mode:     db.RecurrenceCompletion,
+			lastTask: &taskID,
+			now:      start.Add(3 * day),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			if rule == "" {
+				rule = "FREQ=DAILY"
+			}
+			rec := &db.Recurrence{
+				ID:         1,
+				Rule:       rule,
+				StartAt:    start,
+				Timezone:   "UTC",
+				Mode:       tt.mode,
+				NextAt:     tt.nextAt,
+				LastTaskID: tt.lastTask,
+			}
+
+			plan, err := Plan(rec, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalTime(plan.Due, tt.wantDue) || !equalTime(plan.Next, tt.wantNext) {
+				t.Errorf("plan = due %v, next %v; want due %v, next %v", plan.Due, plan.Next, tt.wantDue, tt.wantNext)
+			}
+		})
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
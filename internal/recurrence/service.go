@@ -0,0 +1,197 @@
+package recurrence
+
+import (
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	upcomingLimit int = 5
+)
+
+type service struct {
+	log  *zap.SugaredLogger
+	repo db.RecurrenceRepository
+}
+
+// Service - интерфейс управления правилами повторения задач
+type Service interface {
+	SetRecurrence(ctx *fiber.Ctx) error
+	GetRecurrence(ctx *fiber.Ctx) error
+	DeleteRecurrence(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, repo db.RecurrenceRepository) Service {
+	return &service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (s *service) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), s.log)
+}
+
+// SetRecurrence - создает или заменяет правило повторения задачи. Задача становится
+// шаблоном: по правилу создаются ее копии со сроками повторений
+func (s *service) SetRecurrence(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req RecurrenceRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	rec, sched, fieldErr := newRecurrence(int64(id), req, time.Now())
+	if fieldErr != nil {
+		s.logger(ctx).Errorf("Invalid recurrence %s: %s", fieldErr.Field, fieldErr.Message)
+		return dto.ValidationError(ctx, "Invalid request body", []dto.FieldError{*fieldErr})
+	}
+
+	saved, err := s.repo.SetRecurrence(ctx.UserContext(), rec)
+	if err != nil {
+		return s.repoError(ctx, err, "Error setting recurrence", "Task not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   response(saved, sched),
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetRecurrence - возвращает правило повторения задачи и ближайшие сроки
+func (s *service) GetRecurrence(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	rec, err := s.repo.GetRecurrence(ctx.UserContext(), int64(id))
+	if err != nil {
+		return s.repoError(ctx, err, "Error getting recurrence", "Recurrence not found")
+	}
+
+	sched, err := schedule(rec)
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting recurrence: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   response(rec, sched),
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteRecurrence - удаляет правило повторения, созданные по нему задачи остаются
+func (s *service) DeleteRecurrence(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	if err := s.repo.DeleteRecurrence(ctx.UserContext(), int64(id)); err != nil {
+		return s.repoError(ctx, err, "Error deleting recurrence", "Recurrence not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// repoError - преобразует ошибку репозитория в ответ
+func (s *service) repoError(ctx *fiber.Ctx, err error, msg, notFound string) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return dto.NotFoundError(ctx, notFound)
+	}
+
+	s.logger(ctx).Errorf("%s: %v", msg, zap.Error(err))
+	return dto.InternalServerError(ctx)
+}
+
+// newRecurrence собирает правило задачи taskID из запроса и рассчитывает срок первой задачи.
+// Ошибка описывает поле запроса с неверным значением
+func newRecurrence(taskID int64, req RecurrenceRequest, now time.Time) (db.Recurrence, Schedule, *dto.FieldError) {
+	fieldError := func(field, rule string, err error) *dto.FieldError {
+		return &dto.FieldError{In: "body", Field: field, Rule: rule, Message: err.Error()}
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return db.Recurrence{}, Schedule{}, fieldError("timezone", "timezone", errors.Errorf("unknown time zone %q", req.Timezone))
+	}
+
+	rule, err := ParseRule(req.Rule, loc)
+	if err != nil {
+		return db.Recurrence{}, Schedule{}, fieldError("rule", "rrule", err)
+	}
+
+	start := now.Truncate(time.Second)
+	if req.Start != nil {
+		start = *req.Start
+	}
+	sched := Schedule{Rule: rule, Start: start.In(loc)}
+
+	rec := db.Recurrence{
+		TaskID:   taskID,
+		Rule:     rule.String(),
+		StartAt:  sched.Start,
+		Timezone: req.Timezone,
+		Mode:     req.Mode,
+		Active:   req.Active == nil || *req.Active,
+	}
+	if rec.Mode == "" {
+		rec.Mode = db.RecurrenceSchedule
+	}
+
+	due, err := firstDue(sched, now)
+	if err != nil {
+		return db.Recurrence{}, Schedule{}, fieldError("rule", "rrule", err)
+	}
+	rec.NextAt = &due
+
+	return rec, sched, nil
+}
+
+// response - ответ с правилом и ближайшими сроками
+func response(rec *db.Recurrence, sched Schedule) RecurrenceResponse {
+	upcoming := []time.Time{}
+	if rec.Active && rec.NextAt != nil {
+		upcoming = append(upcoming, sched.Upcoming(*rec.NextAt, upcomingLimit)...)
+	}
+
+	return RecurrenceResponse{
+		Recurrence: rec,
+		Upcoming:   upcoming,
+	}
+}
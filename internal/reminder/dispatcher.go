@@ -0,0 +1,203 @@
+package reminder
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/health"
+	"restapi/internal/metrics"
+	"restapi/internal/repo/db"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Dispatcher отправляет напоминания, срок которых наступил. Несколько экземпляров
+// сервиса забирают разные напоминания (FOR UPDATE SKIP LOCKED)
+type Dispatcher struct {
+	log       *zap.SugaredLogger
+	repo      db.ReminderRepository
+	notifiers map[string]Notifier
+	cfg       config.Reminder
+	beat      *health.Heartbeat
+}
+
+// NewDispatcher создает диспетчер напоминаний с каналами notifiers по имени
+// (ChannelEmail, ChannelWebhook, ChannelLog). Канал без отправителя считается
+// недоступным, попытки отправить в него завершаются ошибкой
+func NewDispatcher(log *zap.SugaredLogger, repo db.ReminderRepository, notifiers map[string]Notifier, cfg config.Reminder) *Dispatcher {
+	return &Dispatcher{
+		log:       log,
+		repo:      repo,
+		notifiers: notifiers,
+		cfg:       cfg,
+		beat:      health.NewHeartbeat(cfg.PollInterval),
+	}
+}
+
+// Notifiers собирает каналы по конфигурации: email - при заданном SMTP_HOST
+func Notifiers(log *zap.SugaredLogger, cfg config.Reminder) map[string]Notifier {
+	notifiers := map[string]Notifier{
+		ChannelWebhook: NewWebhookNotifier(cfg.Timeout),
+		ChannelLog:     NewLogNotifier(log),
+	}
+	if cfg.SMTP.Host != "" {
+		notifiers[ChannelEmail] = NewSMTPNotifier(cfg.SMTP, cfg.Timeout)
+	}
+
+	return notifiers
+}
+
+// Check - проверка живости: очередь напоминаний разбирается
+func (d *Dispatcher) Check(ctx context.Context) error {
+	return d.beat.Check(ctx)
+}
+
+// Run отправляет напоминания до отмены контекста
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.beat.Beat()
+		d.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process отправляет до BatchSize напоминаний. Напоминания забираются по одному:
+// аренда покрывает отправку одного напоминания во все каналы, и остальные не
+// повторятся у другого экземпляра, пока это отправляется
+func (d *Dispatcher) process(ctx context.Context) {
+	lease := time.Duration(len(d.notifiers))*d.cfg.Timeout + d.cfg.PollInterval
+
+	for i := 0; i < d.cfg.BatchSize && ctx.Err() == nil; i++ {
+		reminders, err := d.repo.ClaimReminders(ctx, 1, lease)
+		if err != nil {
+			d.log.Errorf("Error claiming reminders: %v", err)
+			return
+		}
+		if len(reminders) == 0 {
+			return
+		}
+
+		d.beat.Beat()
+		d.deliver(ctx, reminders[0])
+	}
+}
+
+// deliver отправляет напоминание во все каналы пользователя, кроме уже отправленных,
+// и сохраняет результат
+func (d *Dispatcher) deliver(ctx context.Context, reminder *db.Reminder) {
+	task, err := d.repo.GetTask(ctx, reminder.TaskID)
+	if err != nil {
+		d.log.Errorf("Error getting task %d for reminder %d: %v", reminder.TaskID, reminder.ID, err)
+		return
+	}
+	if task.Status == "done" {
+		d.skip(ctx, reminder, "task is done")
+		return
+	}
+
+	prefs, err := d.preferences(ctx, reminder.UserID)
+	if err != nil {
+		d.log.Errorf("Error getting notification preferences for reminder %d: %v", reminder.ID, err)
+		return
+	}
+	if len(prefs.Channels) == 0 {
+		d.skip(ctx, reminder, "user has no notification channels")
+		return
+	}
+
+	sent := slices.Clone(reminder.SentChannels)
+	if sent == nil {
+		sent = []string{}
+	}
+
+	var failures []string
+	n := Notification{Reminder: reminder, Task: task}
+	for _, channel := range prefs.Channels {
+		if slices.Contains(sent, channel) {
+			continue
+		}
+
+		if err := d.notify(ctx, channel, prefs, n); err != nil {
+			metrics.ObserveNotification(channel, "error")
+			failures = append(failures, channel+": "+err.Error())
+			continue
+		}
+
+		metrics.ObserveNotification(channel, "success")
+		sent = append(sent, channel)
+	}
+
+	if len(failures) == 0 {
+		if err := d.repo.SucceedReminder(ctx, reminder.ID, sent); err != nil {
+			d.log.Errorf("Error saving reminder %d: %v", reminder.ID, err)
+		}
+		return
+	}
+
+	attempts := reminder.Attempts + 1
+	lastError := strings.Join(failures, "; ")
+	d.log.Warnf("Reminder %d attempt %d failed: %s", reminder.ID, attempts, lastError)
+
+	if attempts >= d.cfg.MaxAttempts {
+		err = d.repo.FailReminder(ctx, reminder.ID, sent, lastError)
+	} else {
+		err = d.repo.RetryReminder(ctx, reminder.ID, sent, lastError, time.Now().Add(d.backoff(attempts)))
+	}
+	if err != nil {
+		d.log.Errorf("Error saving reminder %d: %v", reminder.ID, err)
+	}
+}
+
+// notify отправляет напоминание в один канал
+func (d *Dispatcher) notify(ctx context.Context, channel string, prefs *db.NotificationPreferences, n Notification) error {
+	notifier, ok := d.notifiers[channel]
+	if !ok {
+		return errors.Errorf("channel %s is not configured", channel)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	return notifier.Notify(ctx, prefs, n)
+}
+
+// preferences возвращает настройки пользователя, без настроек - каналы по умолчанию
+func (d *Dispatcher) preferences(ctx context.Context, userID string) (*db.NotificationPreferences, error) {
+	prefs, err := d.repo.GetPreferences(ctx, userID)
+	if errors.Is(err, db.ErrNotFound) {
+		return &db.NotificationPreferences{UserID: userID, Channels: d.cfg.DefaultChannels}, nil
+	}
+
+	return prefs, err
+}
+
+// skip отмечает напоминание ненужным
+func (d *Dispatcher) skip(ctx context.Context, reminder *db.Reminder, reason string) {
+	if err := d.repo.SkipReminder(ctx, reminder.ID, reason); err != nil {
+		d.log.Errorf("Error saving reminder %d: %v", reminder.ID, err)
+	}
+}
+
+// backoff возвращает задержку перед следующей попыткой: base * 2^(attempt-1), не больше max
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,216 @@
+package reminder
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/repo/db"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeRepo - очередь напоминаний в памяти. Методы, которые не нужны диспетчеру,
+// не реализованы
+type fakeRepo struct {
+	db.ReminderRepository
+
+	mu        sync.Mutex
+	reminders []*db.Reminder
+	prefs     map[string]*db.NotificationPreferences
+	// log - порядок вызовов: claim:<limit>, succeed, retry, fail, skip
+	log []string
+	// leases - аренды, с которыми забирались напоминания
+	leases []time.Duration
+}
+
+func (r *fakeRepo) ClaimReminders(ctx context.Context, limit int, lease time.Duration) ([]*db.Reminder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.log = append(r.log, "claim:"+strconv.Itoa(limit))
+	r.leases = append(r.leases, lease)
+
+	var claimed []*db.Reminder
+	for _, rem := range r.reminders {
+		if len(claimed) == limit {
+			break
+		}
+		if rem.Status == "pending" && !rem.NextAttemptAt.After(time.Now()) {
+			next := time.Now().Add(lease)
+			rem.NextAttemptAt = &next
+			copied := *rem
+			claimed = append(claimed, &copied)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *fakeRepo) GetTask(ctx context.Context, id int64) (*db.Task, error) {
+	return &db.Task{ID: id, Title: "Report", Status: "new"}, nil
+}
+
+func (r *fakeRepo) GetPreferences(ctx context.Context, userID string) (*db.NotificationPreferences, error) {
+	prefs, ok := r.prefs[userID]
+	if !ok {
+		return nil, errors.Wrap(db.ErrNotFound, "preferences not found")
+	}
+	return prefs, nil
+}
+
+func (r *fakeRepo) SucceedReminder(ctx context.Context, id int64, channels []string) error {
+	return r.update(id, "succeed", func(rem *db.Reminder) {
+		rem.Status, rem.SentChannels = "sent", channels
+	})
+}
+
+func (r *fakeRepo) RetryReminder(ctx context.Context, id int64, channels []string, lastError string, next time.Time) error {
+	return r.update(id, "retry", func(rem *db.Reminder) {
+		rem.Attempts++
+		rem.SentChannels, rem.LastError, rem.NextAttemptAt = channels, lastError, &next
+	})
+}
+
+func (r *fakeRepo) FailReminder(ctx context.Context, id int64, channels []string, lastError string) error {
+	return r.update(id, "fail", func(rem *db.Reminder) {
+		rem.Attempts++
+		rem.Status, rem.SentChannels, rem.LastError = "failed", channels, lastError
+	})
+}
+
+func (r *fakeRepo) SkipReminder(ctx context.Context, id int64, reason string) error {
+	return r.update(id, "skip", func(rem *db.Reminder) {
+		rem.Status, rem.LastError = "skipped", reason
+	})
+}
+
+func (r *fakeRepo) update(id int64, op string, apply func(rem *db.Reminder)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.log = append(r.log, op)
+	for _, rem := range r.reminders {
+		if rem.ID == id {
+			apply(rem)
+			return nil
+		}
+	}
+
+	return db.ErrNotFound
+}
+
+// fakeNotifier запоминает отправленные напоминания и отвечает ошибкой, пока fail
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []int64
+	fail bool
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, to *db.NotificationPreferences, note Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.fail {
+		return errors.New("connection refused")
+	}
+	n.sent = append(n.sent, note.Reminder.ID)
+	return nil
+}
+
+func newTestDispatcher(repo *fakeRepo, notifiers map[string]Notifier) *Dispatcher {
+	return NewDispatcher(zap.NewNop().Sugar(), repo, notifiers, config.Reminder{
+		PollInterval:    10 * time.Millisecond,
+		Timeout:         time.Second,
+		BatchSize:       50,
+		MaxAttempts:     3,
+		BaseBackoff:     time.Minute,
+		MaxBackoff:      time.Hour,
+		DefaultChannels: []string{ChannelLog},
+	})
+}
+
+func pendingReminders(n int) []*db.Reminder {
+	var reminders []*db.Reminder
+	for id := int64(1); id <= int64(n); id++ {
+		reminders = append(reminders, &db.Reminder{
+			ID: id, TaskID: 10, UserID: "alice", Status: "pending", NextAttemptAt: &time.Time{},
+		})
+	}
+	return reminders
+}
+
+func TestDispatcherClaimsOneReminderAtATime(t *testing.T) {
+	email, log := &fakeNotifier{}, &fakeNotifier{}
+	repo := &fakeRepo{
+		reminders: pendingReminders(3),
+		prefs: map[string]*db.NotificationPreferences{
+			"alice": {UserID: "alice", Channels: []string{ChannelEmail, ChannelLog}, Email: "alice@example.com"},
+		},
+	}
+	d := newTestDispatcher(repo, map[string]Notifier{ChannelEmail: email, ChannelLog: log})
+
+	d.process(context.Background())
+
+	// Следующее напоминание забирается только после отправки предыдущего, иначе
+	// его аренда истекает, пока отправляются остальные
+	want := []string{"claim:1", "succeed", "claim:1", "succeed", "claim:1", "succeed", "claim:1"}
+	if !slices.Equal(repo.log, want) {
+		t.Fatalf("calls = %v, want %v", repo.log, want)
+	}
+
+	// Аренда покрывает отправку одного напоминания во все каналы
+	if lease := 2*time.Second + 10*time.Millisecond; repo.leases[0] != lease {
+		t.Errorf("lease = %v, want %v", repo.leases[0], lease)
+	}
+	if !slices.Equal(email.sent, []int64{1, 2, 3}) || !slices.Equal(log.sent, []int64{1, 2, 3}) {
+		t.Errorf("sent: email %v, log %v, want each reminder once", email.sent, log.sent)
+	}
+}
+
+func TestDispatcherBatchSize(t *testing.T) {
+	repo := &fakeRepo{reminders: pendingReminders(3)}
+	d := newTestDispatcher(repo, map[string]Notifier{ChannelLog: &fakeNotifier{}})
+	d.cfg.BatchSize = 2
+
+	d.process(context.Background())
+
+	if want := []string{"claim:1", "succeed", "claim:1", "succeed"}; !slices.Equal(repo.log, want) {
+		t.Fatalf("calls = %v, want %v", repo.log, want)
+	}
+	if repo.reminders[2].Status != "pending" {
+		t.Errorf("reminder beyond the batch has status %s", repo.reminders[2].Status)
+	}
+}
+
+func TestDispatcherRetriesFailedChannels(t *testing.T) {
+	email, log := &fakeNotifier{fail: true}, &fakeNotifier{}
+	repo := &fakeRepo{
+		reminders: pendingReminders(1),
+		prefs: map[string]*db.NotificationPreferences{
+			"alice": {UserID: "alice", Channels: []string{ChannelEmail, ChannelLog}, Email: "alice@example.com"},
+		},
+	}
+	d := newTestDispatcher(repo, map[string]Notifier{ChannelEmail: email, ChannelLog: log})
+	rem := repo.reminders[0]
+
+	d.process(context.Background())
+	if rem.Status != "pending" || rem.Attempts != 1 || !slices.Equal(rem.SentChannels, []string{ChannelLog}) {
+		t.Fatalf("after failure: status %s, attempts %d, sent %v", rem.Status, rem.Attempts, rem.SentChannels)
+	}
+
+	// Повтор отправляет только в канал, который не ответил
+	email.fail = false
+	rem.NextAttemptAt = &time.Time{}
+	d.process(context.Background())
+	if rem.Status != "sent" || !slices.Equal(rem.SentChannels, []string{ChannelLog, ChannelEmail}) {
+		t.Fatalf("after retry: status %s, sent %v", rem.Status, rem.SentChannels)
+	}
+	if len(log.sent) != 1 || len(email.sent) != 1 {
+		t.Errorf("sent: log %v, email %v, want one each", log.sent, email.sent)
+	}
+}
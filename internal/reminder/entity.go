@@ -0,0 +1,21 @@
+package reminder
+
+import "time"
+
+// ReminderRequest - запрос на создание напоминания: в момент remind_at
+// или за before_seconds до срока задачи
+type ReminderRequest struct {
+	// UserID - кому напомнить, идентификатор пользователя во внешней системе.
+	// С ключом API по умолчанию - пользователь ключа, с TOKEN обязателен
+	UserID        string     `json:"user_id" validate:"omitempty,max=200"`
+	RemindAt      *time.Time `json:"remind_at"`
+	BeforeSeconds *int64     `json:"before_seconds" validate:"omitempty,min=0,max=31536000"`
+}
+
+// PreferencesRequest - настройки уведомлений пользователя
+type PreferencesRequest struct {
+	// Channels - каналы уведомлений, пустой список отключает уведомления
+	Channels   []string `json:"channels" validate:"required,dive,oneof=email webhook log"`
+	Email      string   `json:"email" validate:"omitempty,email,max=254"`
+	WebhookURL string   `json:"webhook_url" validate:"omitempty,url,max=2000"`
+}
@@ -0,0 +1,132 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"restapi/internal/repo/db"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Каналы уведомлений
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+)
+
+const (
+	userAgent    = "restapi-reminders/1.0"
+	maxErrorBody = 512
+)
+
+// Notification - напоминание о задаче для отправки
+type Notification struct {
+	Reminder *db.Reminder
+	Task     *db.Task
+}
+
+// Subject - тема уведомления
+func (n Notification) Subject() string {
+	return "Reminder: " + n.Task.Title
+}
+
+// Text - текст уведомления
+func (n Notification) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task #%d: %s\n", n.Task.ID, n.Task.Title)
+	fmt.Fprintf(&b, "Status: %s\n", n.Task.Status)
+	if n.Task.DueAt != nil {
+		fmt.Fprintf(&b, "Due: %s\n", n.Task.DueAt.UTC().Format(time.RFC1123))
+	}
+	if n.Task.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", n.Task.Description)
+	}
+
+	return b.String()
+}
+
+// Notifier - канал отправки напоминаний
+type Notifier interface {
+	Notify(ctx context.Context, to *db.NotificationPreferences, n Notification) error
+}
+
+// LogNotifier - пишет напоминания в лог
+type LogNotifier struct {
+	log *zap.SugaredLogger
+}
+
+// NewLogNotifier создает канал, пишущий напоминания в лог
+func NewLogNotifier(log *zap.SugaredLogger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+// Notify пишет напоминание в лог
+func (l *LogNotifier) Notify(ctx context.Context, to *db.NotificationPreferences, n Notification) error {
+	l.log.Infow("Task reminder", "reminder_id", n.Reminder.ID, "user_id", to.UserID, "task_id", n.Task.ID, "due_at", n.Task.DueAt)
+	return nil
+}
+
+// WebhookNotifier - отправляет напоминания POST-запросом на адрес из настроек пользователя
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier создает канал вебхуков с ограничением запроса timeout
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{Timeout: timeout}}
+}
+
+// webhookPayload - тело запроса с напоминанием
+type webhookPayload struct {
+	Event      string    `json:"event"`
+	ReminderID int64     `json:"reminder_id"`
+	UserID     string    `json:"user_id"`
+	Task       *db.Task  `json:"task"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Notify отправляет напоминание, ответ вне 2xx считается ошибкой
+func (w *WebhookNotifier) Notify(ctx context.Context, to *db.NotificationPreferences, n Notification) error {
+	if to.WebhookURL == "" {
+		return errors.New("user has no webhook URL")
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		Event:      "task.reminder",
+		ReminderID: n.Reminder.ID,
+		UserID:     to.UserID,
+		Task:       n.Task,
+		SentAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal reminder")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return errors.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package reminder
+
+import (
+	"restapi/internal/api/middleware"
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	maxUserID int = 200
+)
+
+type service struct {
+	log  *zap.SugaredLogger
+	repo db.ReminderRepository
+}
+
+// Service - интерфейс управления напоминаниями и настройками уведомлений
+type Service interface {
+	CreateReminder(ctx *fiber.Ctx) error
+	GetReminders(ctx *fiber.Ctx) error
+	DeleteReminder(ctx *fiber.Ctx) error
+	GetPreferences(ctx *fiber.Ctx) error
+	SetPreferences(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, repo db.ReminderRepository) Service {
+	return &service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (s *service) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), s.log)
+}
+
+// CreateReminder - создает напоминание о задаче
+func (s *service) CreateReminder(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req ReminderRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	if (req.RemindAt == nil) == (req.BeforeSeconds == nil) {
+		s.logger(ctx).Error("Invalid reminder: remind_at and before_seconds")
+		return dto.ValidationError(ctx, "Invalid request body", []dto.FieldError{{
+			In:      "body",
+			Field:   "remind_at",
+			Rule:    "required_without",
+			Param:   "before_seconds",
+			Message: "Exactly one of remind_at and before_seconds is required",
+		}})
+	}
+
+	// Владелец напоминания - пользователь ключа API, с TOKEN он указывается в запросе
+	userID := req.UserID
+	if userID == "" {
+		userID = middleware.UserID(ctx)
+	}
+	if userID == "" {
+		s.logger(ctx).Error("Invalid reminder: user_id is required")
+		return dto.ValidationError(ctx, "Invalid request body", []dto.FieldError{{
+			In:      "body",
+			Field:   "user_id",
+			Rule:    "required",
+			Message: "user_id is required",
+		}})
+	}
+	if ok, err := s.checkUser(ctx, userID); !ok {
+		return err
+	}
+
+	reminder, err := s.repo.CreateReminder(ctx.UserContext(), db.Reminder{
+		TaskID:        int64(id),
+		UserID:        userID,
+		RemindAt:      req.RemindAt,
+		BeforeSeconds: req.BeforeSeconds,
+	})
+	if err != nil {
+		return s.repoError(ctx, err, "Error creating reminder", "Task not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   reminder,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(responce)
+}
+
+// GetReminders - возвращает напоминания о задаче
+func (s *service) GetReminders(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	reminders, err := s.repo.GetReminders(ctx.UserContext(), int64(id))
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting reminders: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   reminders,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteReminder - удаляет напоминание о задаче
+func (s *service) DeleteReminder(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	reminderID, err := ctx.ParamsInt("reminder_id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid reminder id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid reminder id")
+	}
+
+	if err := s.repo.DeleteReminder(ctx.UserContext(), int64(id), int64(reminderID)); err != nil {
+		return s.repoError(ctx, err, "Error deleting reminder", "Reminder not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetPreferences - возвращает настройки уведомлений пользователя
+func (s *service) GetPreferences(ctx *fiber.Ctx) error {
+	userID, ok := s.userID(ctx)
+	if !ok {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid user id")
+	}
+
+	if ok, err := s.checkUser(ctx, userID); !ok {
+		return err
+	}
+
+	prefs, err := s.repo.GetPreferences(ctx.UserContext(), userID)
+	if err != nil {
+		return s.repoError(ctx, err, "Error getting notification preferences", "Notification preferences not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   prefs,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// SetPreferences - создает или заменяет настройки уведомлений пользователя
+func (s *service) SetPreferences(ctx *fiber.Ctx) error {
+	userID, ok := s.userID(ctx)
+	if !ok {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid user id")
+	}
+
+	if ok, err := s.checkUser(ctx, userID); !ok {
+		return err
+	}
+
+	var req PreferencesRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	// Адрес обязателен для канала, который его использует
+	var fields []dto.FieldError
+	if slices.Contains(req.Channels, ChannelEmail) && req.Email == "" {
+		fields = append(fields, dto.FieldError{In: "body", Field: "email", Rule: "required_if", Param: "channels email",
+			Message: "email is required for the email channel"})
+	}
+	if slices.Contains(req.Channels, ChannelWebhook) && req.WebhookURL == "" {
+		fields = append(fields, dto.FieldError{In: "body", Field: "webhook_url", Rule: "required_if", Param: "channels webhook",
+			Message: "webhook_url is required for the webhook channel"})
+	}
+	if len(fields) > 0 {
+		s.logger(ctx).Error("Invalid notification preferences: missing address")
+		return dto.ValidationError(ctx, "Invalid request body", fields)
+	}
+
+	prefs, err := s.repo.SetPreferences(ctx.UserContext(), db.NotificationPreferences{
+		UserID:     userID,
+		Channels:   slices.Compact(slices.Sorted(slices.Values(req.Channels))),
+		Email:      req.Email,
+		WebhookURL: req.WebhookURL,
+	})
+	if err != nil {
+		s.logger(ctx).Errorf("Error setting notification preferences: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   prefs,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// userID - идентификатор пользователя из пути
+func (s *service) userID(ctx *fiber.Ctx) (string, bool) {
+	userID := ctx.Params("user_id")
+	if userID == "" || len(userID) > maxUserID {
+		s.logger(ctx).Error("Invalid user id")
+		return "", false
+	}
+
+	// Параметры Fiber ссылаются на буфер запроса
+	return string([]byte(userID)), true
+}
+
+// checkUser - запрос с ключом API может обращаться только к напоминаниям и настройкам
+// пользователя ключа, запрос с TOKEN - любого пользователя. При отказе отправляет ответ и возвращает false
+func (s *service) checkUser(ctx *fiber.Ctx, userID string) (bool, error) {
+	caller := middleware.UserID(ctx)
+	if caller == "" || caller == userID {
+		return true, nil
+	}
+
+	s.logger(ctx).Warnf("Notifications of user %s requested with API key of user %s", userID, caller)
+	return false, dto.ForbiddenError(ctx, "user_id does not match the API key user")
+}
+
+// repoError - преобразует ошибку репозитория в ответ
+func (s *service) repoError(ctx *fiber.Ctx, err error, msg, notFound string) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return dto.NotFoundError(ctx, notFound)
+	}
+
+	s.logger(ctx).Errorf("%s: %v", msg, zap.Error(err))
+	return dto.InternalServerError(ctx)
+}
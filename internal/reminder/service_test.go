@@ -0,0 +1,153 @@
+package reminder
+
+import (
+	"context"
+	"net/http/httptest"
+	"restapi/internal/api/middleware"
+	"restapi/internal/auth"
+	"restapi/internal/repo/db"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const token = "secret"
+
+// fakeStore запоминает пользователей напоминаний и настроек. Остальные методы
+// хранилища не реализованы
+type fakeStore struct {
+	db.ReminderRepository
+
+	reminders []string
+	read      []string
+	written   []string
+}
+
+func (r *fakeStore) CreateReminder(ctx context.Context, reminder db.Reminder) (*db.Reminder, error) {
+	r.reminders = append(r.reminders, reminder.UserID)
+	return &reminder, nil
+}
+
+func (r *fakeStore) GetPreferences(ctx context.Context, userID string) (*db.NotificationPreferences, error) {
+	r.read = append(r.read, userID)
+	return &db.NotificationPreferences{UserID: userID}, nil
+}
+
+func (r *fakeStore) SetPreferences(ctx context.Context, prefs db.NotificationPreferences) (*db.NotificationPreferences, error) {
+	r.written = append(r.written, prefs.UserID)
+	return &prefs, nil
+}
+
+// fakeKeys - ключ API пользователя alice
+type fakeKeys struct {
+	hash string
+}
+
+func (k fakeKeys) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	if hash != k.hash {
+		return "", errors.Wrap(db.ErrNotFound, "API key not found")
+	}
+	return "alice", nil
+}
+
+func newTestApp(t *testing.T, repo *fakeStore) (*fiber.App, string) {
+	t.Helper()
+
+	key, _, hash, err := auth.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := zap.NewNop().Sugar()
+	svc := NewService(log, repo)
+
+	app := fiber.New()
+	app.Use(middleware.Autorization(log, auth.NewAuthenticator(func() string { return token }, fakeKeys{hash: hash})))
+	app.Post("/tasks/:id/reminders", svc.CreateReminder)
+	app.Get("/users/:user_id/notification-preferences", svc.GetPreferences)
+	app.Put("/users/:user_id/notification-preferences", svc.SetPreferences)
+
+	return app, key
+}
+
+func do(t *testing.T, app *fiber.App, method, path, secret, body string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+secret)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestReminderCallerCheck(t *testing.T) {
+	repo := &fakeStore{}
+	app, key := newTestApp(t, repo)
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   int
+	}{
+		{name: "own reminder", secret: key, body: `{"user_id":"alice","before_seconds":60}`, want: fiber.StatusCreated},
+		{name: "owner from key", secret: key, body: `{"before_seconds":60}`, want: fiber.StatusCreated},
+		{name: "other user", secret: key, body: `{"user_id":"bob","before_seconds":60}`, want: fiber.StatusForbidden},
+		{name: "token for any user", secret: token, body: `{"user_id":"bob","before_seconds":60}`, want: fiber.StatusCreated},
+		{name: "token without user", secret: token, body: `{"before_seconds":60}`, want: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := do(t, app, fiber.MethodPost, "/tasks/7/reminders", tt.secret, tt.body); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if want := []string{"alice", "alice", "bob"}; !slices.Equal(repo.reminders, want) {
+		t.Errorf("reminders created for %v, want %v", repo.reminders, want)
+	}
+}
+
+func TestPreferencesCallerCheck(t *testing.T) {
+	repo := &fakeStore{}
+	app, key := newTestApp(t, repo)
+
+	const body = `{"channels":["webhook"],"webhook_url":"https://example.com/hook"}`
+	tests := []struct {
+		name   string
+		secret string
+		userID string
+		want   int
+	}{
+		{name: "own preferences", secret: key, userID: "alice", want: fiber.StatusOK},
+		{name: "other user", secret: key, userID: "bob", want: fiber.StatusForbidden},
+		{name: "token for any user", secret: token, userID: "bob", want: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/users/" + tt.userID + "/notification-preferences"
+			if got := do(t, app, fiber.MethodGet, path, tt.secret, ""); got != tt.want {
+				t.Errorf("GET status = %d, want %d", got, tt.want)
+			}
+			if got := do(t, app, fiber.MethodPut, path, tt.secret, body); got != tt.want {
+				t.Errorf("PUT status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	want := []string{"alice", "bob"}
+	if !slices.Equal(repo.read, want) || !slices.Equal(repo.written, want) {
+		t.Errorf("preferences read for %v, written for %v, want %v", repo.read, repo.written, want)
+	}
+}
@@ -0,0 +1,137 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"restapi/internal/config"
+	"restapi/internal/repo/db"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SMTPNotifier - отправляет напоминания письмом на адрес из настроек пользователя
+type SMTPNotifier struct {
+	cfg     config.SMTP
+	timeout time.Duration
+	// tls - настройки TLS, ServerName по умолчанию - SMTP_HOST
+	tls *tls.Config
+}
+
+// NewSMTPNotifier создает почтовый канал с ограничением отправки письма timeout
+func NewSMTPNotifier(cfg config.SMTP, timeout time.Duration) *SMTPNotifier {
+	return &SMTPNotifier{
+		cfg:     cfg,
+		timeout: timeout,
+		tls:     &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12},
+	}
+}
+
+// Notify отправляет письмо. Пароль передается только по TLS или на localhost
+func (s *SMTPNotifier) Notify(ctx context.Context, to *db.NotificationPreferences, n Notification) error {
+	if to.Email == "" {
+		return errors.New("user has no email address")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tls); err != nil {
+			return errors.Wrap(err, "failed to start TLS")
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return errors.Wrap(err, "SMTP authentication failed")
+		}
+	}
+
+	if err := client.Mail(s.cfg.From); err != nil {
+		return errors.Wrap(err, "SMTP MAIL FROM failed")
+	}
+	if err := client.Rcpt(to.Email); err != nil {
+		return errors.Wrap(err, "SMTP RCPT TO failed")
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "SMTP DATA failed")
+	}
+	if _, err := w.Write(s.message(to.Email, n)); err != nil {
+		return errors.Wrap(err, "failed to write message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "SMTP server rejected the message")
+	}
+
+	return client.Quit()
+}
+
+// dial подключается к серверу с учетом SMTP_TLS. Соединение ограничено сроком ctx
+func (s *SMTPNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to SMTP server")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if s.cfg.TLS == "tls" {
+		tlsConn := tls.Client(conn, s.tls)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "TLS handshake failed")
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "SMTP handshake failed")
+	}
+
+	return client, nil
+}
+
+// message собирает письмо: заголовки и текст в quoted-printable
+func (s *SMTPNotifier) message(to string, n Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	text := strings.ReplaceAll(n.Text(), "\r\n", "\n")
+	_, _ = w.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	_ = w.Close()
+
+	return b.Bytes()
+}
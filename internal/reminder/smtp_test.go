@@ -0,0 +1,267 @@
+package reminder
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"restapi/internal/config"
+	"restapi/internal/repo/db"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP - SMTP-сервер на localhost, принимает письма по одному соединению за раз
+type fakeSMTP struct {
+	ln net.Listener
+	// extensions - расширения в ответе на EHLO
+	extensions []string
+	// rejectRcpt - ответ 550 на RCPT TO
+	rejectRcpt bool
+	// silent - сервер принимает соединение и ничего не отвечает
+	silent bool
+
+	mu       sync.Mutex
+	commands []string
+	auth     string
+	message  string
+}
+
+// newFakeSMTP запускает сервер, setup настраивает его до первого соединения
+func newFakeSMTP(t *testing.T, setup func(s *fakeSMTP)) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, extensions: []string{"AUTH PLAIN"}}
+	if setup != nil {
+		setup(s)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		_, _ = io.Copy(io.Discard, conn)
+		return
+	}
+
+	tp := textproto.NewConn(conn)
+	reply := func(lines ...string) {
+		for i, line := range lines {
+			sep := " "
+			if i < len(lines)-1 {
+				sep = "-"
+			}
+			_ = tp.PrintfLine("%s%s%s", line[:3], sep, line[4:])
+		}
+	}
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"250 localhost"}
+			for _, ext := range s.extensions {
+				lines = append(lines, "250 "+ext)
+			}
+			reply(lines...)
+		case "AUTH":
+			_, credentials, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(credentials)
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			if s.rejectRcpt {
+				reply("550 5.1.1 No such user")
+				continue
+			}
+			reply("250 2.1.5 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.message = string(data)
+			s.mu.Unlock()
+			reply("250 2.0.0 Queued")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+func newTestNotification() Notification {
+	due := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	return Notification{
+		Reminder: &db.Reminder{ID: 5, UserID: "alice"},
+		Task:     &db.Task{ID: 42, Title: "Отчет за май", Status: "new", Description: "Собрать цифры", DueAt: &due},
+	}
+}
+
+func newTestSMTPNotifier(server *fakeSMTP, cfg config.SMTP) *SMTPNotifier {
+	cfg.Host = "127.0.0.1"
+	cfg.Port = server.port()
+	if cfg.From == "" {
+		cfg.From = "tasks@example.com"
+	}
+	return NewSMTPNotifier(cfg, time.Second)
+}
+
+func TestSMTPNotifierSends(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	n := newTestSMTPNotifier(server, config.SMTP{TLS: "none", Username: "mailer", Password: "secret"})
+
+	to := &db.NotificationPreferences{UserID: "alice", Email: "alice@example.com"}
+	if err := n.Notify(context.Background(), to, newTestNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	// Пароль передается на localhost без TLS
+	if server.auth != "\x00mailer\x00secret" {
+		t.Errorf("AUTH PLAIN = %q, want mailer/secret", server.auth)
+	}
+
+	var mailFrom, rcptTo string
+	for _, cmd := range server.commands {
+		switch {
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mailFrom = cmd
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcptTo = cmd
+		}
+	}
+	if !strings.HasPrefix(mailFrom, "MAIL FROM:<tasks@example.com>") {
+		t.Errorf("MAIL = %q", mailFrom)
+	}
+	if rcptTo != "RCPT TO:<alice@example.com>" {
+		t.Errorf("RCPT = %q", rcptTo)
+	}
+	if last := server.commands[len(server.commands)-1]; last != "QUIT" {
+		t.Errorf("last command = %q, want QUIT", last)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.message))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, server.message)
+	}
+	if got := msg.Header.Get("To"); got != "alice@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Reminder: Отчет за май" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Task #42: Отчет за май", "Due: Mon, 01 Jun 2026 09:00:00 UTC", "Собрать цифры"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestSMTPNotifierErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(s *fakeSMTP)
+		cfg     config.SMTP
+		email   string
+		wantErr string
+	}{
+		{name: "no email", cfg: config.SMTP{TLS: "none"}, wantErr: "no email address"},
+		{
+			name:    "recipient rejected",
+			setup:   func(s *fakeSMTP) { s.rejectRcpt = true },
+			cfg:     config.SMTP{TLS: "none"},
+			email:   "nobody@example.com",
+			wantErr: "RCPT TO failed",
+		},
+		{
+			// Без STARTTLS письмо с паролем не отправляется открытым текстом
+			name:    "starttls not supported",
+			cfg:     config.SMTP{TLS: "starttls", Username: "mailer", Password: "secret"},
+			email:   "alice@example.com",
+			wantErr: "does not support STARTTLS",
+		},
+		{
+			name:    "server does not answer",
+			setup:   func(s *fakeSMTP) { s.silent = true },
+			cfg:     config.SMTP{TLS: "none"},
+			email:   "alice@example.com",
+			wantErr: "SMTP handshake failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, tt.setup)
+			n := newTestSMTPNotifier(server, tt.cfg)
+			n.timeout = 200 * time.Millisecond
+
+			start := time.Now()
+			err := n.Notify(context.Background(), &db.NotificationPreferences{UserID: "alice", Email: tt.email}, newTestNotification())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Notify returned after %v", elapsed)
+			}
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			for _, cmd := range server.commands {
+				if strings.HasPrefix(cmd, "AUTH") || cmd == "DATA" {
+					t.Errorf("unexpected command %q", cmd)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/event"
+	"restapi/internal/metrics"
+	"restapi/internal/rank"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// ErrWIPLimit - в колонке уже столько задач, сколько разрешает ее WIP-лимит
+var ErrWIPLimit = errors.New("WIP limit reached")
+
+// boardLock - пространство ключей advisory-блокировок колонок доски
+const boardLock int32 = 0x626f6172
+
+// Запросы доски
+const (
+	// lockColumnQuery - ключи колонки выдаются по очереди, иначе две задачи получат один ключ
+	lockColumnQuery = "SELECT pg_advisory_xact_lock($1, hashtext($2))"
+	lastRankQuery   = "SELECT max(rank) FROM tasks WHERE status = $1 AND id <> $2"
+	// nextRankQuery, prevRankQuery - соседний ключ колонки без перемещаемой задачи $3
+	nextRankQuery     = "SELECT min(rank) FROM tasks WHERE status = $1 AND rank > $2 AND id <> $3"
+	prevRankQuery     = "SELECT max(rank) FROM tasks WHERE status = $1 AND rank < $2 AND id <> $3"
+	neighborTaskQuery = "SELECT status, rank FROM tasks WHERE id = $1"
+	wipLimitQuery     = "SELECT wip_limit FROM board_columns WHERE status = $1"
+	countColumnQuery  = "SELECT count(*) FROM tasks WHERE status = $1"
+	moveTaskQuery     = "UPDATE tasks SET status = $2, rank = $3, updated_at = $4 WHERE id = $1 RETURNING " + taskColumns
+	getColumnsQuery   = `SELECT s.status, c.wip_limit, (SELECT count(*) FROM tasks t WHERE t.status = s.status)
+		FROM unnest($1::text[]) WITH ORDINALITY AS s(status, n)
+		LEFT JOIN board_columns c ON c.status = s.status
+		ORDER BY s.n`
+	setWIPLimitQuery = `INSERT INTO board_columns (status, wip_limit) VALUES ($1, $2)
+		ON CONFLICT (status) DO UPDATE SET wip_limit = EXCLUDED.wip_limit, updated_at = now()`
+	deleteWIPLimitQuery = "DELETE FROM board_columns WHERE status = $1"
+)
+
+// BoardRepository - порядок задач в колонках доски и WIP-лимиты колонок
+type BoardRepository interface {
+	MoveTask(ctx context.Context, id int64, move TaskMove) (*Task, error)
+	GetColumns(ctx context.Context, statuses []string) ([]BoardColumn, error)
+	SetWIPLimit(ctx context.Context, status string, limit int) error
+	DeleteWIPLimit(ctx context.Context, status string) error
+}
+
+// MoveTask переносит задачу в колонку move.Status между соседями и создает события
+// task.updated и task.status_changed в одной транзакции. Соседи не из этой колонки
+// или в неверном порядке - ErrConflict, переполненная колонка - ErrWIPLimit
+func (r *DBrepository) MoveTask(ctx context.Context, id int64, move TaskMove) (*Task, error) {
+	defer metrics.ObserveQuery("MoveTask", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		current *Task
+		events  []event.Event
+	)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		previous, err := scanTask(tx.QueryRow(ctx, lockTaskQuery, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.Wrap(ErrNotFound, "task not found")
+			}
+			return errors.Wrap(err, "failed to lock task")
+		}
+
+		status := move.Status
+		if status == "" {
+			status = previous.Status
+		}
+
+		if _, err := tx.Exec(ctx, lockColumnQuery, boardLock, status); err != nil {
+			return errors.Wrap(err, "failed to lock board column")
+		}
+
+		if status != previous.Status {
+			if err := checkWIPLimit(ctx, tx, status); err != nil {
+				return err
+			}
+		}
+
+		after, before, err := neighborRanks(ctx, tx, id, status, move)
+		if err != nil {
+			return err
+		}
+
+		key, err := rank.Between(after, before)
+		if err != nil {
+			return errors.Wrap(err, "failed to rank task")
+		}
+
+		if current, err = scanTask(tx.QueryRow(ctx, moveTaskQuery, id, status, key, time.Now())); err != nil {
+			return errors.Wrap(err, "failed to move task")
+		}
+
+		events = event.Changed(previous.event(), current.event())
+		for _, e := range events {
+			if err := insertOutbox(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) && !errors.Is(err, ErrWIPLimit) {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to move task"))
+		}
+		return nil, err
+	}
+
+	metrics.ObserveEvents(events...)
+
+	return current, nil
+}
+
+// GetColumns возвращает колонки statuses в том же порядке с числом задач и WIP-лимитами
+func (r *DBrepository) GetColumns(ctx context.Context, statuses []string) ([]BoardColumn, error) {
+	defer metrics.ObserveQuery("GetColumns", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, getColumnsQuery, statuses)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get board columns"))
+		return nil, errors.Wrap(err, "failed to get board columns")
+	}
+
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (BoardColumn, error) {
+		var column BoardColumn
+		err := row.Scan(&column.Status, &column.WIPLimit, &column.Tasks)
+		return column, err
+	})
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to scan board columns"))
+		return nil, errors.Wrap(err, "failed to scan board columns")
+	}
+
+	return columns, nil
+}
+
+// SetWIPLimit задает наибольшее число задач в колонке status
+func (r *DBrepository) SetWIPLimit(ctx context.Context, status string, limit int) error {
+	defer metrics.ObserveQuery("SetWIPLimit", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := r.pool.Exec(ctx, setWIPLimitQuery, status, limit); err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to set WIP limit"))
+		return errors.Wrap(err, "failed to set WIP limit")
+	}
+
+	return nil
+}
+
+// DeleteWIPLimit снимает WIP-лимит колонки status
+func (r *DBrepository) DeleteWIPLimit(ctx context.Context, status string) error {
+	defer metrics.ObserveQuery("DeleteWIPLimit", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, deleteWIPLimitQuery, status)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete WIP limit"))
+		return errors.Wrap(err, "failed to delete WIP limit")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "WIP limit not found")
+	}
+
+	return nil
+}
+
+// appendRank возвращает ключ конца колонки status для задачи id (0 - новой задачи).
+// Колонка блокируется до конца транзакции, полная колонка - ErrWIPLimit
+func appendRank(ctx context.Context, tx pgx.Tx, status string, id int64) (string, error) {
+	if _, err := tx.Exec(ctx, lockColumnQuery, boardLock, status); err != nil {
+		return "", errors.Wrap(err, "failed to lock board column")
+	}
+
+	if err := checkWIPLimit(ctx, tx, status); err != nil {
+		return "", err
+	}
+
+	var last *string
+	if err := tx.QueryRow(ctx, lastRankQuery, status, id).Scan(&last); err != nil {
+		return "", errors.Wrap(err, "failed to get last rank")
+	}
+
+	key, err := rank.Between(deref(last), "")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to rank task")
+	}
+
+	return key, nil
+}
+
+// checkWIPLimit возвращает ErrWIPLimit, если в колонку status нельзя добавить задачу
+func checkWIPLimit(ctx context.Context, tx pgx.Tx, status string) error {
+	var limit int64
+	if err := tx.QueryRow(ctx, wipLimitQuery, status).Scan(&limit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to get WIP limit")
+	}
+
+	var count int64
+	if err := tx.QueryRow(ctx, countColumnQuery, status).Scan(&count); err != nil {
+		return errors.Wrap(err, "failed to count column tasks")
+	}
+
+	if count >= limit {
+		return errors.Wrapf(ErrWIPLimit, "column %s has %d of %d tasks", status, count, limit)
+	}
+
+	return nil
+}
+
+// neighborRanks возвращает ключи, между которыми встает задача id в колонке status.
+// Без соседей задача встает в конец колонки
+func neighborRanks(ctx context.Context, tx pgx.Tx, id int64, status string, move TaskMove) (string, string, error) {
+	var after, before string
+
+	if move.AfterID != nil {
+		key, err := neighborRank(ctx, tx, *move.AfterID, status)
+		if err != nil {
+			return "", "", err
+		}
+		after = key
+	}
+	if move.BeforeID != nil {
+		key, err := neighborRank(ctx, tx, *move.BeforeID, status)
+		if err != nil {
+			return "", "", err
+		}
+		before = key
+	}
+
+	var (
+		adjacent *string
+		err      error
+	)
+	switch {
+	case move.AfterID != nil && move.BeforeID != nil:
+		if after >= before {
+			return "", "", errors.Wrapf(ErrConflict, "task %d is not above task %d", *move.AfterID, *move.BeforeID)
+		}
+		return after, before, nil
+	case move.AfterID != nil:
+		err = tx.QueryRow(ctx, nextRankQuery, status, after, id).Scan(&adjacent)
+		before = deref(adjacent)
+	case move.BeforeID != nil:
+		err = tx.QueryRow(ctx, prevRankQuery, status, before, id).Scan(&adjacent)
+		after = deref(adjacent)
+	default:
+		err = tx.QueryRow(ctx, lastRankQuery, status, id).Scan(&adjacent)
+		after = deref(adjacent)
+	}
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to get neighbor rank")
+	}
+
+	return after, before, nil
+}
+
+// neighborRank возвращает ключ соседа id, который должен быть в колонке status
+func neighborRank(ctx context.Context, tx pgx.Tx, id int64, status string) (string, error) {
+	var neighborStatus, key string
+	if err := tx.QueryRow(ctx, neighborTaskQuery, id).Scan(&neighborStatus, &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.Wrapf(ErrConflict, "neighbor task %d not found", id)
+		}
+		return "", errors.Wrap(err, "failed to get neighbor task")
+	}
+
+	if neighborStatus != status {
+		return "", errors.Wrapf(ErrConflict, "neighbor task %d is not in column %s", id, status)
+	}
+
+	return key, nil
+}
+
+// deref - значение строки, nil - пустая строка
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -2,12 +2,15 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"fmt"
 	"restapi/internal/config"
+	"restapi/internal/event"
+	"restapi/internal/logger"
+	"restapi/internal/metrics"
+	"restapi/internal/tracing"
 	"time"
 
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
@@ -17,11 +20,18 @@ import (
 
 // Запросы
 const (
-	insertTaskQuery  = "INSERT INTO tasks (title, description, status) VALUES ($1, $2, $3) RETURNING id"
-	gatTaskQuery     = "SELECT id, title, description, status, created_at, updated_at FROM tasks WHERE id = $1"
-	getAllTasksQuery = "SELECT id, title, description, status, created_at, updated_at FROM tasks ORDER BY created_at LIMIT $1 OFFSET $2"
-	deleteTaskQuery  = "DELETE FROM tasks WHERE id = $1"
-	updateTaskQuery  = "UPDATE tasks SET title = $2, description = $3, status = $4, updated_at = $5 WHERE id = $1"
+	taskColumns = "id, title, description, status, recurrence_id, due_at, rank, created_at, updated_at"
+
+	insertTaskQuery    = "INSERT INTO tasks (title, description, status, due_at, rank) VALUES ($1, $2, $3, $4, $5) RETURNING " + taskColumns
+	gatTaskQuery       = "SELECT " + taskColumns + " FROM tasks WHERE id = $1"
+	lockTaskQuery      = "SELECT " + taskColumns + " FROM tasks WHERE id = $1 FOR UPDATE"
+	getAllTasksQuery   = "SELECT " + taskColumns + " FROM tasks WHERE ($3::text[] IS NULL OR status = ANY($3)) ORDER BY created_at LIMIT $1 OFFSET $2"
+	getBoardTasksQuery = "SELECT " + taskColumns + " FROM tasks WHERE ($3::text[] IS NULL OR status = ANY($3)) ORDER BY status, rank LIMIT $1 OFFSET $2"
+	getTasksByIDsQuery = "SELECT " + taskColumns + " FROM tasks WHERE id = ANY($1)"
+	deleteTaskQuery    = "DELETE FROM tasks WHERE id = $1 RETURNING " + taskColumns
+	// updateTaskQuery - rank $7 задается при переходе в другую колонку, иначе не меняется
+	updateTaskQuery   = "UPDATE tasks SET title = $2, description = $3, status = $4, updated_at = $5, due_at = COALESCE($6, due_at), rank = COALESCE($7, rank) WHERE id = $1 RETURNING " + taskColumns
+	insertOutboxQuery = "INSERT INTO outbox (event, aggregate_id, payload) VALUES ($1, $2, $3)"
 )
 
 // Таймаут
@@ -30,19 +40,22 @@ const (
 )
 
 type DBrepository struct {
+	log  *zap.SugaredLogger
 	pool *pgxpool.Pool
 }
 
 type Repository interface {
-	CreateTask(ctx context.Context, task Task) (int64, error)
+	CreateTask(ctx context.Context, task Task) (*Task, error)
 	GetTask(ctx context.Context, id int64) (*Task, error)
-	GetAllTasks(ctx context.Context, limit int, offset int) ([]*Task, error)
+	GetAllTasks(ctx context.Context, filter TaskFilter, limit int, offset int) ([]*Task, error)
+	GetTasksByIDs(ctx context.Context, ids []int64) ([]*Task, error)
+	GetTaskEvents(ctx context.Context, taskIDs []int64) ([]event.Event, error)
 	DeleteTask(ctx context.Context, id int64) error
-	UpdateTask(ctx context.Context, id int64, task UpdateTask) error
+	UpdateTask(ctx context.Context, id int64, task UpdateTask) (*Task, error)
 }
 
 // NewRepo создает новый репозиторий
-func NewRepo(ctx context.Context, log *zap.SugaredLogger, cfg config.AppConfig) (Repository, error) {
+func NewRepo(ctx context.Context, log *zap.SugaredLogger, cfg config.AppConfig) (*DBrepository, error) {
 	// Составляем строку подключения
 	connString := fmt.Sprintf(
 		`user=%s password=%s host=%s port=%d dbname=%s sslmode=%s 
@@ -68,6 +81,9 @@ func NewRepo(ctx context.Context, log *zap.SugaredLogger, cfg config.AppConfig)
 	// Устанавливаем режим кэширования
 	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheDescribe
 
+	// Спаны OpenTelemetry на каждый запрос
+	config.ConnConfig.Tracer = tracing.NewQueryTracer()
+
 	// Создаем подключение
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -85,27 +101,75 @@ func NewRepo(ctx context.Context, log *zap.SugaredLogger, cfg config.AppConfig)
 	log.Info("Connected to database")
 
 	return &DBrepository{
+		log:  log,
 		pool: pool,
 	}, nil
 }
 
-// CreateTask создает новую задачу
-func (r *DBrepository) CreateTask(ctx context.Context, task Task) (int64, error) {
+// logger возвращает логгер запроса из контекста, в фоновых обработчиках - логгер репозитория
+func (r *DBrepository) logger(ctx context.Context) *zap.SugaredLogger {
+	return logger.FromContext(ctx, r.log)
+}
+
+// Stat возвращает статистику пула соединений
+func (r *DBrepository) Stat() *pgxpool.Stat {
+	return r.pool.Stat()
+}
+
+// Close закрывает пул соединений, дожидаясь возврата занятых соединений
+func (r *DBrepository) Close() {
+	r.pool.Close()
+}
+
+// Ping проверяет соединение с базой данных
+func (r *DBrepository) Ping(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		return errors.Wrap(err, "failed to ping database")
+	}
+
+	return nil
+}
+
+// CreateTask создает новую задачу в конце колонки ее статуса и событие task.created в одной транзакции
+func (r *DBrepository) CreateTask(ctx context.Context, task Task) (*Task, error) {
+	defer metrics.ObserveQuery("CreateTask", time.Now())
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var id int64
-	err := r.pool.QueryRow(ctx, insertTaskQuery, task.Title, task.Description, task.Status).Scan(&id)
+	var (
+		created *Task
+		e       event.Event
+	)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		key, err := appendRank(ctx, tx, task.Status, 0)
+		if err != nil {
+			return err
+		}
+
+		if created, err = scanTask(tx.QueryRow(ctx, insertTaskQuery, task.Title, task.Description, task.Status, task.DueAt, key)); err != nil {
+			return err
+		}
+
+		e = event.New(event.TaskCreated, created.event())
+		return insertOutbox(ctx, tx, e)
+	})
 	if err != nil {
-		log.Error(errors.Wrap(err, "failed to create task"))
-		return -1, errors.Wrap(err, "failed to create task")
+		if !errors.Is(err, ErrWIPLimit) {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to create task"))
+		}
+		return nil, errors.Wrap(err, "failed to create task")
 	}
 
-	return id, nil
+	metrics.ObserveEvents(e)
+
+	return created, nil
 }
 
 // GetTask возвращает задачу по id
 func (r *DBrepository) GetTask(ctx context.Context, id int64) (*Task, error) {
+	defer metrics.ObserveQuery("GetTask", time.Now())
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -115,80 +179,237 @@ func (r *DBrepository) GetTask(ctx context.Context, id int64) (*Task, error) {
 		&task.Title,
 		&task.Description,
 		&task.Status,
+		&task.RecurrenceID,
+		&task.DueAt,
+		&task.Rank,
 		&task.CreatedAt,
 		&task.UpdatedAt,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			log.Error(errors.Wrap(err, "task not found"))
-			return nil, errors.Wrap(err, "task not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "task not found")
 		}
-		log.Error(errors.Wrap(err, "failed to scan task"))
+		r.logger(ctx).Error(errors.Wrap(err, "failed to scan task"))
 		return nil, errors.Wrap(err, "failed to get task")
 	}
 
 	return &task, nil
 }
 
-// GetAllTasks возвращает все задачи
-func (r *DBrepository) GetAllTasks(ctx context.Context, limit int, offset int) ([]*Task, error) {
+// GetAllTasks возвращает все задачи, подходящие под фильтр
+func (r *DBrepository) GetAllTasks(ctx context.Context, filter TaskFilter, limit int, offset int) ([]*Task, error) {
+	defer metrics.ObserveQuery("GetAllTasks", time.Now())
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var tasks []*Task
-	rows, err := r.pool.Query(ctx, getAllTasksQuery, limit, offset)
+	var statuses []string
+	if len(filter.Statuses) > 0 {
+		statuses = filter.Statuses
+	}
+
+	query := getAllTasksQuery
+	if filter.ByRank {
+		query = getBoardTasksQuery
+	}
+
+	rows, err := r.pool.Query(ctx, query, limit, offset, statuses)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Error(errors.Wrap(err, "no tasks found"))
-			return nil, errors.Wrap(err, "no tasks found")
-		}
-		log.Error(errors.Wrap(err, "failed to get all tasks"))
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get all tasks"))
 		return nil, errors.Wrap(err, "failed to get all tasks")
 	}
+	defer rows.Close()
 
+	var tasks []*Task
 	for rows.Next() {
-		var task Task
-		if err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
-			log.Error(errors.Wrap(err, "failed to scan task"))
+		task, err := scanTask(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan task"))
 			return nil, errors.Wrap(err, "failed to scan task")
 		}
-
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to read tasks"))
+		return nil, errors.Wrap(err, "failed to read tasks")
 	}
 
 	return tasks, nil
 }
 
-// DeleteTask удаляет задачу
+// GetTasksByIDs возвращает задачи по списку id, отсутствующие задачи пропускаются
+func (r *DBrepository) GetTasksByIDs(ctx context.Context, ids []int64) ([]*Task, error) {
+	defer metrics.ObserveQuery("GetTasksByIDs", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, getTasksByIDsQuery, ids)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get tasks by ids"))
+		return nil, errors.Wrap(err, "failed to get tasks by ids")
+	}
+	defer rows.Close()
+
+	tasks := make([]*Task, 0, len(ids))
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan task"))
+			return nil, errors.Wrap(err, "failed to scan task")
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// DeleteTask удаляет задачу и создает событие task.deleted в одной транзакции
 func (r *DBrepository) DeleteTask(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("DeleteTask", time.Now())
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	_, err := r.pool.Exec(ctx, deleteTaskQuery, id)
+	var e event.Event
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		deleted, err := scanTask(tx.QueryRow(ctx, deleteTaskQuery, id))
+		if err != nil {
+			return err
+		}
+
+		e = event.New(event.TaskDeleted, deleted.event())
+		return insertOutbox(ctx, tx, e)
+	})
 	if err != nil {
-		log.Error(errors.Wrap(err, "failed to delete task"))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrap(ErrNotFound, "task not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete task"))
 		return errors.Wrap(err, "failed to delete task")
 	}
 
+	metrics.ObserveEvents(e)
+
 	return nil
 }
 
-// UpdateTask обновляет задачу
-func (r *DBrepository) UpdateTask(ctx context.Context, id int64, task UpdateTask) error {
+// UpdateTask обновляет задачу и создает события task.updated и task.status_changed
+// в одной транзакции. Срок без значения не меняется, при смене статуса задача
+// встает в конец новой колонки
+func (r *DBrepository) UpdateTask(ctx context.Context, id int64, task UpdateTask) (*Task, error) {
+	defer metrics.ObserveQuery("UpdateTask", time.Now())
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	_, err := r.pool.Exec(ctx, updateTaskQuery, id, task.Title, task.Description, task.Status, time.Now())
+	var (
+		current *Task
+		events  []event.Event
+	)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		previous, err := scanTask(tx.QueryRow(ctx, lockTaskQuery, id))
+		if err != nil {
+			return err
+		}
+
+		// В другой колонке задача встает в конец
+		var key *string
+		if task.Status != previous.Status {
+			last, err := appendRank(ctx, tx, task.Status, id)
+			if err != nil {
+				return err
+			}
+			key = &last
+		}
+
+		if current, err = scanTask(tx.QueryRow(ctx, updateTaskQuery, id, task.Title, task.Description, task.Status, time.Now(), task.DueAt, key)); err != nil {
+			return err
+		}
+
+		// Напоминания относительно срока переносятся вместе с ним
+		if !sameTime(previous.DueAt, current.DueAt) {
+			if _, err := tx.Exec(ctx, rescheduleRemindersQuery, id, current.DueAt); err != nil {
+				return errors.Wrap(err, "failed to reschedule reminders")
+			}
+		}
+
+		events = event.Changed(previous.event(), current.event())
+		for _, e := range events {
+			if err := insertOutbox(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "task not found")
+		}
+		if !errors.Is(err, ErrWIPLimit) {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to update task"))
+		}
+		return nil, errors.Wrap(err, "failed to update task")
+	}
+
+	metrics.ObserveEvents(events...)
+
+	return current, nil
+}
+
+// inTx выполняет fn в транзакции
+func (r *DBrepository) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// insertOutbox записывает событие в outbox в рамках транзакции
+func insertOutbox(ctx context.Context, tx pgx.Tx, e event.Event) error {
+	payload, err := json.Marshal(e)
 	if err != nil {
-		log.Error(errors.Wrap(err, "failed to update task"))
-		return errors.Wrap(err, "failed to update task")
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	if _, err := tx.Exec(ctx, insertOutboxQuery, e.Type, e.Data.Task.ID, payload); err != nil {
+		return errors.Wrap(err, "failed to insert outbox event")
 	}
 
 	return nil
 }
+
+// sameTime - оба момента не заданы или совпадают
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func scanTask(row pgx.Row) (*Task, error) {
+	var task Task
+	if err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.RecurrenceID,
+		&task.DueAt,
+		&task.Rank,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
@@ -1,17 +1,64 @@
 package db
 
 import (
+	"encoding/json"
+	"restapi/internal/event"
 	"time"
 )
 
 // Task - задача
 type Task struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// RecurrenceID - правило, по которому создана задача
+	RecurrenceID *int64 `json:"recurrence_id,omitempty"`
+	// DueAt - срок задачи
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Rank - ключ порядка задачи в колонке своего статуса
+	Rank      string    `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// event возвращает состояние задачи для события
+func (t *Task) event() *event.Task {
+	return &event.Task{
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Status:       t.Status,
+		RecurrenceID: t.RecurrenceID,
+		DueAt:        t.DueAt,
+		Rank:         t.Rank,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+// TaskFilter - фильтр списка задач, пустые поля не ограничивают выборку
+type TaskFilter struct {
+	Statuses []string
+	// ByRank - порядок колонок доски (статус, ключ порядка) вместо порядка создания
+	ByRank bool
+}
+
+// TaskMove - перемещение задачи на доске. AfterID - задача над ней, BeforeID - под ней;
+// без соседей задача встает в конец колонки
+type TaskMove struct {
+	// Status - колонка, пустой - текущая колонка задачи
+	Status   string
+	AfterID  *int64
+	BeforeID *int64
+}
+
+// BoardColumn - колонка доски
+type BoardColumn struct {
+	Status string `json:"status"`
+	// WIPLimit - наибольшее число задач в колонке, nil - без ограничения
+	WIPLimit *int  `json:"wip_limit"`
+	Tasks    int64 `json:"tasks"`
 }
 
 // UpdateTask - обновленная задача
@@ -19,5 +66,139 @@ type UpdateTask struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Status      string `json:"status"`
-	UpdatedAt   time.Time
+	// DueAt - новый срок, nil - срок не меняется
+	DueAt     *time.Time `json:"due_at"`
+	UpdatedAt time.Time
+}
+
+// Webhook - подписка на события задач
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery - доставка события подписчику
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	ResponseCode  int             `json:"response_code"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// OutboxEntry - событие outbox, ожидающее публикации
+type OutboxEntry struct {
+	Event event.Event
+	// Attempts - число неудачных попыток публикации
+	Attempts int
+}
+
+// Recurrence - правило повторения задачи-шаблона
+type Recurrence struct {
+	ID     int64 `json:"id"`
+	TaskID int64 `json:"task_id"`
+	// Rule - правило в формате RRULE
+	Rule     string    `json:"rule"`
+	StartAt  time.Time `json:"start_at"`
+	Timezone string    `json:"timezone"`
+	// Mode - RecurrenceSchedule или RecurrenceCompletion
+	Mode   string `json:"mode"`
+	Active bool   `json:"active"`
+	// NextAt - срок следующей задачи, nil - повторений больше нет
+	NextAt *time.Time `json:"next_at"`
+	// LastTaskID - последняя созданная по правилу задача
+	LastTaskID *int64    `json:"last_task_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Reminder - напоминание пользователю о задаче
+type Reminder struct {
+	ID     int64  `json:"id"`
+	TaskID int64  `json:"task_id"`
+	UserID string `json:"user_id"`
+	// RemindAt - момент напоминания, если оно не привязано к сроку задачи
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+	// BeforeSeconds - за сколько секунд до срока задачи напомнить
+	BeforeSeconds *int64 `json:"before_seconds,omitempty"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	// NextAttemptAt - когда отправить напоминание, nil - у задачи нет срока
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	// SentChannels - каналы, в которые напоминание уже отправлено
+	SentChannels []string   `json:"sent_channels"`
+	LastError    string     `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	SentAt       *time.Time `json:"sent_at,omitempty"`
+}
+
+// NotificationPreferences - настройки уведомлений пользователя
+type NotificationPreferences struct {
+	UserID string `json:"user_id"`
+	// Channels - каналы уведомлений: email, webhook, log. Пустой - не уведомлять
+	Channels   []string  `json:"channels"`
+	Email      string    `json:"email"`
+	WebhookURL string    `json:"webhook_url"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Worklog - запись учета времени пользователя по задаче
+type Worklog struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	UserID    string    `json:"user_id"`
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt - окончание работы, nil - таймер запущен
+	EndedAt     *time.Time `json:"ended_at"`
+	Description string     `json:"description"`
+	// Seconds - длительность, у запущенного таймера - до текущего момента
+	Seconds   int64     `json:"seconds"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorklogFilter - отбор записей учета времени для отчета. Пустые поля не ограничивают
+type WorklogFilter struct {
+	// From, To - период [From, To), записи обрезаются по его границам
+	From     *time.Time
+	To       *time.Time
+	UserID   string
+	TaskID   int64
+	Statuses []string
+}
+
+// WorklogTotal - строка отчета: суммарное время по значению группировки
+type WorklogTotal struct {
+	// Key - пользователь, id задачи, статус или дата YYYY-MM-DD
+	Key     string `json:"key"`
+	Seconds int64  `json:"seconds"`
+	Entries int64  `json:"entries"`
+}
+
+// User - пользователь API
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKey - ключ API пользователя. Сам ключ не хранится, Prefix - его начало
+type APIKey struct {
+	ID        int64      `json:"id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 }
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Запросы миграций
+const (
+	createMigrationsTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+	getAppliedMigrationsQuery = "SELECT version FROM schema_migrations"
+	insertMigrationQuery      = "INSERT INTO schema_migrations (version) VALUES ($1)"
+)
+
+// migrations возвращает имена файлов миграций в порядке применения
+func migrations() ([]string, error) {
+	names, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list migrations")
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// migrationVersion возвращает версию миграции по имени файла
+func migrationVersion(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+}
+
+// Migrate применяет недостающие миграции
+func (r *DBrepository) Migrate(ctx context.Context) error {
+	if _, err := r.pool.Exec(ctx, createMigrationsTableQuery); err != nil {
+		return errors.Wrap(err, "failed to create schema_migrations table")
+	}
+
+	applied, err := r.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	names, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		version := migrationVersion(name)
+		if applied[version] {
+			continue
+		}
+
+		query, err := migrationsFS.ReadFile(name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read migration %s", version)
+		}
+
+		tx, err := r.pool.Begin(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+
+		if _, err := tx.Exec(ctx, string(query)); err != nil {
+			_ = tx.Rollback(ctx)
+			return errors.Wrapf(err, "failed to apply migration %s", version)
+		}
+
+		if _, err := tx.Exec(ctx, insertMigrationQuery, version); err != nil {
+			_ = tx.Rollback(ctx)
+			return errors.Wrapf(err, "failed to record migration %s", version)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return errors.Wrapf(err, "failed to commit migration %s", version)
+		}
+
+		r.logger(ctx).Infof("Applied migration %s", version)
+	}
+
+	return nil
+}
+
+// CheckMigrations возвращает ошибку, если в базе применены не все миграции
+func (r *DBrepository) CheckMigrations(ctx context.Context) error {
+	applied, err := r.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	names, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, name := range names {
+		if version := migrationVersion(name); !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+
+	if len(pending) > 0 {
+		return errors.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+// appliedMigrations возвращает множество уже примененных миграций
+func (r *DBrepository) appliedMigrations(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.pool.Query(ctx, getAppliedMigrationsQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get applied migrations")
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, errors.Wrap(err, "failed to scan migration version")
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
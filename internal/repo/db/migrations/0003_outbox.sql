@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id           BIGSERIAL PRIMARY KEY,
+    event        TEXT        NOT NULL,
+    aggregate_id BIGINT      NOT NULL,
+    payload      JSONB       NOT NULL,
+    attempts     INT         NOT NULL DEFAULT 0,
+    last_error   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx
+    ON outbox (id)
+    WHERE published_at IS NULL;
+
+-- Повторная публикация события не должна порождать повторную доставку
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS event_id BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx
+    ON webhook_deliveries (webhook_id, event_id);
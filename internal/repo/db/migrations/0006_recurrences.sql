@@ -0,0 +1,25 @@
+-- Правила повторения: задача-шаблон и расписание RRULE
+CREATE TABLE IF NOT EXISTS recurrences (
+    id           BIGSERIAL PRIMARY KEY,
+    task_id      BIGINT      NOT NULL UNIQUE REFERENCES tasks (id) ON DELETE CASCADE,
+    rule         TEXT        NOT NULL,
+    start_at     TIMESTAMPTZ NOT NULL,
+    timezone     TEXT        NOT NULL DEFAULT 'UTC',
+    mode         TEXT        NOT NULL DEFAULT 'schedule',
+    active       BOOLEAN     NOT NULL DEFAULT true,
+    next_at      TIMESTAMPTZ,
+    last_task_id BIGINT      REFERENCES tasks (id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS recurrences_next_idx
+    ON recurrences (next_at)
+    WHERE active AND next_at IS NOT NULL;
+
+-- Задачи, созданные по правилу, и их сроки. Одно повторение не создается дважды
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence_id BIGINT REFERENCES recurrences (id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_recurrence_due_idx
+    ON tasks (recurrence_id, due_at);
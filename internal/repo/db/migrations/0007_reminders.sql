@@ -0,0 +1,34 @@
+-- Настройки уведомлений пользователя. Пользователь - внешний идентификатор,
+-- учетных записей в сервисе нет
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id     TEXT PRIMARY KEY,
+    channels    TEXT[]      NOT NULL,
+    email       TEXT        NOT NULL DEFAULT '',
+    webhook_url TEXT        NOT NULL DEFAULT '',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Напоминания о задачах: в момент remind_at или за before_seconds до срока задачи.
+-- next_attempt_at - когда отправить, NULL - у задачи нет срока
+CREATE TABLE IF NOT EXISTS reminders (
+    id              BIGSERIAL PRIMARY KEY,
+    task_id         BIGINT      NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id         TEXT        NOT NULL,
+    remind_at       TIMESTAMPTZ,
+    before_seconds  BIGINT,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    sent_channels   TEXT[]      NOT NULL DEFAULT '{}',
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMPTZ,
+    CHECK ((remind_at IS NULL) <> (before_seconds IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS reminders_due_idx
+    ON reminders (next_attempt_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS reminders_task_idx
+    ON reminders (task_id);
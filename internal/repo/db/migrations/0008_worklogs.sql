@@ -0,0 +1,22 @@
+-- Учет времени по задачам. ended_at NULL - запущенный таймер
+CREATE TABLE IF NOT EXISTS worklogs (
+    id          BIGSERIAL PRIMARY KEY,
+    task_id     BIGINT      NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id     TEXT        NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    ended_at    TIMESTAMPTZ,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+-- У пользователя не больше одного запущенного таймера
+CREATE UNIQUE INDEX IF NOT EXISTS worklogs_running_idx
+    ON worklogs (user_id)
+    WHERE ended_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS worklogs_user_started_idx
+    ON worklogs (user_id, started_at);
+
+CREATE INDEX IF NOT EXISTS worklogs_task_idx
+    ON worklogs (task_id);
@@ -0,0 +1,31 @@
+-- Порядок задач в колонке доски (колонка - статус). Ключи сравниваются побайтно
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C";
+
+-- Существующие задачи получают ключи по порядку создания: 'd' и четыре цифры base62
+WITH ordered AS (
+    SELECT id, row_number() OVER (PARTITION BY status ORDER BY created_at, id) - 1 AS n
+    FROM tasks
+    WHERE rank IS NULL
+), alphabet AS (
+    SELECT '0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz'::text AS d
+)
+UPDATE tasks t
+SET rank = 'd'
+    || substr(a.d, (o.n / 238328 % 62)::int + 1, 1)
+    || substr(a.d, (o.n / 3844 % 62)::int + 1, 1)
+    || substr(a.d, (o.n / 62 % 62)::int + 1, 1)
+    || substr(a.d, (o.n % 62)::int + 1, 1)
+FROM ordered o, alphabet a
+WHERE o.id = t.id;
+
+ALTER TABLE tasks ALTER COLUMN rank SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_status_rank_idx
+    ON tasks (status, rank);
+
+-- Ограничения числа задач в колонке (WIP), проверяются при перемещении задач
+CREATE TABLE IF NOT EXISTS board_columns (
+    status     TEXT PRIMARY KEY,
+    wip_limit  INT         NOT NULL CHECK (wip_limit > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
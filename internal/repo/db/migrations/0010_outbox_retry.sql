@@ -0,0 +1,11 @@
+-- Повторы публикации по расписанию: неудачное событие не задерживает остальные
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Событие, которое не удалось опубликовать за OUTBOX_MAX_ATTEMPTS попыток
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS outbox_unpublished_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx
+    ON outbox (next_attempt_at)
+    WHERE published_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,7 @@
+-- Транзакция, записавшая событие. Лента событий читается по (tx_id, id) и только
+-- из транзакций старше самой старой незавершенной: событие, чья транзакция
+-- зафиксирована позже, не окажется перед уже прочитанными
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS tx_id xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS outbox_position_idx
+    ON outbox (tx_id, id);
@@ -0,0 +1,3 @@
+-- last_task_id сохраняется и после удаления задачи: так правило в режиме completion
+-- отличает удаленную задачу от первой, которая еще не создана
+ALTER TABLE recurrences DROP CONSTRAINT IF EXISTS recurrences_last_task_id_fkey;
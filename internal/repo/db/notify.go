@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"restapi/internal/event"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// EventsChannel - канал LISTEN/NOTIFY, в который пишется id нового события outbox
+const EventsChannel = "task_events"
+
+// Запросы ленты событий. Читаются только события транзакций старше самой старой
+// незавершенной (pg_snapshot_xmin): более поздние могут еще зафиксироваться
+// с меньшей позицией. Позиция - (tx_id, id)
+const (
+	eventColumns = "id, payload, tx_id::text::bigint"
+	// visibleEvents - условие стабильной части ленты после позиции ($1, $2)
+	visibleEvents = `(tx_id, id) > ($1::bigint::text::xid8, $2::bigint)
+		AND tx_id < pg_snapshot_xmin(pg_current_snapshot())`
+
+	getEventsQuery = "SELECT " + eventColumns + " FROM outbox WHERE " + visibleEvents +
+		" ORDER BY tx_id, id LIMIT $3"
+	// lastEventsQuery - последние $3 событий после позиции в порядке ленты
+	lastEventsQuery = "SELECT * FROM (SELECT " + eventColumns + " FROM outbox WHERE " + visibleEvents +
+		" ORDER BY tx_id DESC, id DESC LIMIT $3) last ORDER BY 3, 1"
+	lastPositionQuery = `SELECT tx_id::text::bigint, id FROM outbox
+		WHERE tx_id < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY tx_id DESC, id DESC LIMIT 1`
+	eventPositionQuery = "SELECT tx_id::text::bigint, id FROM outbox WHERE id = $1"
+	taskEventsQuery    = "SELECT " + eventColumns + " FROM outbox WHERE aggregate_id = ANY($1) ORDER BY tx_id, id"
+)
+
+// EventRepository - лента событий задач
+type EventRepository interface {
+	GetEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error)
+	GetLastEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error)
+	LastPosition(ctx context.Context) (event.Position, error)
+	EventPosition(ctx context.Context, id int64) (event.Position, error)
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+}
+
+// GetEvents возвращает до limit событий после позиции after в порядке ленты
+func (r *DBrepository) GetEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error) {
+	defer metrics.ObserveQuery("GetEvents", time.Now())
+
+	return r.queryEvents(ctx, "failed to get events", getEventsQuery, after.TxID, after.ID, limit)
+}
+
+// GetLastEvents возвращает последние limit событий после позиции after в порядке ленты
+func (r *DBrepository) GetLastEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error) {
+	defer metrics.ObserveQuery("GetLastEvents", time.Now())
+
+	return r.queryEvents(ctx, "failed to get last events", lastEventsQuery, after.TxID, after.ID, limit)
+}
+
+// GetTaskEvents возвращает историю событий задач из outbox в порядке ленты.
+// История хранится не дольше OUTBOX_RETENTION
+func (r *DBrepository) GetTaskEvents(ctx context.Context, taskIDs []int64) ([]event.Event, error) {
+	defer metrics.ObserveQuery("GetTaskEvents", time.Now())
+
+	return r.queryEvents(ctx, "failed to get task events", taskEventsQuery, taskIDs)
+}
+
+// LastPosition возвращает позицию последнего события стабильной части ленты,
+// нулевую - если событий нет
+func (r *DBrepository) LastPosition(ctx context.Context) (event.Position, error) {
+	defer metrics.ObserveQuery("LastPosition", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var position event.Position
+	err := r.pool.QueryRow(ctx, lastPositionQuery).Scan(&position.TxID, &position.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get last event position"))
+		return event.Position{}, errors.Wrap(err, "failed to get last event position")
+	}
+
+	return position, nil
+}
+
+// EventPosition возвращает позицию события id. Удаленное по сроку хранения
+// или неизвестное событие - ErrNotFound
+func (r *DBrepository) EventPosition(ctx context.Context, id int64) (event.Position, error) {
+	defer metrics.ObserveQuery("EventPosition", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var position event.Position
+	if err := r.pool.QueryRow(ctx, eventPositionQuery, id).Scan(&position.TxID, &position.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return event.Position{}, errors.Wrapf(ErrNotFound, "event %d not found", id)
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get event position"))
+		return event.Position{}, errors.Wrap(err, "failed to get event position")
+	}
+
+	return position, nil
+}
+
+// Listen подписывается на канал LISTEN/NOTIFY и вызывает handle для каждого
+// уведомления. Занимает отдельное соединение пула до отмены контекста или ошибки
+func (r *DBrepository) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return errors.Wrapf(err, "failed to listen %s", channel)
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// Соединение с активным LISTEN не возвращаем в пул
+			_ = conn.Conn().Close(context.Background())
+			return errors.Wrap(err, "failed to wait for notification")
+		}
+
+		handle(notification.Payload)
+	}
+}
+
+// queryEvents выполняет запрос событий с колонками eventColumns
+func (r *DBrepository) queryEvents(ctx context.Context, msg, query string, args ...any) ([]event.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, msg))
+		return nil, errors.Wrap(err, msg)
+	}
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, msg))
+		return nil, errors.Wrap(err, msg)
+	}
+
+	return events, nil
+}
+
+func scanEvents(rows pgx.Rows) ([]event.Event, error) {
+	defer rows.Close()
+
+	events := make([]event.Event, 0)
+	for rows.Next() {
+		var (
+			position event.Position
+			payload  []byte
+			e        event.Event
+		)
+		if err := rows.Scan(&position.ID, &payload, &position.TxID); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal event %d", position.ID)
+		}
+		e.ID = position.ID
+		e.Position = position
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
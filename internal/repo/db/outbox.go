@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"restapi/internal/metrics"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// Запросы outbox
+const (
+	// claimOutboxQuery забирает готовые события и откладывает их до $2, пока идет публикация
+	claimOutboxQuery = `WITH due AS (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox o SET next_attempt_at = $2
+		FROM due WHERE o.id = due.id
+		RETURNING o.id, o.payload, o.attempts`
+	publishOutboxQuery = "UPDATE outbox SET published_at = now(), attempts = attempts + 1, last_error = '' WHERE id = $1"
+	retryOutboxQuery   = "UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1"
+	failOutboxQuery    = "UPDATE outbox SET attempts = attempts + 1, last_error = $2, failed_at = now() WHERE id = $1"
+	cleanOutboxQuery   = "DELETE FROM outbox WHERE published_at < $1 OR failed_at < $1"
+)
+
+// OutboxRepository - хранилище неопубликованных событий
+type OutboxRepository interface {
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxEntry, error)
+	PublishOutbox(ctx context.Context, id int64) error
+	RetryOutbox(ctx context.Context, id int64, lastError string, next time.Time) error
+	FailOutbox(ctx context.Context, id int64, lastError string) error
+	CleanOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ClaimOutbox забирает до limit готовых к публикации событий и откладывает их на время
+// lease, чтобы другие экземпляры сервиса их не взяли. Блокировки строк снимаются сразу,
+// публикация идет вне транзакции. Порядок публикации событий не гарантируется
+func (r *DBrepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxEntry, error) {
+	defer metrics.ObserveQuery("ClaimOutbox", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, claimOutboxQuery, limit, time.Now().Add(lease))
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to claim outbox events"))
+		return nil, errors.Wrap(err, "failed to claim outbox events")
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (OutboxEntry, error) {
+		var (
+			entry   OutboxEntry
+			payload []byte
+		)
+		if err := row.Scan(&entry.Event.ID, &payload, &entry.Attempts); err != nil {
+			return entry, err
+		}
+		id := entry.Event.ID
+		if err := json.Unmarshal(payload, &entry.Event); err != nil {
+			return entry, errors.Wrapf(err, "failed to unmarshal event %d", id)
+		}
+		entry.Event.ID = id
+		return entry, nil
+	})
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to scan outbox events"))
+		return nil, errors.Wrap(err, "failed to scan outbox events")
+	}
+
+	slices.SortFunc(entries, func(a, b OutboxEntry) int {
+		return int(a.Event.ID - b.Event.ID)
+	})
+
+	return entries, nil
+}
+
+// PublishOutbox отмечает событие опубликованным
+func (r *DBrepository) PublishOutbox(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("PublishOutbox", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark outbox event published", publishOutboxQuery, id)
+}
+
+// RetryOutbox откладывает публикацию события до next
+func (r *DBrepository) RetryOutbox(ctx context.Context, id int64, lastError string, next time.Time) error {
+	defer metrics.ObserveQuery("RetryOutbox", time.Now())
+
+	return r.execDelivery(ctx, "failed to schedule outbox retry", retryOutboxQuery, id, lastError, next)
+}
+
+// FailOutbox больше не публикует событие: оно остается в outbox с failed_at и last_error
+func (r *DBrepository) FailOutbox(ctx context.Context, id int64, lastError string) error {
+	defer metrics.ObserveQuery("FailOutbox", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark outbox event failed", failOutboxQuery, id, lastError)
+}
+
+// CleanOutbox удаляет события, опубликованные или отброшенные раньше before
+func (r *DBrepository) CleanOutbox(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("CleanOutbox", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, cleanOutboxQuery, before)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to clean outbox"))
+		return 0, errors.Wrap(err, "failed to clean outbox")
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Запросы ограничения запросов. Корзина пополняется на rate токенов в секунду
+// до burst, запрос разрешается, если в корзине есть целый токен
+const (
+	takeTokenQuery = `INSERT INTO rate_limits AS r (key, tokens, allowed, updated_at)
+		VALUES ($1, $3::float8 - 1, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2) >= 1,
+			tokens = LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2)
+				- CASE WHEN LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2) >= 1
+					THEN 1 ELSE 0 END,
+			updated_at = now()
+		RETURNING tokens, allowed`
+	cleanRateLimitsQuery = "DELETE FROM rate_limits WHERE updated_at < $1"
+)
+
+// RateLimitRepository - общее хранилище корзин токенов
+type RateLimitRepository interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error)
+	CleanRateLimits(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TakeToken пополняет корзину key и забирает из нее токен одним запросом.
+// Возвращает остаток токенов и разрешен ли запрос
+func (r *DBrepository) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	defer metrics.ObserveQuery("TakeToken", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := r.pool.QueryRow(ctx, takeTokenQuery, key, rate, burst).Scan(&tokens, &allowed); err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to take rate limit token"))
+		return 0, false, errors.Wrap(err, "failed to take rate limit token")
+	}
+
+	return tokens, allowed, nil
+}
+
+// CleanRateLimits удаляет корзины, не использовавшиеся с before
+func (r *DBrepository) CleanRateLimits(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("CleanRateLimits", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, cleanRateLimitsQuery, before)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to clean rate limits"))
+		return 0, errors.Wrap(err, "failed to clean rate limits")
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/event"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// Режимы правил повторения
+const (
+	// RecurrenceSchedule - задача создается, когда наступает срок повторения
+	RecurrenceSchedule = "schedule"
+	// RecurrenceCompletion - следующая задача создается, когда предыдущая выполнена
+	RecurrenceCompletion = "completion"
+)
+
+// recurrenceLock - пространство ключей advisory-блокировок правил повторения
+const recurrenceLock int32 = 0x72656375
+
+// Запросы правил повторения
+const (
+	recurrenceColumns = "r.id, r.task_id, r.rule, r.start_at, r.timezone, r.mode, r.active, r.next_at, r.last_task_id, r.created_at, r.updated_at"
+
+	setRecurrenceQuery = `INSERT INTO recurrences AS r (task_id, rule, start_at, timezone, mode, active, next_at)
+		SELECT id, $2, $3, $4, $5, $6, $7 FROM tasks WHERE id = $1
+		ON CONFLICT (task_id) DO UPDATE SET rule = EXCLUDED.rule, start_at = EXCLUDED.start_at,
+			timezone = EXCLUDED.timezone, mode = EXCLUDED.mode, active = EXCLUDED.active,
+			next_at = EXCLUDED.next_at, updated_at = now()
+		RETURNING ` + recurrenceColumns
+	getRecurrenceQuery    = "SELECT " + recurrenceColumns + " FROM recurrences r WHERE r.task_id = $1"
+	deleteRecurrenceQuery = "DELETE FROM recurrences WHERE task_id = $1"
+
+	// dueRecurrence - по правилу пора создать задачу: наступил срок или выполнена
+	// (удалена) предыдущая задача. Первая задача в режиме completion создается
+	// в срок первого повторения. $1 - текущее время
+	dueRecurrence = `r.active AND r.next_at IS NOT NULL AND (
+			(r.mode = 'schedule' AND r.next_at <= $1)
+			OR (r.mode = 'completion' AND r.last_task_id IS NULL AND r.next_at <= $1)
+			OR (r.mode = 'completion' AND r.last_task_id IS NOT NULL AND (t.id IS NULL OR t.status = 'done'))
+		)`
+	dueRecurrencesQuery = `SELECT r.id FROM recurrences r
+		LEFT JOIN tasks t ON t.id = r.last_task_id
+		WHERE ` + dueRecurrence + `
+		ORDER BY r.next_at
+		LIMIT $2`
+	lockRecurrenceQuery    = "SELECT pg_try_advisory_xact_lock($1, ($2::bigint % 2147483647)::int)"
+	lockDueRecurrenceQuery = `SELECT ` + recurrenceColumns + ` FROM recurrences r
+		LEFT JOIN tasks t ON t.id = r.last_task_id
+		WHERE r.id = $2 AND ` + dueRecurrence + `
+		FOR UPDATE OF r`
+	insertOccurrenceQuery = `INSERT INTO tasks (title, description, status, recurrence_id, due_at, rank)
+		SELECT title, description, 'new', $1, $2, $4 FROM tasks WHERE id = $3
+		ON CONFLICT (recurrence_id, due_at) DO NOTHING
+		RETURNING ` + taskColumns
+	getOccurrenceQuery     = "SELECT id FROM tasks WHERE recurrence_id = $1 AND due_at = $2"
+	advanceRecurrenceQuery = "UPDATE recurrences SET next_at = $2, last_task_id = COALESCE($3, last_task_id), updated_at = now() WHERE id = $1"
+)
+
+// RecurrencePlan - что создать по правилу: задачу со сроком Due и следующий срок Next.
+// Due nil - повторений больше нет, Next nil - эта задача последняя
+type RecurrencePlan struct {
+	Due  *time.Time
+	Next *time.Time
+}
+
+// RecurrenceRepository - хранилище правил повторения
+type RecurrenceRepository interface {
+	SetRecurrence(ctx context.Context, rec Recurrence) (*Recurrence, error)
+	GetRecurrence(ctx context.Context, taskID int64) (*Recurrence, error)
+	DeleteRecurrence(ctx context.Context, taskID int64) error
+	DueRecurrences(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	MaterializeRecurrence(ctx context.Context, id int64, now time.Time, plan func(rec *Recurrence) (RecurrencePlan, error)) (*Task, error)
+}
+
+// SetRecurrence создает или заменяет правило повторения задачи rec.TaskID
+func (r *DBrepository) SetRecurrence(ctx context.Context, rec Recurrence) (*Recurrence, error) {
+	defer metrics.ObserveQuery("SetRecurrence", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	saved, err := scanRecurrence(r.pool.QueryRow(ctx, setRecurrenceQuery,
+		rec.TaskID, rec.Rule, rec.StartAt, rec.Timezone, rec.Mode, rec.Active, rec.NextAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "task not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to set recurrence"))
+		return nil, errors.Wrap(err, "failed to set recurrence")
+	}
+
+	return saved, nil
+}
+
+// GetRecurrence возвращает правило повторения задачи
+func (r *DBrepository) GetRecurrence(ctx context.Context, taskID int64) (*Recurrence, error) {
+	defer metrics.ObserveQuery("GetRecurrence", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rec, err := scanRecurrence(r.pool.QueryRow(ctx, getRecurrenceQuery, taskID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "recurrence not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get recurrence"))
+		return nil, errors.Wrap(err, "failed to get recurrence")
+	}
+
+	return rec, nil
+}
+
+// DeleteRecurrence удаляет правило повторения задачи, созданные задачи остаются
+func (r *DBrepository) DeleteRecurrence(ctx context.Context, taskID int64) error {
+	defer metrics.ObserveQuery("DeleteRecurrence", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, deleteRecurrenceQuery, taskID)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete recurrence"))
+		return errors.Wrap(err, "failed to delete recurrence")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "recurrence not found")
+	}
+
+	return nil
+}
+
+// DueRecurrences возвращает id правил, по которым пора создать задачу
+func (r *DBrepository) DueRecurrences(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	defer metrics.ObserveQuery("DueRecurrences", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, dueRecurrencesQuery, now, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get due recurrences")
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan due recurrences")
+	}
+
+	return ids, nil
+}
+
+// MaterializeRecurrence создает по правилу id задачу, которую рассчитывает plan, и событие
+// task.created в одной транзакции. Правило обрабатывает один экземпляр сервиса: остальные
+// не получают advisory-блокировку и пропускают его. Повторная попытка создать задачу
+// с тем же сроком игнорируется уникальным индексом. Возвращает nil, если правило уже
+// обработано, исчерпано или задача с этим сроком уже есть
+func (r *DBrepository) MaterializeRecurrence(ctx context.Context, id int64, now time.Time, plan func(rec *Recurrence) (RecurrencePlan, error)) (*Task, error) {
+	defer metrics.ObserveQuery("MaterializeRecurrence", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		created *Task
+		e       event.Event
+	)
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, lockRecurrenceQuery, recurrenceLock, id).Scan(&locked); err != nil {
+			return errors.Wrap(err, "failed to lock recurrence")
+		}
+		if !locked {
+			return nil
+		}
+
+		rec, err := scanRecurrence(tx.QueryRow(ctx, lockDueRecurrenceQuery, now, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to get recurrence")
+		}
+
+		next, err := plan(rec)
+		if err != nil {
+			return err
+		}
+		if next.Due == nil {
+			_, err := tx.Exec(ctx, advanceRecurrenceQuery, rec.ID, nil, nil)
+			return errors.Wrap(err, "failed to finish recurrence")
+		}
+
+		key, err := appendRank(ctx, tx, "new", 0)
+		if err != nil {
+			return err
+		}
+
+		var taskID int64
+		created, err = scanTask(tx.QueryRow(ctx, insertOccurrenceQuery, rec.ID, *next.Due, rec.TaskID, key))
+		switch {
+		case err == nil:
+			taskID = created.ID
+			e = event.New(event.TaskCreated, created.event())
+			if err := insertOutbox(ctx, tx, e); err != nil {
+				return err
+			}
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := tx.QueryRow(ctx, getOccurrenceQuery, rec.ID, *next.Due).Scan(&taskID); err != nil {
+				return errors.Wrap(err, "failed to get existing occurrence")
+			}
+		default:
+			return errors.Wrap(err, "failed to create occurrence")
+		}
+
+		_, err = tx.Exec(ctx, advanceRecurrenceQuery, rec.ID, next.Next, taskID)
+		return errors.Wrap(err, "failed to advance recurrence")
+	})
+	if err != nil {
+		if !errors.Is(err, ErrWIPLimit) {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to materialize recurrence"))
+		}
+		return nil, errors.Wrap(err, "failed to materialize recurrence")
+	}
+
+	if created != nil {
+		metrics.ObserveEvents(e)
+	}
+
+	return created, nil
+}
+
+func scanRecurrence(row pgx.Row) (*Recurrence, error) {
+	var rec Recurrence
+	if err := row.Scan(
+		&rec.ID,
+		&rec.TaskID,
+		&rec.Rule,
+		&rec.StartAt,
+		&rec.Timezone,
+		&rec.Mode,
+		&rec.Active,
+		&rec.NextAt,
+		&rec.LastTaskID,
+		&rec.CreatedAt,
+		&rec.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
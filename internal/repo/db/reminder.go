@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// Статусы напоминания
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+	// ReminderSkipped - напоминание не отправлено: задача выполнена или у пользователя нет каналов
+	ReminderSkipped = "skipped"
+)
+
+// Запросы напоминаний и настроек уведомлений
+const (
+	reminderColumns = "id, task_id, user_id, remind_at, before_seconds, status, attempts, next_attempt_at, sent_channels, last_error, created_at, sent_at"
+
+	insertReminderQuery = `INSERT INTO reminders (task_id, user_id, remind_at, before_seconds, next_attempt_at)
+		SELECT id, $2, $3::timestamptz, $4::bigint, COALESCE($3::timestamptz, due_at - $4::bigint * interval '1 second')
+		FROM tasks WHERE id = $1
+		RETURNING ` + reminderColumns
+	getRemindersQuery   = "SELECT " + reminderColumns + " FROM reminders WHERE task_id = $1 ORDER BY id"
+	deleteReminderQuery = "DELETE FROM reminders WHERE task_id = $1 AND id = $2"
+	claimRemindersQuery = `WITH due AS (
+			SELECT id FROM reminders
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE reminders r SET next_attempt_at = $2
+		FROM due WHERE r.id = due.id
+		RETURNING r.id, r.task_id, r.user_id, r.remind_at, r.before_seconds, r.status, r.attempts, r.next_attempt_at,
+			r.sent_channels, r.last_error, r.created_at, r.sent_at`
+	succeedReminderQuery = `UPDATE reminders
+		SET status = 'sent', attempts = attempts + 1, sent_channels = $2, last_error = '', sent_at = now()
+		WHERE id = $1`
+	retryReminderQuery = `UPDATE reminders
+		SET attempts = attempts + 1, sent_channels = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $1`
+	failReminderQuery = `UPDATE reminders
+		SET status = 'failed', attempts = attempts + 1, sent_channels = $2, last_error = $3
+		WHERE id = $1`
+	skipReminderQuery = "UPDATE reminders SET status = 'skipped', last_error = $2 WHERE id = $1"
+	// rescheduleRemindersQuery переносит неотправленные напоминания относительно срока задачи
+	rescheduleRemindersQuery = `UPDATE reminders
+		SET next_attempt_at = $2::timestamptz - before_seconds * interval '1 second'
+		WHERE task_id = $1 AND before_seconds IS NOT NULL AND status = 'pending'`
+
+	getPreferencesQuery = "SELECT user_id, channels, email, webhook_url, updated_at FROM notification_preferences WHERE user_id = $1"
+	setPreferencesQuery = `INSERT INTO notification_preferences (user_id, channels, email, webhook_url) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET channels = EXCLUDED.channels, email = EXCLUDED.email,
+			webhook_url = EXCLUDED.webhook_url, updated_at = now()
+		RETURNING user_id, channels, email, webhook_url, updated_at`
+)
+
+// ReminderRepository - хранилище напоминаний, очереди их отправки и настроек уведомлений
+type ReminderRepository interface {
+	CreateReminder(ctx context.Context, reminder Reminder) (*Reminder, error)
+	GetReminders(ctx context.Context, taskID int64) ([]*Reminder, error)
+	DeleteReminder(ctx context.Context, taskID, id int64) error
+	ClaimReminders(ctx context.Context, limit int, lease time.Duration) ([]*Reminder, error)
+	SucceedReminder(ctx context.Context, id int64, channels []string) error
+	RetryReminder(ctx context.Context, id int64, channels []string, lastError string, next time.Time) error
+	FailReminder(ctx context.Context, id int64, channels []string, lastError string) error
+	SkipReminder(ctx context.Context, id int64, reason string) error
+	GetTask(ctx context.Context, id int64) (*Task, error)
+	GetPreferences(ctx context.Context, userID string) (*NotificationPreferences, error)
+	SetPreferences(ctx context.Context, prefs NotificationPreferences) (*NotificationPreferences, error)
+}
+
+// CreateReminder создает напоминание о задаче reminder.TaskID. Напоминание относительно
+// срока отправляется, когда у задачи есть срок
+func (r *DBrepository) CreateReminder(ctx context.Context, reminder Reminder) (*Reminder, error) {
+	defer metrics.ObserveQuery("CreateReminder", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	created, err := scanReminder(r.pool.QueryRow(ctx, insertReminderQuery,
+		reminder.TaskID, reminder.UserID, reminder.RemindAt, reminder.BeforeSeconds))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "task not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to create reminder"))
+		return nil, errors.Wrap(err, "failed to create reminder")
+	}
+
+	return created, nil
+}
+
+// GetReminders возвращает напоминания о задаче
+func (r *DBrepository) GetReminders(ctx context.Context, taskID int64) ([]*Reminder, error) {
+	defer metrics.ObserveQuery("GetReminders", time.Now())
+
+	return r.queryReminders(ctx, getRemindersQuery, taskID)
+}
+
+// DeleteReminder удаляет напоминание о задаче
+func (r *DBrepository) DeleteReminder(ctx context.Context, taskID, id int64) error {
+	defer metrics.ObserveQuery("DeleteReminder", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, deleteReminderQuery, taskID, id)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete reminder"))
+		return errors.Wrap(err, "failed to delete reminder")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "reminder not found")
+	}
+
+	return nil
+}
+
+// ClaimReminders забирает порцию напоминаний, которые пора отправить, и откладывает их
+// на lease, чтобы другие экземпляры сервиса не отправили их одновременно
+func (r *DBrepository) ClaimReminders(ctx context.Context, limit int, lease time.Duration) ([]*Reminder, error) {
+	defer metrics.ObserveQuery("ClaimReminders", time.Now())
+
+	return r.queryReminders(ctx, claimRemindersQuery, limit, time.Now().Add(lease))
+}
+
+// SucceedReminder отмечает напоминание отправленным во все каналы channels
+func (r *DBrepository) SucceedReminder(ctx context.Context, id int64, channels []string) error {
+	defer metrics.ObserveQuery("SucceedReminder", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark reminder sent", succeedReminderQuery, id, channels)
+}
+
+// RetryReminder откладывает напоминание до следующей попытки. channels - каналы,
+// в которые оно уже отправлено и не отправляется повторно
+func (r *DBrepository) RetryReminder(ctx context.Context, id int64, channels []string, lastError string, next time.Time) error {
+	defer metrics.ObserveQuery("RetryReminder", time.Now())
+
+	return r.execDelivery(ctx, "failed to schedule reminder retry", retryReminderQuery, id, channels, lastError, next)
+}
+
+// FailReminder отмечает напоминание окончательно неотправленным
+func (r *DBrepository) FailReminder(ctx context.Context, id int64, channels []string, lastError string) error {
+	defer metrics.ObserveQuery("FailReminder", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark reminder failed", failReminderQuery, id, channels, lastError)
+}
+
+// SkipReminder отмечает, что напоминание не нужно отправлять
+func (r *DBrepository) SkipReminder(ctx context.Context, id int64, reason string) error {
+	defer metrics.ObserveQuery("SkipReminder", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark reminder skipped", skipReminderQuery, id, reason)
+}
+
+// GetPreferences возвращает настройки уведомлений пользователя
+func (r *DBrepository) GetPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+	defer metrics.ObserveQuery("GetPreferences", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	prefs, err := scanPreferences(r.pool.QueryRow(ctx, getPreferencesQuery, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "notification preferences not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get notification preferences"))
+		return nil, errors.Wrap(err, "failed to get notification preferences")
+	}
+
+	return prefs, nil
+}
+
+// SetPreferences создает или заменяет настройки уведомлений пользователя
+func (r *DBrepository) SetPreferences(ctx context.Context, prefs NotificationPreferences) (*NotificationPreferences, error) {
+	defer metrics.ObserveQuery("SetPreferences", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	saved, err := scanPreferences(r.pool.QueryRow(ctx, setPreferencesQuery, prefs.UserID, prefs.Channels, prefs.Email, prefs.WebhookURL))
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to set notification preferences"))
+		return nil, errors.Wrap(err, "failed to set notification preferences")
+	}
+
+	return saved, nil
+}
+
+func (r *DBrepository) queryReminders(ctx context.Context, query string, args ...any) ([]*Reminder, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get reminders"))
+		return nil, errors.Wrap(err, "failed to get reminders")
+	}
+	defer rows.Close()
+
+	reminders := make([]*Reminder, 0)
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan reminder"))
+			return nil, errors.Wrap(err, "failed to scan reminder")
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+func scanReminder(row pgx.Row) (*Reminder, error) {
+	var reminder Reminder
+	if err := row.Scan(
+		&reminder.ID,
+		&reminder.TaskID,
+		&reminder.UserID,
+		&reminder.RemindAt,
+		&reminder.BeforeSeconds,
+		&reminder.Status,
+		&reminder.Attempts,
+		&reminder.NextAttemptAt,
+		&reminder.SentChannels,
+		&reminder.LastError,
+		&reminder.CreatedAt,
+		&reminder.SentAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+func scanPreferences(row pgx.Row) (*NotificationPreferences, error) {
+	var prefs NotificationPreferences
+	if err := row.Scan(
+		&prefs.UserID,
+		&prefs.Channels,
+		&prefs.Email,
+		&prefs.WebhookURL,
+		&prefs.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// Запросы пользователей и ключей API
+const (
+	apiKeyColumns = "id, user_id, name, prefix, created_at, revoked_at"
+
+	insertUserQuery = "INSERT INTO users (id, name, email) VALUES ($1, $2, $3) RETURNING id, name, email, created_at"
+	getUsersQuery   = "SELECT id, name, email, created_at FROM users ORDER BY id"
+	deleteUserQuery = "DELETE FROM users WHERE id = $1"
+
+	insertAPIKeyQuery = `INSERT INTO api_keys (user_id, name, prefix, key_hash)
+		SELECT id, $2, $3, $4 FROM users WHERE id = $1
+		RETURNING ` + apiKeyColumns
+	// getAPIKeysQuery - ключи пользователя $1, пустой $1 - всех пользователей
+	getAPIKeysQuery   = "SELECT " + apiKeyColumns + " FROM api_keys WHERE $1 = '' OR user_id = $1 ORDER BY id"
+	revokeAPIKeyQuery = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1
+		RETURNING ` + apiKeyColumns
+	apiKeyUserQuery = "SELECT user_id FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL"
+)
+
+// UserRepository - хранилище пользователей и их ключей API
+type UserRepository interface {
+	CreateUser(ctx context.Context, user User) (*User, error)
+	GetUsers(ctx context.Context) ([]*User, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	CreateAPIKey(ctx context.Context, key APIKey, hash string) (*APIKey, error)
+	GetAPIKeys(ctx context.Context, userID string) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*APIKey, error)
+	APIKeyRepository
+}
+
+// APIKeyRepository - проверка ключей API при авторизации
+type APIKeyRepository interface {
+	// GetAPIKeyUser возвращает пользователя действующего ключа с хешем hash
+	GetAPIKeyUser(ctx context.Context, hash string) (string, error)
+}
+
+// CreateUser создает пользователя, занятый id - ErrConflict
+func (r *DBrepository) CreateUser(ctx context.Context, user User) (*User, error) {
+	defer metrics.ObserveQuery("CreateUser", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var created User
+	err := r.pool.QueryRow(ctx, insertUserQuery, user.ID, user.Name, user.Email).
+		Scan(&created.ID, &created.Name, &created.Email, &created.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, errors.Wrapf(ErrConflict, "user %s already exists", user.ID)
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to create user"))
+		return nil, errors.Wrap(err, "failed to create user")
+	}
+
+	return &created, nil
+}
+
+// GetUsers возвращает всех пользователей
+func (r *DBrepository) GetUsers(ctx context.Context) ([]*User, error) {
+	defer metrics.ObserveQuery("GetUsers", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, getUsersQuery)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get users"))
+		return nil, errors.Wrap(err, "failed to get users")
+	}
+	defer rows.Close()
+
+	users := make([]*User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt); err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan user"))
+			return nil, errors.Wrap(err, "failed to scan user")
+		}
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+// DeleteUser удаляет пользователя вместе с его ключами API. Учет времени
+// и настройки уведомлений пользователя сохраняются
+func (r *DBrepository) DeleteUser(ctx context.Context, id string) error {
+	defer metrics.ObserveQuery("DeleteUser", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, deleteUserQuery, id)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete user"))
+		return errors.Wrap(err, "failed to delete user")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "user not found")
+	}
+
+	return nil
+}
+
+// CreateAPIKey сохраняет ключ пользователя key.UserID с хешем hash
+func (r *DBrepository) CreateAPIKey(ctx context.Context, key APIKey, hash string) (*APIKey, error) {
+	defer metrics.ObserveQuery("CreateAPIKey", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	created, err := scanAPIKey(r.pool.QueryRow(ctx, insertAPIKeyQuery, key.UserID, key.Name, key.Prefix, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "user not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to create API key"))
+		return nil, errors.Wrap(err, "failed to create API key")
+	}
+
+	return created, nil
+}
+
+// GetAPIKeys возвращает ключи пользователя, для пустого userID - ключи всех пользователей
+func (r *DBrepository) GetAPIKeys(ctx context.Context, userID string) ([]*APIKey, error) {
+	defer metrics.ObserveQuery("GetAPIKeys", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, getAPIKeysQuery, userID)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get API keys"))
+		return nil, errors.Wrap(err, "failed to get API keys")
+	}
+	defer rows.Close()
+
+	keys := make([]*APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan API key"))
+			return nil, errors.Wrap(err, "failed to scan API key")
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время отзыва
+func (r *DBrepository) RevokeAPIKey(ctx context.Context, id int64) (*APIKey, error) {
+	defer metrics.ObserveQuery("RevokeAPIKey", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, revokeAPIKeyQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "API key not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to revoke API key"))
+		return nil, errors.Wrap(err, "failed to revoke API key")
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyUser возвращает пользователя действующего ключа с хешем hash,
+// неизвестный или отозванный ключ - ErrNotFound
+func (r *DBrepository) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	defer metrics.ObserveQuery("GetAPIKeyUser", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var userID string
+	if err := r.pool.QueryRow(ctx, apiKeyUserQuery, hash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.Wrap(ErrNotFound, "API key not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get API key"))
+		return "", errors.Wrap(err, "failed to get API key")
+	}
+
+	return userID, nil
+}
+
+func scanAPIKey(row pgx.Row) (*APIKey, error) {
+	var key APIKey
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
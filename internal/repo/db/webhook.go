@@ -0,0 +1,357 @@
+package db
+
+import (
+	"context"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// ErrNotFound - запись не найдена
+var ErrNotFound = errors.New("not found")
+
+// Запросы вебхуков
+const (
+	insertWebhookQuery = "INSERT INTO webhooks (url, secret, events, active) VALUES ($1, $2, $3, $4) RETURNING id"
+	getWebhookQuery    = "SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks WHERE id = $1"
+	getWebhooksQuery   = "SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks ORDER BY id"
+	getEventHooksQuery = "SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks WHERE active AND $1 = ANY(events)"
+	updateWebhookQuery = "UPDATE webhooks SET url = $2, events = $3, active = $4, updated_at = now() WHERE id = $1"
+	deleteWebhookQuery = "DELETE FROM webhooks WHERE id = $1"
+
+	insertDeliveryQuery = `INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	getDeliveryQuery = `SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE id = $1`
+	getDeliveriesQuery = `SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+	claimDeliveriesQuery = `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM due WHERE d.id = due.id
+		RETURNING d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.response_code, d.last_error, d.created_at, d.delivered_at`
+	succeedDeliveryQuery = `UPDATE webhook_deliveries
+		SET status = 'succeeded', attempts = attempts + 1, response_code = $2, last_error = '', delivered_at = now()
+		WHERE id = $1`
+	retryDeliveryQuery = `UPDATE webhook_deliveries
+		SET attempts = attempts + 1, response_code = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $1`
+	failDeliveryQuery = `UPDATE webhook_deliveries
+		SET status = 'failed', attempts = attempts + 1, response_code = $2, last_error = $3
+		WHERE id = $1`
+	redeliverQuery = `UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE id = $1`
+)
+
+// WebhookRepository - хранилище подписок и очереди доставок
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, hook Webhook) (int64, error)
+	GetWebhook(ctx context.Context, id int64) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*Webhook, error)
+	GetWebhooksByEvent(ctx context.Context, event string) ([]*Webhook, error)
+	UpdateWebhook(ctx context.Context, hook Webhook) error
+	DeleteWebhook(ctx context.Context, id int64) error
+
+	CreateDelivery(ctx context.Context, webhookID int64, eventID int64, event string, payload []byte) error
+	GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]*WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	SucceedDelivery(ctx context.Context, id int64, code int) error
+	RetryDelivery(ctx context.Context, id int64, code int, lastError string, next time.Time) error
+	FailDelivery(ctx context.Context, id int64, code int, lastError string) error
+	Redeliver(ctx context.Context, id int64) error
+}
+
+// CreateWebhook создает подписку
+func (r *DBrepository) CreateWebhook(ctx context.Context, hook Webhook) (int64, error) {
+	defer metrics.ObserveQuery("CreateWebhook", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var id int64
+	err := r.pool.QueryRow(ctx, insertWebhookQuery, hook.URL, hook.Secret, hook.Events, hook.Active).Scan(&id)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to create webhook"))
+		return -1, errors.Wrap(err, "failed to create webhook")
+	}
+
+	return id, nil
+}
+
+// GetWebhook возвращает подписку по id
+func (r *DBrepository) GetWebhook(ctx context.Context, id int64) (*Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhook", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	hook, err := scanWebhook(r.pool.QueryRow(ctx, getWebhookQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "webhook not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get webhook"))
+		return nil, errors.Wrap(err, "failed to get webhook")
+	}
+
+	return hook, nil
+}
+
+// GetWebhooks возвращает все подписки
+func (r *DBrepository) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhooks", time.Now())
+
+	return r.queryWebhooks(ctx, getWebhooksQuery)
+}
+
+// GetWebhooksByEvent возвращает активные подписки на событие
+func (r *DBrepository) GetWebhooksByEvent(ctx context.Context, event string) ([]*Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhooksByEvent", time.Now())
+
+	return r.queryWebhooks(ctx, getEventHooksQuery, event)
+}
+
+// UpdateWebhook обновляет подписку
+func (r *DBrepository) UpdateWebhook(ctx context.Context, hook Webhook) error {
+	defer metrics.ObserveQuery("UpdateWebhook", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, updateWebhookQuery, hook.ID, hook.URL, hook.Events, hook.Active)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to update webhook"))
+		return errors.Wrap(err, "failed to update webhook")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "webhook not found")
+	}
+
+	return nil
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом доставок
+func (r *DBrepository) DeleteWebhook(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("DeleteWebhook", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, deleteWebhookQuery, id)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete webhook"))
+		return errors.Wrap(err, "failed to delete webhook")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "webhook not found")
+	}
+
+	return nil
+}
+
+// CreateDelivery ставит доставку события в очередь, повторная постановка
+// того же события подписчику игнорируется
+func (r *DBrepository) CreateDelivery(ctx context.Context, webhookID int64, eventID int64, event string, payload []byte) error {
+	defer metrics.ObserveQuery("CreateDelivery", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := r.pool.Exec(ctx, insertDeliveryQuery, webhookID, eventID, event, payload); err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to create delivery"))
+		return errors.Wrap(err, "failed to create delivery")
+	}
+
+	return nil
+}
+
+// GetDelivery возвращает доставку по id
+func (r *DBrepository) GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	defer metrics.ObserveQuery("GetDelivery", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delivery, err := scanDelivery(r.pool.QueryRow(ctx, getDeliveryQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "delivery not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get delivery"))
+		return nil, errors.Wrap(err, "failed to get delivery")
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries возвращает журнал доставок подписки
+func (r *DBrepository) GetDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]*WebhookDelivery, error) {
+	defer metrics.ObserveQuery("GetDeliveries", time.Now())
+
+	return r.queryDeliveries(ctx, getDeliveriesQuery, webhookID, limit, offset)
+}
+
+// ClaimDeliveries забирает готовые к отправке доставки и откладывает их на время lease,
+// чтобы другие экземпляры сервиса не отправили их повторно
+func (r *DBrepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	defer metrics.ObserveQuery("ClaimDeliveries", time.Now())
+
+	return r.queryDeliveries(ctx, claimDeliveriesQuery, limit, time.Now().Add(lease))
+}
+
+// SucceedDelivery отмечает доставку успешной
+func (r *DBrepository) SucceedDelivery(ctx context.Context, id int64, code int) error {
+	defer metrics.ObserveQuery("SucceedDelivery", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark delivery succeeded", succeedDeliveryQuery, id, code)
+}
+
+// RetryDelivery откладывает доставку до следующей попытки
+func (r *DBrepository) RetryDelivery(ctx context.Context, id int64, code int, lastError string, next time.Time) error {
+	defer metrics.ObserveQuery("RetryDelivery", time.Now())
+
+	return r.execDelivery(ctx, "failed to schedule delivery retry", retryDeliveryQuery, id, code, lastError, next)
+}
+
+// FailDelivery отмечает доставку окончательно неуспешной
+func (r *DBrepository) FailDelivery(ctx context.Context, id int64, code int, lastError string) error {
+	defer metrics.ObserveQuery("FailDelivery", time.Now())
+
+	return r.execDelivery(ctx, "failed to mark delivery failed", failDeliveryQuery, id, code, lastError)
+}
+
+// Redeliver возвращает доставку в очередь
+func (r *DBrepository) Redeliver(ctx context.Context, id int64) error {
+	defer metrics.ObserveQuery("Redeliver", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, redeliverQuery, id)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to redeliver"))
+		return errors.Wrap(err, "failed to redeliver")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "delivery not found")
+	}
+
+	return nil
+}
+
+func (r *DBrepository) queryWebhooks(ctx context.Context, query string, args ...any) ([]*Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get webhooks"))
+		return nil, errors.Wrap(err, "failed to get webhooks")
+	}
+	defer rows.Close()
+
+	hooks := make([]*Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan webhook"))
+			return nil, errors.Wrap(err, "failed to scan webhook")
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+func (r *DBrepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get deliveries"))
+		return nil, errors.Wrap(err, "failed to get deliveries")
+	}
+	defer rows.Close()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan delivery"))
+			return nil, errors.Wrap(err, "failed to scan delivery")
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *DBrepository) execDelivery(ctx context.Context, msg string, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := r.pool.Exec(ctx, query, args...); err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, msg))
+		return errors.Wrap(err, msg)
+	}
+
+	return nil
+}
+
+func scanWebhook(row pgx.Row) (*Webhook, error) {
+	var hook Webhook
+	if err := row.Scan(
+		&hook.ID,
+		&hook.URL,
+		&hook.Secret,
+		&hook.Events,
+		&hook.Active,
+		&hook.CreatedAt,
+		&hook.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+func scanDelivery(row pgx.Row) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.ResponseCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
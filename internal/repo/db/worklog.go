@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"restapi/internal/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// ErrConflict - запись противоречит уже сохраненным данным
+var ErrConflict = errors.New("conflict")
+
+// worklogLock - пространство ключей advisory-блокировок учета времени пользователя
+const worklogLock int32 = 0x776f726b
+
+// uniqueViolation - код ошибки Postgres при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+// Группировки отчета по учету времени
+const (
+	WorklogByUser   = "user"
+	WorklogByTask   = "task"
+	WorklogByStatus = "status"
+	WorklogByDay    = "day"
+)
+
+// worklogGroups - выражения группировки отчета по строкам reportWorklogsQuery
+var worklogGroups = map[string]string{
+	WorklogByUser:   "user_id",
+	WorklogByTask:   "task_id::text",
+	WorklogByStatus: "status",
+	WorklogByDay:    "day",
+}
+
+// Запросы учета времени
+const (
+	worklogColumns = `id, task_id, user_id, started_at, ended_at, description,
+		EXTRACT(EPOCH FROM COALESCE(ended_at, now()) - started_at)::bigint, created_at`
+
+	lockWorklogUserQuery = "SELECT pg_advisory_xact_lock($1, hashtext($2))"
+	// overlapWorklogQuery ищет запись пользователя, пересекающую [$2, $3).
+	// $2 NULL - текущий момент, $3 NULL - без окончания (запуск таймера)
+	overlapWorklogQuery = `SELECT id FROM worklogs
+		WHERE user_id = $1
+			AND started_at < COALESCE($3::timestamptz, 'infinity')
+			AND COALESCE(ended_at, 'infinity') > COALESCE($2::timestamptz, now())
+		LIMIT 1`
+	insertWorklogQuery = `INSERT INTO worklogs (task_id, user_id, started_at, ended_at, description)
+		SELECT id, $2, COALESCE($3::timestamptz, now()), $4::timestamptz, $5 FROM tasks WHERE id = $1
+		RETURNING ` + worklogColumns
+	stopWorklogQuery = `UPDATE worklogs SET ended_at = GREATEST(now(), started_at)
+		WHERE task_id = $1 AND user_id = $2 AND ended_at IS NULL
+		RETURNING ` + worklogColumns
+	getWorklogsQuery       = "SELECT " + worklogColumns + " FROM worklogs WHERE task_id = $1 ORDER BY started_at, id"
+	deleteWorklogQuery     = "DELETE FROM worklogs WHERE task_id = $1 AND id = $2"
+	deleteUserWorklogQuery = "DELETE FROM worklogs WHERE task_id = $1 AND id = $2 AND user_id = $3"
+	// reportWorklogsQuery - записи обрезаются по периоду [$1, $2), запущенные таймеры
+	// считаются до текущего момента. Записи делятся на части по границам дней в часовом
+	// поясе $6, и время каждой части относится к своему дню. Часть нулевой длины на
+	// границе дня отбрасывается, entries считает записи, а не части
+	reportWorklogsQuery = `WITH periods AS (
+			SELECT w.id, w.user_id, w.task_id, t.status,
+				GREATEST(w.started_at, COALESCE($1::timestamptz, '-infinity')) AS started_at,
+				LEAST(COALESCE(w.ended_at, now()), COALESCE($2::timestamptz, 'infinity')) AS ended_at
+			FROM worklogs w
+			JOIN tasks t ON t.id = w.task_id
+			WHERE ($3::text = '' OR w.user_id = $3)
+				AND ($4::bigint = 0 OR w.task_id = $4)
+				AND (cardinality($5::text[]) = 0 OR t.status = ANY($5))
+		), clipped AS (
+			SELECT * FROM periods WHERE ended_at >= started_at
+		), days AS (
+			SELECT c.id, c.user_id, c.task_id, c.status,
+				GREATEST(c.started_at, d.day AT TIME ZONE $6::text) AS started_at,
+				LEAST(c.ended_at, (d.day + interval '1 day') AT TIME ZONE $6::text) AS ended_at,
+				to_char(d.day, 'YYYY-MM-DD') AS day,
+				d.day = date_trunc('day', c.started_at AT TIME ZONE $6::text) AS first
+			FROM clipped c
+			CROSS JOIN LATERAL generate_series(
+				date_trunc('day', c.started_at AT TIME ZONE $6::text),
+				date_trunc('day', c.ended_at AT TIME ZONE $6::text),
+				interval '1 day') AS d(day)
+		)
+		SELECT %s AS key, SUM(EXTRACT(EPOCH FROM ended_at - started_at))::bigint, COUNT(DISTINCT id)
+		FROM days WHERE ended_at > started_at OR first
+		GROUP BY key ORDER BY key`
+)
+
+// WorklogRepository - хранилище учета времени по задачам
+type WorklogRepository interface {
+	StartWorklog(ctx context.Context, taskID int64, userID, description string) (*Worklog, error)
+	StopWorklog(ctx context.Context, taskID int64, userID string) (*Worklog, error)
+	CreateWorklog(ctx context.Context, worklog Worklog) (*Worklog, error)
+	GetWorklogs(ctx context.Context, taskID int64) ([]*Worklog, error)
+	DeleteWorklog(ctx context.Context, taskID, id int64, userID string) error
+	ReportWorklogs(ctx context.Context, groupBy string, filter WorklogFilter, timezone string) ([]WorklogTotal, error)
+}
+
+// StartWorklog запускает таймер пользователя по задаче. Возвращает ErrConflict,
+// если у пользователя уже есть запущенный таймер или запись, которая еще не закончилась
+func (r *DBrepository) StartWorklog(ctx context.Context, taskID int64, userID, description string) (*Worklog, error) {
+	defer metrics.ObserveQuery("StartWorklog", time.Now())
+
+	return r.insertWorklog(ctx, Worklog{TaskID: taskID, UserID: userID, Description: description}, nil, nil)
+}
+
+// StopWorklog останавливает запущенный таймер пользователя по задаче
+func (r *DBrepository) StopWorklog(ctx context.Context, taskID int64, userID string) (*Worklog, error) {
+	defer metrics.ObserveQuery("StopWorklog", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	worklog, err := scanWorklog(r.pool.QueryRow(ctx, stopWorklogQuery, taskID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(ErrNotFound, "running timer not found")
+		}
+		r.logger(ctx).Error(errors.Wrap(err, "failed to stop worklog"))
+		return nil, errors.Wrap(err, "failed to stop worklog")
+	}
+
+	return worklog, nil
+}
+
+// CreateWorklog добавляет запись о работе за период [StartedAt, EndedAt). Возвращает
+// ErrConflict, если период пересекается с другой записью пользователя
+func (r *DBrepository) CreateWorklog(ctx context.Context, worklog Worklog) (*Worklog, error) {
+	defer metrics.ObserveQuery("CreateWorklog", time.Now())
+
+	return r.insertWorklog(ctx, worklog, &worklog.StartedAt, worklog.EndedAt)
+}
+
+// GetWorklogs возвращает записи учета времени по задаче
+func (r *DBrepository) GetWorklogs(ctx context.Context, taskID int64) ([]*Worklog, error) {
+	defer metrics.ObserveQuery("GetWorklogs", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, getWorklogsQuery, taskID)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to get worklogs"))
+		return nil, errors.Wrap(err, "failed to get worklogs")
+	}
+	defer rows.Close()
+
+	worklogs := make([]*Worklog, 0)
+	for rows.Next() {
+		worklog, err := scanWorklog(rows)
+		if err != nil {
+			r.logger(ctx).Error(errors.Wrap(err, "failed to scan worklog"))
+			return nil, errors.Wrap(err, "failed to scan worklog")
+		}
+		worklogs = append(worklogs, worklog)
+	}
+
+	return worklogs, rows.Err()
+}
+
+// DeleteWorklog удаляет запись учета времени по задаче. Непустой userID - только
+// запись этого пользователя, запись другого пользователя не найдется
+func (r *DBrepository) DeleteWorklog(ctx context.Context, taskID, id int64, userID string) error {
+	defer metrics.ObserveQuery("DeleteWorklog", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	query, args := deleteWorklogQuery, []any{taskID, id}
+	if userID != "" {
+		query, args = deleteUserWorklogQuery, append(args, userID)
+	}
+
+	tag, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to delete worklog"))
+		return errors.Wrap(err, "failed to delete worklog")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(ErrNotFound, "worklog not found")
+	}
+
+	return nil
+}
+
+// ReportWorklogs суммирует время по группировке groupBy (WorklogByUser, WorklogByTask,
+// WorklogByStatus, WorklogByDay). Дни считаются в часовом поясе timezone
+func (r *DBrepository) ReportWorklogs(ctx context.Context, groupBy string, filter WorklogFilter, timezone string) ([]WorklogTotal, error) {
+	defer metrics.ObserveQuery("ReportWorklogs", time.Now())
+
+	key, ok := worklogGroups[groupBy]
+	if !ok {
+		return nil, errors.Errorf("unknown worklog grouping %q", groupBy)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	statuses := filter.Statuses
+	if statuses == nil {
+		statuses = []string{}
+	}
+
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(reportWorklogsQuery, key),
+		filter.From, filter.To, filter.UserID, filter.TaskID, statuses, timezone)
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to report worklogs"))
+		return nil, errors.Wrap(err, "failed to report worklogs")
+	}
+
+	totals, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (WorklogTotal, error) {
+		var total WorklogTotal
+		err := row.Scan(&total.Key, &total.Seconds, &total.Entries)
+		return total, err
+	})
+	if err != nil {
+		r.logger(ctx).Error(errors.Wrap(err, "failed to scan worklog report"))
+		return nil, errors.Wrap(err, "failed to scan worklog report")
+	}
+
+	return totals, nil
+}
+
+// insertWorklog сохраняет запись, если она не пересекается с другими записями
+// пользователя. Записи одного пользователя добавляются по очереди под advisory-блокировкой
+func (r *DBrepository) insertWorklog(ctx context.Context, worklog Worklog, from, to *time.Time) (*Worklog, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var created *Worklog
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, lockWorklogUserQuery, worklogLock, worklog.UserID); err != nil {
+			return errors.Wrap(err, "failed to lock user worklogs")
+		}
+
+		var overlapID int64
+		err := tx.QueryRow(ctx, overlapWorklogQuery, worklog.UserID, from, to).Scan(&overlapID)
+		switch {
+		case err == nil:
+			return errors.Wrapf(ErrConflict, "overlaps worklog %d", overlapID)
+		case !errors.Is(err, pgx.ErrNoRows):
+			return errors.Wrap(err, "failed to check overlapping worklogs")
+		}
+
+		created, err = scanWorklog(tx.QueryRow(ctx, insertWorklogQuery,
+			worklog.TaskID, worklog.UserID, from, to, worklog.Description))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrap(ErrNotFound, "task not found")
+		}
+		return errors.Wrap(err, "failed to create worklog")
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, errors.Wrap(ErrConflict, "user already has a running timer")
+		}
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) {
+			r.logger(ctx).Error(err)
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func scanWorklog(row pgx.Row) (*Worklog, error) {
+	var worklog Worklog
+	if err := row.Scan(
+		&worklog.ID,
+		&worklog.TaskID,
+		&worklog.UserID,
+		&worklog.StartedAt,
+		&worklog.EndedAt,
+		&worklog.Description,
+		&worklog.Seconds,
+		&worklog.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &worklog, nil
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"restapi/internal/auth"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const bearerPrefix = "Bearer "
+
+// authorize - проверяет TOKEN или ключ API из метаданных authorization: Bearer <token>
+func authorize(ctx context.Context, log *zap.SugaredLogger, authenticator *auth.Authenticator) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if !strings.HasPrefix(value, bearerPrefix) {
+			continue
+		}
+
+		_, err := authenticator.Authenticate(ctx, strings.TrimPrefix(value, bearerPrefix))
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, auth.ErrUnauthorized) {
+			log.Errorf("Error checking authorization: %v", err)
+			return internalError()
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+// unaryAuth - проверка авторизации для обычных вызовов
+func unaryAuth(log *zap.SugaredLogger, authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, log, authenticator); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// streamAuth - проверка авторизации для потоковых вызовов
+func streamAuth(log *zap.SugaredLogger, authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), log, authenticator); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
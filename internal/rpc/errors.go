@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"restapi/internal/dto"
+	"restapi/pkg/validator"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "restapi"
+
+// statusCodes - соответствие кодов ошибок REST API кодам gRPC
+var statusCodes = map[string]codes.Code{
+	dto.FieldBadFormat:     codes.InvalidArgument,
+	dto.FieldIncorrect:     codes.InvalidArgument,
+	dto.FieldNotFound:      codes.NotFound,
+	dto.Conflict:           codes.FailedPrecondition,
+	dto.ServiceUnavailable: codes.Unavailable,
+}
+
+// newError - возвращает ошибку gRPC с кодом REST API в ErrorInfo.Reason.
+// Код без соответствия в statusCodes становится codes.Internal
+func newError(code, desc string) error {
+	grpcCode, ok := statusCodes[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+	st := status.New(grpcCode, desc)
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: code,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// internalError - возвращает ошибку недоступности сервиса
+func internalError() error {
+	return newError(dto.ServiceUnavailable, dto.InternalError)
+}
+
+// validationError - возвращает ошибку с нарушениями валидации в BadRequest.FieldViolations
+func validationError(fields []validator.FieldError) error {
+	st := status.New(codes.InvalidArgument, "Invalid request body")
+
+	info := &errdetails.ErrorInfo{
+		Reason: dto.FieldIncorrect,
+		Domain: errorDomain,
+	}
+	badRequest := &errdetails.BadRequest{}
+	for _, f := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		})
+	}
+
+	detailed, err := st.WithDetails(info, badRequest)
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// acceptLanguage - язык сообщений из метаданных accept-language
+func acceptLanguage(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return strings.Join(md.Get("accept-language"), ",")
+}
@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"context"
+	"restapi/internal/auth"
+	"restapi/internal/dto"
+	"restapi/internal/event"
+	"restapi/internal/service"
+	"restapi/internal/stream"
+	"restapi/pkg/pb/taskv1"
+	"restapi/pkg/validator"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type server struct {
+	taskv1.UnimplementedTaskServiceServer
+
+	log     *zap.SugaredLogger
+	service service.TaskService
+	hub     *stream.Hub
+}
+
+// NewServer создает gRPC-сервер TaskService с авторизацией по TOKEN или ключу API
+func NewServer(log *zap.SugaredLogger, svc service.TaskService, hub *stream.Hub, authenticator *auth.Authenticator) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth(log, authenticator)),
+		grpc.ChainStreamInterceptor(streamAuth(log, authenticator)),
+	)
+
+	taskv1.RegisterTaskServiceServer(srv, &server{
+		log:     log,
+		service: svc,
+		hub:     hub,
+	})
+
+	return srv
+}
+
+// CreateTask - создает новую задачу
+func (s *server) CreateTask(ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.CreateTaskResponse, error) {
+	task, err := s.service.CreateTask(ctx, service.CreateTaskInput{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Status:      req.GetStatus(),
+		DueAt:       fromTimestamp(req.GetDueAt()),
+	})
+	if err != nil {
+		return nil, s.serviceError(ctx, err, "Error creating task")
+	}
+
+	return &taskv1.CreateTaskResponse{Id: task.ID}, nil
+}
+
+// GetTask - возвращает задачу по id
+func (s *server) GetTask(ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.Task, error) {
+	if req.GetId() <= 0 {
+		s.log.Error("Invalid task id")
+		return nil, newError(dto.FieldBadFormat, "Invalid task id")
+	}
+
+	task, err := s.service.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, s.serviceError(ctx, err, "Error getting task")
+	}
+
+	return toProto(task), nil
+}
+
+// ListTasks - возвращает страницу задач с фильтром по статусам
+func (s *server) ListTasks(ctx context.Context, req *taskv1.ListTasksRequest) (*taskv1.ListTasksResponse, error) {
+	tasks, err := s.service.ListTasks(ctx, service.ListTasksInput{
+		Page:     int(req.GetPage()),
+		Limit:    int(req.GetLimit()),
+		Statuses: req.GetStatus(),
+	})
+	if err != nil {
+		return nil, s.serviceError(ctx, err, "Error getting tasks")
+	}
+
+	if len(tasks) == 0 {
+		s.log.Error("Tasks not found")
+		return nil, newError(dto.FieldNotFound, "Tasks not found")
+	}
+
+	resp := &taskv1.ListTasksResponse{Tasks: make([]*taskv1.Task, 0, len(tasks))}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, toProto(task))
+	}
+
+	return resp, nil
+}
+
+// UpdateTask - обновляет задачу
+func (s *server) UpdateTask(ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.UpdateTaskResponse, error) {
+	if req.GetId() <= 0 {
+		s.log.Error("Invalid task id")
+		return nil, newError(dto.FieldBadFormat, "Invalid task id")
+	}
+
+	_, err := s.service.UpdateTask(ctx, req.GetId(), service.UpdateTaskInput{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Status:      req.GetStatus(),
+		DueAt:       fromTimestamp(req.GetDueAt()),
+	})
+	if err != nil {
+		return nil, s.serviceError(ctx, err, "Error updating task")
+	}
+
+	return &taskv1.UpdateTaskResponse{}, nil
+}
+
+// DeleteTask - удаляет задачу
+func (s *server) DeleteTask(ctx context.Context, req *taskv1.DeleteTaskRequest) (*taskv1.DeleteTaskResponse, error) {
+	if req.GetId() <= 0 {
+		s.log.Error("Invalid task id")
+		return nil, newError(dto.FieldBadFormat, "Invalid task id")
+	}
+
+	if err := s.service.DeleteTask(ctx, req.GetId()); err != nil {
+		return nil, s.serviceError(ctx, err, "Error deleting task")
+	}
+
+	return &taskv1.DeleteTaskResponse{}, nil
+}
+
+// WatchTasks - отдает поток событий задач
+func (s *server) WatchTasks(req *taskv1.WatchTasksRequest, srv taskv1.TaskService_WatchTasksServer) error {
+	filter, err := stream.NewFilter(req.GetStatuses(), req.GetEvents(), req.GetTaskId())
+	if err != nil {
+		s.log.Errorf("Invalid stream filter: %v", zap.Error(err))
+		return newError(dto.FieldIncorrect, err.Error())
+	}
+
+	ctx := srv.Context()
+
+	// Подписка оформляется до чтения истории, чтобы не пропустить события между ними
+	sub := s.hub.Subscribe(filter)
+	defer s.hub.Unsubscribe(sub)
+
+	var last event.Position
+	if req.GetLastEventId() > 0 {
+		replay, err := s.hub.Replay(ctx, req.GetLastEventId(), filter)
+		if err != nil {
+			s.log.Errorf("Error replaying events: %v", zap.Error(err))
+			return internalError()
+		}
+
+		for _, e := range replay {
+			if err := srv.Send(eventToProto(e)); err != nil {
+				return err
+			}
+			last = e.Position
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return newError(dto.ServiceUnavailable, "Event stream closed")
+			}
+			// Событие могло прийти и в истории, и в подписке
+			if !last.Less(e.Position) {
+				continue
+			}
+			if err := srv.Send(eventToProto(e)); err != nil {
+				return err
+			}
+			last = e.Position
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// serviceError - преобразует ошибку TaskService в ошибку gRPC
+func (s *server) serviceError(ctx context.Context, err error, msg string) error {
+	var validationErr *service.ValidationError
+	var vErrors *validator.Errors
+	switch {
+	case errors.As(err, &vErrors):
+		s.log.Errorf("Invalid request body: %v", zap.Error(err))
+		return validationError(vErrors.Fields(acceptLanguage(ctx)))
+	case errors.As(err, &validationErr):
+		s.log.Errorf("Invalid request body: %v", zap.Error(err))
+		return newError(dto.FieldIncorrect, "Invalid request body")
+	case errors.Is(err, service.ErrNotFound):
+		return newError(dto.FieldNotFound, "Task not found")
+	case errors.Is(err, service.ErrWIPLimit):
+		return newError(dto.Conflict, "WIP limit of the column is reached")
+	default:
+		s.log.Errorf("%s: %v", msg, zap.Error(err))
+		return internalError()
+	}
+}
+
+func toProto(task service.Task) *taskv1.Task {
+	pb := &taskv1.Task{
+		Id:           task.ID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		CreatedAt:    timestamppb.New(task.CreatedAt),
+		UpdatedAt:    timestamppb.New(task.UpdatedAt),
+		Rank:         task.Rank,
+		RecurrenceId: task.RecurrenceID,
+	}
+	if task.DueAt != nil {
+		pb.DueAt = timestamppb.New(*task.DueAt)
+	}
+
+	return pb
+}
+
+// fromTimestamp - срок из запроса, nil если не задан
+func fromTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
+
+func eventToProto(e event.Event) *taskv1.TaskEvent {
+	task := e.Data.Task
+
+	pb := &taskv1.Task{
+		Id:           task.ID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		CreatedAt:    timestamppb.New(task.CreatedAt),
+		UpdatedAt:    timestamppb.New(task.UpdatedAt),
+		Rank:         task.Rank,
+		RecurrenceId: task.RecurrenceID,
+	}
+	if task.DueAt != nil {
+		pb.DueAt = timestamppb.New(*task.DueAt)
+	}
+
+	return &taskv1.TaskEvent{
+		Id:             e.ID,
+		Event:          e.Type,
+		OccurredAt:     timestamppb.New(e.OccurredAt),
+		Task:           pb,
+		PreviousStatus: e.Data.PreviousStatus,
+	}
+}
@@ -0,0 +1,220 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"restapi/internal/auth"
+	"restapi/internal/dto"
+	"restapi/internal/event"
+	"restapi/internal/repo/db"
+	"restapi/internal/service"
+	"restapi/pkg/pb/taskv1"
+	"restapi/pkg/validator"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	testToken = "secret"
+	// userKey - ключ API пользователя alice
+	userKey = auth.KeyPrefix + "0123456789abcdef0123456789abcdef"
+	// brokenKey - ключ, при проверке которого хранилище недоступно
+	brokenKey = auth.KeyPrefix + "ffffffffffffffffffffffffffffffff"
+)
+
+// testKeys знает только userKey
+type testKeys struct{}
+
+func (testKeys) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	switch hash {
+	case auth.Hash(userKey):
+		return "alice", nil
+	case auth.Hash(brokenKey):
+		return "", errors.New("connection refused")
+	default:
+		return "", errors.Wrap(db.ErrNotFound, "API key not found")
+	}
+}
+
+// fakeService отвечает err и запоминает фильтр списка. Остальные методы не реализованы
+type fakeService struct {
+	service.TaskService
+
+	listed service.ListTasksInput
+	err    error
+}
+
+func (s *fakeService) GetTask(ctx context.Context, id int64) (service.Task, error) {
+	return service.Task{ID: id, Status: "new"}, s.err
+}
+
+func (s *fakeService) ListTasks(ctx context.Context, input service.ListTasksInput) ([]service.Task, error) {
+	s.listed = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []service.Task{{ID: 1, Status: "new"}}, nil
+}
+
+// newTestClient запускает сервер в памяти и возвращает клиент к нему
+func newTestClient(t *testing.T, svc service.TaskService) taskv1.TaskServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	authenticator := auth.NewAuthenticator(func() string { return testToken }, testKeys{})
+	srv := NewServer(zap.NewNop().Sugar(), svc, nil, authenticator)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return taskv1.NewTaskServiceClient(conn)
+}
+
+// withAuth - контекст с метаданными authorization
+func withAuth(values ...string) context.Context {
+	ctx := context.Background()
+	for _, v := range values {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", v)
+	}
+	return ctx
+}
+
+func TestAuth(t *testing.T) {
+	client := newTestClient(t, &fakeService{})
+
+	tests := []struct {
+		name string
+		auth []string
+		want codes.Code
+	}{
+		{name: "no metadata", want: codes.Unauthenticated},
+		{name: "token without bearer", auth: []string{testToken}, want: codes.Unauthenticated},
+		{name: "wrong token", auth: []string{"Bearer wrong"}, want: codes.Unauthenticated},
+		{name: "unknown key", auth: []string{"Bearer " + auth.KeyPrefix + "00000000000000000000000000000000"}, want: codes.Unauthenticated},
+		{name: "token", auth: []string{"Bearer " + testToken}, want: codes.OK},
+		{name: "api key", auth: []string{"Bearer " + userKey}, want: codes.OK},
+		{name: "second value", auth: []string{"Bearer wrong", "Bearer " + testToken}, want: codes.OK},
+		{name: "key store unavailable", auth: []string{"Bearer " + brokenKey}, want: codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetTask(withAuth(tt.auth...), &taskv1.GetTaskRequest{Id: 1})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code = %s, want %s (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestListTasksFilter(t *testing.T) {
+	svc := &fakeService{}
+	client := newTestClient(t, svc)
+
+	_, err := client.ListTasks(withAuth("Bearer "+testToken), &taskv1.ListTasksRequest{
+		Page: 2, Limit: 50, Status: []string{"new", "done"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.listed.Page != 2 || svc.listed.Limit != 50 || !slices.Equal(svc.listed.Statuses, []string{"new", "done"}) {
+		t.Errorf("ListTasksInput = %+v, want page 2, limit 50, statuses [new done]", svc.listed)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	invalid := validator.Validate(context.Background(), service.ListTasksInput{Statuses: []string{"new", "closed"}})
+	if invalid == nil {
+		t.Fatal("invalid filter passed validation")
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+		wantField  string
+	}{
+		{name: "not found", err: errors.Wrap(service.ErrNotFound, "get task"), wantCode: codes.NotFound, wantReason: dto.FieldNotFound},
+		{name: "wip limit", err: service.ErrWIPLimit, wantCode: codes.FailedPrecondition, wantReason: dto.Conflict},
+		{name: "validation", err: errors.Wrap(invalid, "list tasks"), wantCode: codes.InvalidArgument, wantReason: dto.FieldIncorrect, wantField: "status[1]"},
+		{name: "internal", err: errors.New("connection refused"), wantCode: codes.Unavailable, wantReason: dto.ServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &fakeService{err: tt.err})
+
+			_, err := client.ListTasks(withAuth("Bearer "+testToken), &taskv1.ListTasksRequest{})
+			st := status.Convert(err)
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s, want %s (%v)", st.Code(), tt.wantCode, err)
+			}
+
+			var reason, field string
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					reason = d.GetReason()
+				case *errdetails.BadRequest:
+					if len(d.GetFieldViolations()) > 0 {
+						field = d.GetFieldViolations()[0].GetField()
+					}
+				}
+			}
+			if reason != tt.wantReason || field != tt.wantField {
+				t.Errorf("reason = %q, field = %q, want %q, %q", reason, field, tt.wantReason, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestNewErrorUnknownCode(t *testing.T) {
+	// Код без соответствия не должен превращаться в codes.OK и nil
+	err := newError("UNKNOWN", "Something failed")
+	if err == nil || status.Code(err) != codes.Internal {
+		t.Errorf("newError = %v, want code %s", err, codes.Internal)
+	}
+}
+
+func TestEventToProto(t *testing.T) {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	recurrenceID := int64(3)
+
+	pb := eventToProto(event.Event{ID: 10, Type: event.TaskUpdated, Data: event.TaskData{Task: &event.Task{
+		ID: 42, Status: "new", DueAt: &due, RecurrenceID: &recurrenceID, Rank: "a0",
+	}}})
+
+	task := pb.GetTask()
+	if !task.GetDueAt().AsTime().Equal(due) || task.GetRecurrenceId() != 3 || task.GetRank() != "a0" {
+		t.Errorf("task = %v, want due_at %v, recurrence_id 3 and rank a0", task, due)
+	}
+
+	// Задача без срока и правила
+	pb = eventToProto(event.New(event.TaskCreated, &event.Task{ID: 43, Status: "new"}))
+	if pb.GetTask().DueAt != nil || pb.GetTask().RecurrenceId != nil {
+		t.Errorf("task = %v, want no due_at and recurrence_id", pb.GetTask())
+	}
+}
@@ -1,15 +1,48 @@
 package service
 
-// TaskRequest - запрос на создание задачи
-type TaskRequest struct {
+import "time"
+
+// Task - задача
+type Task struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// RecurrenceID - правило повторения, по которому создана задача
+	RecurrenceID *int64 `json:"recurrence_id,omitempty"`
+	// DueAt - срок задачи
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Rank - ключ порядка задачи в колонке доски, задачи колонки сортируются по нему побайтно
+	Rank      string    `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTaskInput - данные для создания задачи
+type CreateTaskInput struct {
 	Title       string `json:"title" validate:"required"`
 	Description string `json:"description" validate:"required"`
-	Status      string `json:"status" validate:"required"`
+	Status      string `json:"status" validate:"required,oneof=new in_progress done"`
+	// DueAt - срок задачи, необязательный
+	DueAt *time.Time `json:"due_at"`
 }
 
-// UpdateTaskRequest - запрос на обновление задачи
-type UpdateTaskRequest struct {
+// UpdateTaskInput - данные для обновления задачи
+type UpdateTaskInput struct {
 	Title       string `json:"title" validate:"required"`
 	Description string `json:"description" validate:"required"`
 	Status      string `json:"status" validate:"required,oneof=new in_progress done"`
-}
\ No newline at end of file
+	// DueAt - новый срок задачи, без значения срок не меняется
+	DueAt *time.Time `json:"due_at"`
+}
+
+// ListTasksInput - параметры списка задач
+type ListTasksInput struct {
+	// Page - номер страницы, начиная с 1
+	Page int `json:"page"`
+	// Limit - размер страницы, по умолчанию PageLimit
+	Limit    int      `json:"limit" validate:"min=0,max=100"`
+	Statuses []string `json:"status" validate:"dive,oneof=new in_progress done"`
+	// Sort - порядок: created_at (по умолчанию) или rank - по колонкам доски
+	Sort string `json:"sort" validate:"omitempty,oneof=created_at rank"`
+}
@@ -0,0 +1,22 @@
+package service
+
+import "github.com/pkg/errors"
+
+// ErrNotFound - задача не найдена
+var ErrNotFound = errors.New("task not found")
+
+// ErrWIPLimit - в колонке статуса задачи достигнут WIP-лимит
+var ErrWIPLimit = errors.New("WIP limit of the column is reached")
+
+// ValidationError - входные данные не прошли проверку
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
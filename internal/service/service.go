@@ -1,175 +1,185 @@
 package service
 
 import (
-	"restapi/internal/dto"
+	"context"
+	"restapi/internal/event"
 	"restapi/internal/repo/db"
 	"restapi/pkg/validator"
 
-	"github.com/gofiber/fiber/v2"
-	"go.uber.org/zap"
+	"github.com/pkg/errors"
 )
 
+// PageLimit - размер страницы списка задач
 const (
-	limit int = 2
+	PageLimit int = 2
 )
 
+// SortRank - список задач по колонкам доски: по статусу и порядку в колонке
+const SortRank = "rank"
+
 type service struct {
-	log  *zap.SugaredLogger
 	repo db.Repository
 }
 
-// Service - интерфейс сервиса
-type Service interface {
-	CreateTask(ctx *fiber.Ctx) error
-	GetTask(ctx *fiber.Ctx) error
-	GetAllTasks(ctx *fiber.Ctx) error
-	DeleteTask(ctx *fiber.Ctx) error
-	UpdateTask(ctx *fiber.Ctx) error
+// TaskService - бизнес-логика задач, не зависящая от транспорта.
+// Ошибки: *ValidationError для неверных входных данных, ErrNotFound для отсутствующей задачи
+type TaskService interface {
+	CreateTask(ctx context.Context, input CreateTaskInput) (Task, error)
+	GetTask(ctx context.Context, id int64) (Task, error)
+	ListTasks(ctx context.Context, input ListTasksInput) ([]Task, error)
+	GetTasks(ctx context.Context, ids []int64) (map[int64]Task, error)
+	GetTaskEvents(ctx context.Context, ids []int64) (map[int64][]event.Event, error)
+	UpdateTask(ctx context.Context, id int64, input UpdateTaskInput) (Task, error)
+	DeleteTask(ctx context.Context, id int64) error
 }
 
-func NewService(log *zap.SugaredLogger, repo db.Repository) Service {
+func NewService(repo db.Repository) TaskService {
 	return &service{
-		log:  log,
 		repo: repo,
 	}
 }
 
 // CreateTask - создает новую задачу
-func (s *service) CreateTask(ctx *fiber.Ctx) error {
-	var req TaskRequest
-
-	if err := ctx.BodyParser(&req); err != nil {
-		s.log.Errorf("Invalid request body: %v", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
-	}
-
-	if err := validator.Validate(ctx.Context(), req); err != nil {
-		s.log.Errorf("Invalid request body: %v", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, "Invalid request body")
+func (s *service) CreateTask(ctx context.Context, input CreateTaskInput) (Task, error) {
+	if err := validator.Validate(ctx, input); err != nil {
+		return Task{}, &ValidationError{Err: err}
 	}
 
-	task := db.Task{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
-	}
-
-	id, err := s.repo.CreateTask(ctx.Context(), task)
+	task, err := s.repo.CreateTask(ctx, db.Task{
+		Title:       input.Title,
+		Description: input.Description,
+		Status:      input.Status,
+		DueAt:       input.DueAt,
+	})
 	if err != nil {
-		s.log.Errorf("Error creating task: %v", zap.Error(err))
-		return dto.InternalServerError(ctx)
-	}
-
-	responce := dto.Response{
-		Status: "success",
-		Data:   map[string]int64{"id": id},
+		return Task{}, repoError(err, "failed to create task")
 	}
 
-	return ctx.Status(fiber.StatusCreated).JSON(responce)
+	return fromDB(task), nil
 }
 
 // GetTask - возвращает задачу по id
-func (s *service) GetTask(ctx *fiber.Ctx) error {
-	id, err := ctx.ParamsInt("id")
+func (s *service) GetTask(ctx context.Context, id int64) (Task, error) {
+	task, err := s.repo.GetTask(ctx, id)
 	if err != nil {
-		s.log.Error("Invalid task id")
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+		return Task{}, repoError(err, "failed to get task")
 	}
 
-	task, err := s.repo.GetTask(ctx.Context(), int64(id))
-	if err != nil {
-		s.log.Errorf("Error getting task: %v", zap.Error(err))
-		return dto.InternalServerError(ctx)
-	}
+	return fromDB(task), nil
+}
 
-	responce := dto.Response{
-		Status: "success",
-		Data:   task,
+// ListTasks - возвращает страницу задач
+func (s *service) ListTasks(ctx context.Context, input ListTasksInput) ([]Task, error) {
+	if err := validator.Validate(ctx, input); err != nil {
+		return nil, &ValidationError{Err: err}
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(responce)
-}
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
 
-// GetAllTasks - возвращает все задачи
-func (s *service) GetAllTasks(ctx *fiber.Ctx) error {
-	page := ctx.QueryInt("page", 1)
+	limit := input.Limit
+	if limit == 0 {
+		limit = PageLimit
+	}
 	offset := (page - 1) * limit
 
-	tasks, err := s.repo.GetAllTasks(ctx.Context(), limit, offset)
+	tasks, err := s.repo.GetAllTasks(ctx, db.TaskFilter{Statuses: input.Statuses, ByRank: input.Sort == SortRank}, limit, offset)
 	if err != nil {
-		s.log.Errorf("Error getting tasks: %v", zap.Error(err))
-		return dto.InternalServerError(ctx)
-	}
-
-	if len(tasks) == 0 {
-		s.log.Error("Tasks not found")
-		return dto.BadResponseError(ctx, dto.FieldNotFound, "Tasks not found")
+		return nil, errors.Wrap(err, "failed to get tasks")
 	}
 
-	responce := dto.Response{
-		Status: "success",
-		Data:   tasks,
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, fromDB(task))
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(responce)
+	return result, nil
 }
 
-// DeleteTask - удаляет задачу
-func (s *service) DeleteTask(ctx *fiber.Ctx) error {
-	id, err := ctx.ParamsInt("id")
+// GetTasks - возвращает задачи по списку id одним запросом, отсутствующих задач нет в результате
+func (s *service) GetTasks(ctx context.Context, ids []int64) (map[int64]Task, error) {
+	tasks, err := s.repo.GetTasksByIDs(ctx, ids)
 	if err != nil {
-		s.log.Error("Invalid task id")
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+		return nil, errors.Wrap(err, "failed to get tasks")
+	}
+
+	result := make(map[int64]Task, len(tasks))
+	for _, task := range tasks {
+		result[task.ID] = fromDB(task)
 	}
 
-	err = s.repo.DeleteTask(ctx.Context(), int64(id))
+	return result, nil
+}
+
+// GetTaskEvents - возвращает историю событий задач одним запросом
+func (s *service) GetTaskEvents(ctx context.Context, ids []int64) (map[int64][]event.Event, error) {
+	events, err := s.repo.GetTaskEvents(ctx, ids)
 	if err != nil {
-		s.log.Errorf("Error deleting task: %v", zap.Error(err))
-		return dto.InternalServerError(ctx)
+		return nil, errors.Wrap(err, "failed to get task events")
 	}
 
-	responce := dto.Response{
-		Status: "success",
+	result := make(map[int64][]event.Event, len(ids))
+	for _, e := range events {
+		id := e.Data.Task.ID
+		result[id] = append(result[id], e)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(responce)
+	return result, nil
 }
 
 // UpdateTask - обновляет задачу
-func (s *service) UpdateTask(ctx *fiber.Ctx) error {
-	id, err := ctx.ParamsInt("id")
+func (s *service) UpdateTask(ctx context.Context, id int64, input UpdateTaskInput) (Task, error) {
+	if err := validator.Validate(ctx, input); err != nil {
+		return Task{}, &ValidationError{Err: err}
+	}
+
+	task, err := s.repo.UpdateTask(ctx, id, db.UpdateTask{
+		Title:       input.Title,
+		Description: input.Description,
+		Status:      input.Status,
+		DueAt:       input.DueAt,
+	})
 	if err != nil {
-		s.log.Error("Invalid id")
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+		return Task{}, repoError(err, "failed to update task")
 	}
 
-	var req UpdateTaskRequest
+	return fromDB(task), nil
+}
 
-	if err := ctx.BodyParser(&req); err != nil {
-		s.log.Errorf("Invalid request body: %v", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+// DeleteTask - удаляет задачу
+func (s *service) DeleteTask(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteTask(ctx, id); err != nil {
+		return repoError(err, "failed to delete task")
 	}
 
-	if err := validator.Validate(ctx.Context(), req); err != nil {
-		s.log.Errorf("Invalid request body: %v", zap.Error(err))
-		return dto.BadResponseError(ctx, dto.FieldIncorrect, "Invalid request body")
-	}
+	return nil
+}
 
-	task := db.UpdateTask{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
+// repoError - заменяет отсутствие записи в репозитории на ErrNotFound,
+// а полную колонку - на ErrWIPLimit
+func repoError(err error, msg string) error {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, db.ErrWIPLimit):
+		return ErrWIPLimit
 	}
 
-	err = s.repo.UpdateTask(ctx.Context(), int64(id), task)
-	if err != nil {
-		s.log.Errorf("Error updating task: %v", zap.Error(err))
-		return dto.InternalServerError(ctx)
-	}
+	return errors.Wrap(err, msg)
+}
 
-	responce := dto.Response{
-		Status: "success",
+func fromDB(task *db.Task) Task {
+	return Task{
+		ID:           task.ID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		RecurrenceID: task.RecurrenceID,
+		DueAt:        task.DueAt,
+		Rank:         task.Rank,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
 	}
-
-	return ctx.Status(fiber.StatusOK).JSON(responce)
 }
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeRepo возвращает err или задачу из переданных данных и запоминает аргументы.
+// Остальные методы хранилища не реализованы
+type fakeRepo struct {
+	db.Repository
+
+	err error
+
+	created db.Task
+	updated db.UpdateTask
+	filter  db.TaskFilter
+	limit   int
+	offset  int
+}
+
+func (r *fakeRepo) CreateTask(ctx context.Context, task db.Task) (*db.Task, error) {
+	r.created = task
+	if r.err != nil {
+		return nil, r.err
+	}
+	task.ID = 1
+	task.Rank = "a0"
+	return &task, nil
+}
+
+func (r *fakeRepo) UpdateTask(ctx context.Context, id int64, task db.UpdateTask) (*db.Task, error) {
+	r.updated = task
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &db.Task{ID: id, Title: task.Title, Description: task.Description, Status: task.Status, DueAt: task.DueAt}, nil
+}
+
+func (r *fakeRepo) GetAllTasks(ctx context.Context, filter db.TaskFilter, limit int, offset int) ([]*db.Task, error) {
+	r.filter, r.limit, r.offset = filter, limit, offset
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []*db.Task{{ID: 1, Status: "new"}}, nil
+}
+
+// fields - нарушения валидации в виде поле:правило
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+
+	var validationErr *ValidationError
+	var vErrors *validator.Errors
+	if !errors.As(err, &validationErr) || !errors.As(err, &vErrors) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+
+	var result []string
+	for _, f := range vErrors.Fields("") {
+		result = append(result, f.Field+":"+f.Rule)
+	}
+	return result
+}
+
+func TestCreateTask(t *testing.T) {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	valid := CreateTaskInput{Title: "t", Description: "d", Status: "new", DueAt: &due}
+
+	tests := []struct {
+		name       string
+		input      CreateTaskInput
+		repoErr    error
+		wantFields []string
+		wantErr    error
+	}{
+		{name: "created", input: valid},
+		{name: "empty input", input: CreateTaskInput{}, wantFields: []string{"title:required", "description:required", "status:required"}},
+		{name: "unknown status", input: CreateTaskInput{Title: "t", Description: "d", Status: "archived"}, wantFields: []string{"status:oneof"}},
+		{name: "WIP limit", input: valid, repoErr: errors.Wrap(db.ErrWIPLimit, "column new has 1 of 1 tasks"), wantErr: ErrWIPLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{err: tt.repoErr}
+			task, err := NewService(repo).CreateTask(context.Background(), tt.input)
+
+			switch {
+			case tt.wantFields != nil:
+				if got := fields(t, err); !slices.Equal(got, tt.wantFields) {
+					t.Errorf("fields = %v, want %v", got, tt.wantFields)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatal(err)
+			default:
+				if task.ID != 1 || task.Rank != "a0" || task.DueAt == nil || !task.DueAt.Equal(due) {
+					t.Errorf("task = %+v, want id 1, rank a0 and due %v", task, due)
+				}
+				if repo.created.Title != "t" || repo.created.Status != "new" {
+					t.Errorf("repository got %+v", repo.created)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	valid := UpdateTaskInput{Title: "t", Description: "d", Status: "done"}
+
+	tests := []struct {
+		name       string
+		input      UpdateTaskInput
+		repoErr    error
+		wantFields []string
+		wantErr    error
+	}{
+		{name: "updated", input: valid},
+		{name: "unknown status", input: UpdateTaskInput{Title: "t", Description: "d", Status: "archived"}, wantFields: []string{"status:oneof"}},
+		{name: "no title", input: UpdateTaskInput{Description: "d", Status: "new"}, wantFields: []string{"title:required"}},
+		{name: "not found", input: valid, repoErr: errors.Wrap(db.ErrNotFound, "task not found"), wantErr: ErrNotFound},
+		{name: "WIP limit", input: valid, repoErr: errors.Wrap(db.ErrWIPLimit, "column done has 3 of 3 tasks"), wantErr: ErrWIPLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{err: tt.repoErr}
+			task, err := NewService(repo).UpdateTask(context.Background(), 7, tt.input)
+
+			switch {
+			case tt.wantFields != nil:
+				if got := fields(t, err); !slices.Equal(got, tt.wantFields) {
+					t.Errorf("fields = %v, want %v", got, tt.wantFields)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatal(err)
+			default:
+				if task.ID != 7 || task.Status != "done" {
+					t.Errorf("task = %+v, want id 7 in done", task)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTaskRepositoryError(t *testing.T) {
+	repoErr := errors.New("connection refused")
+
+	_, err := NewService(&fakeRepo{err: repoErr}).UpdateTask(context.Background(), 7, UpdateTaskInput{Title: "t", Description: "d", Status: "new"})
+	if !errors.Is(err, repoErr) || errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want wrapped repository error", err)
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      ListTasksInput
+		wantLimit  int
+		wantOffset int
+		wantFilter db.TaskFilter
+		wantFields []string
+	}{
+		{name: "defaults", wantLimit: PageLimit, wantOffset: 0},
+		{name: "page below one", input: ListTasksInput{Page: -3}, wantLimit: PageLimit, wantOffset: 0},
+		{name: "third page", input: ListTasksInput{Page: 3, Limit: 10}, wantLimit: 10, wantOffset: 20},
+		{name: "largest limit", input: ListTasksInput{Page: 2, Limit: 100}, wantLimit: 100, wantOffset: 100},
+		{
+			name:       "board order",
+			input:      ListTasksInput{Statuses: []string{"new", "done"}, Sort: SortRank},
+			wantLimit:  PageLimit,
+			wantFilter: db.TaskFilter{Statuses: []string{"new", "done"}, ByRank: true},
+		},
+		{name: "limit above maximum", input: ListTasksInput{Limit: 101}, wantFields: []string{"limit:max"}},
+		{name: "negative limit", input: ListTasksInput{Limit: -1}, wantFields: []string{"limit:min"}},
+		{name: "unknown status", input: ListTasksInput{Statuses: []string{"new", "archived"}}, wantFields: []string{"status[1]:oneof"}},
+		{name: "unknown sort", input: ListTasksInput{Sort: "title"}, wantFields: []string{"sort:oneof"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{}
+			tasks, err := NewService(repo).ListTasks(context.Background(), tt.input)
+
+			if tt.wantFields != nil {
+				if got := fields(t, err); !slices.Equal(got, tt.wantFields) {
+					t.Errorf("fields = %v, want %v", got, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if repo.limit != tt.wantLimit || repo.offset != tt.wantOffset {
+				t.Errorf("limit, offset = %d, %d, want %d, %d", repo.limit, repo.offset, tt.wantLimit, tt.wantOffset)
+			}
+			if !slices.Equal(repo.filter.Statuses, tt.wantFilter.Statuses) || repo.filter.ByRank != tt.wantFilter.ByRank {
+				t.Errorf("filter = %+v, want %+v", repo.filter, tt.wantFilter)
+			}
+			if len(tasks) != 1 {
+				t.Errorf("tasks = %v, want 1 task", tasks)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"restapi/internal/event"
+	"restapi/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingService - TaskService, который оборачивает каждый метод в спан
+type tracingService struct {
+	next TaskService
+}
+
+// WithTracing добавляет спаны вокруг методов TaskService
+func WithTracing(next TaskService) TaskService {
+	return &tracingService{next: next}
+}
+
+func (s *tracingService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "TaskService."+method, trace.WithAttributes(attrs...))
+}
+
+func (s *tracingService) CreateTask(ctx context.Context, input CreateTaskInput) (Task, error) {
+	ctx, span := s.start(ctx, "CreateTask")
+	task, err := s.next.CreateTask(ctx, input)
+	tracing.End(span, err)
+
+	return task, err
+}
+
+func (s *tracingService) GetTask(ctx context.Context, id int64) (Task, error) {
+	ctx, span := s.start(ctx, "GetTask", attribute.Int64("task.id", id))
+	task, err := s.next.GetTask(ctx, id)
+	tracing.End(span, err)
+
+	return task, err
+}
+
+func (s *tracingService) ListTasks(ctx context.Context, input ListTasksInput) ([]Task, error) {
+	ctx, span := s.start(ctx, "ListTasks", attribute.Int("page", input.Page), attribute.StringSlice("statuses", input.Statuses))
+	tasks, err := s.next.ListTasks(ctx, input)
+	tracing.End(span, err)
+
+	return tasks, err
+}
+
+func (s *tracingService) GetTasks(ctx context.Context, ids []int64) (map[int64]Task, error) {
+	ctx, span := s.start(ctx, "GetTasks", attribute.Int("task.count", len(ids)))
+	tasks, err := s.next.GetTasks(ctx, ids)
+	tracing.End(span, err)
+
+	return tasks, err
+}
+
+func (s *tracingService) GetTaskEvents(ctx context.Context, ids []int64) (map[int64][]event.Event, error) {
+	ctx, span := s.start(ctx, "GetTaskEvents", attribute.Int("task.count", len(ids)))
+	events, err := s.next.GetTaskEvents(ctx, ids)
+	tracing.End(span, err)
+
+	return events, err
+}
+
+func (s *tracingService) UpdateTask(ctx context.Context, id int64, input UpdateTaskInput) (Task, error) {
+	ctx, span := s.start(ctx, "UpdateTask", attribute.Int64("task.id", id))
+	task, err := s.next.UpdateTask(ctx, id, input)
+	tracing.End(span, err)
+
+	return task, err
+}
+
+func (s *tracingService) DeleteTask(ctx context.Context, id int64) error {
+	ctx, span := s.start(ctx, "DeleteTask", attribute.Int64("task.id", id))
+	err := s.next.DeleteTask(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
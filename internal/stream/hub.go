@@ -0,0 +1,306 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"restapi/internal/config"
+	"restapi/internal/event"
+	"restapi/internal/repo/db"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const pageSize = 100
+
+// Filter - фильтр событий подписчика, пустые поля не ограничивают выборку
+type Filter struct {
+	Events   map[string]bool
+	Statuses map[string]bool
+	TaskID   int64
+}
+
+// Допустимые значения фильтров
+var (
+	statuses = map[string]bool{"new": true, "in_progress": true, "done": true}
+	types    = map[string]bool{
+		event.TaskCreated:       true,
+		event.TaskUpdated:       true,
+		event.TaskStatusChanged: true,
+		event.TaskDeleted:       true,
+	}
+)
+
+// NewFilter создает фильтр и проверяет значения статусов и типов событий
+func NewFilter(statusList, eventList []string, taskID int64) (Filter, error) {
+	filter := Filter{TaskID: taskID}
+
+	var err error
+	if filter.Statuses, err = newSet(statusList, statuses, "status"); err != nil {
+		return Filter{}, err
+	}
+
+	if filter.Events, err = newSet(eventList, types, "event"); err != nil {
+		return Filter{}, err
+	}
+
+	return filter, nil
+}
+
+// newSet - собирает множество допустимых значений
+func newSet(items []string, allowed map[string]bool, name string) (map[string]bool, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !allowed[item] {
+			return nil, fmt.Errorf("invalid %s %q", name, item)
+		}
+		set[item] = true
+	}
+
+	return set, nil
+}
+
+// Match проверяет, подходит ли событие под фильтр
+func (f Filter) Match(e event.Event) bool {
+	if len(f.Events) > 0 && !f.Events[e.Type] {
+		return false
+	}
+
+	if len(f.Statuses) > 0 && !f.Statuses[e.Data.Task.Status] {
+		return false
+	}
+
+	if f.TaskID != 0 && f.TaskID != e.Data.Task.ID {
+		return false
+	}
+
+	return true
+}
+
+// Subscription - подписка на события. Канал C закрывается, если подписчик
+// не успевает читать события или хаб остановлен
+type Subscription struct {
+	C      <-chan event.Event
+	ch     chan event.Event
+	filter Filter
+}
+
+// Hub раздает события задач локальным подписчикам. Экземпляры сервиса узнают
+// о новых событиях через LISTEN/NOTIFY и читают их из outbox, поэтому
+// подписчик получает изменения, сделанные на любом экземпляре
+type Hub struct {
+	log  *zap.SugaredLogger
+	repo db.EventRepository
+	cfg  config.Stream
+
+	mu        sync.RWMutex
+	subs      map[*Subscription]struct{}
+	listening atomic.Bool
+
+	// feedMu защищает позицию ленты: ее дочитывают уведомления и опрос
+	feedMu   sync.Mutex
+	started  bool
+	position event.Position
+}
+
+// NewHub создает хаб событий
+func NewHub(log *zap.SugaredLogger, repo db.EventRepository, cfg config.Stream) *Hub {
+	return &Hub{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe регистрирует подписчика
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	ch := make(chan event.Event, h.cfg.Buffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe удаляет подписчика и закрывает его канал
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Replay возвращает подходящие под фильтр события после события afterID,
+// но не больше STREAM_REPLAY_LIMIT последних. Если события afterID уже нет
+// в outbox, возвращаются последние STREAM_REPLAY_LIMIT событий
+func (h *Hub) Replay(ctx context.Context, afterID int64, filter Filter) ([]event.Event, error) {
+	after, err := h.repo.EventPosition(ctx, afterID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, errors.Wrap(err, "failed to get event position")
+	}
+
+	events, err := h.repo.GetLastEvents(ctx, after, h.cfg.ReplayLimit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get events")
+	}
+
+	replay := make([]event.Event, 0, len(events))
+	for _, e := range events {
+		if filter.Match(e) {
+			replay = append(replay, e)
+		}
+	}
+
+	return replay, nil
+}
+
+// Run слушает уведомления о новых событиях до отмены контекста,
+// при обрыве соединения переподключается и дочитывает пропущенное.
+// Кроме уведомлений лента дочитывается раз в STREAM_POLL_INTERVAL
+func (h *Hub) Run(ctx context.Context) {
+	defer h.closeAll()
+
+	go h.poll(ctx)
+
+	for {
+		if err := h.start(ctx); err == nil {
+			h.listening.Store(true)
+			err = h.repo.Listen(ctx, db.EventsChannel, func(string) { h.catchUp(ctx) })
+			h.listening.Store(false)
+			if ctx.Err() != nil {
+				return
+			}
+			h.log.Errorf("Error listening for task events: %v", err)
+		} else {
+			h.log.Errorf("Error starting event stream: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.cfg.ReconnectDelay):
+		}
+	}
+}
+
+// poll дочитывает ленту по таймеру. Событие, зафиксированное при незавершенной
+// более старой транзакции, становится видимым без нового уведомления
+func (h *Hub) poll(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.catchUp(ctx)
+		}
+	}
+}
+
+// Check - проверка готовности: хаб подписан на уведомления о событиях
+func (h *Hub) Check(context.Context) error {
+	if !h.listening.Load() {
+		return errors.New("not listening for task events")
+	}
+
+	return nil
+}
+
+// start запоминает позицию ленты при первом запуске или дочитывает
+// события, пропущенные за время переподключения
+func (h *Hub) start(ctx context.Context) error {
+	h.feedMu.Lock()
+	started := h.started
+	h.feedMu.Unlock()
+
+	if started {
+		h.catchUp(ctx)
+		return nil
+	}
+
+	position, err := h.repo.LastPosition(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.feedMu.Lock()
+	h.position, h.started = position, true
+	h.feedMu.Unlock()
+
+	return nil
+}
+
+// catchUp читает события после последнего разосланного и раздает подписчикам
+func (h *Hub) catchUp(ctx context.Context) {
+	h.feedMu.Lock()
+	defer h.feedMu.Unlock()
+
+	if !h.started {
+		return
+	}
+
+	for {
+		events, err := h.repo.GetEvents(ctx, h.position, pageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.log.Errorf("Error reading task events: %v", err)
+			}
+			return
+		}
+
+		for _, e := range events {
+			h.broadcast(e)
+			h.position = e.Position
+		}
+
+		if len(events) < pageSize {
+			return
+		}
+	}
+}
+
+// broadcast раздает событие подписчикам, медленные подписчики отключаются
+func (h *Hub) broadcast(e event.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			h.log.Warnf("Dropping slow event stream subscriber")
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// closeAll отключает всех подписчиков при остановке хаба
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,358 @@
+package stream
+
+import (
+	"context"
+	"restapi/internal/config"
+	"restapi/internal/event"
+	"restapi/internal/repo/db"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeFeed - outbox в памяти с видимостью как в Postgres: id выдаются при записи,
+// а событие видно ленте, когда его транзакция и все более старые завершены
+type fakeFeed struct {
+	mu     sync.Mutex
+	nextID int64
+	nextTx int64
+	open   map[int64]bool
+	events []fakeEvent
+}
+
+type fakeEvent struct {
+	e         event.Event
+	committed bool
+}
+
+func newFakeFeed() *fakeFeed {
+	return &fakeFeed{open: make(map[int64]bool)}
+}
+
+// begin начинает транзакцию и возвращает ее номер
+func (f *fakeFeed) begin() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextTx++
+	f.open[f.nextTx] = true
+	return f.nextTx
+}
+
+// insert записывает событие в транзакции tx и возвращает его id
+func (f *fakeFeed) insert(tx int64, taskID int64) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	e := event.New(event.TaskUpdated, &event.Task{ID: taskID, Status: "new"})
+	e.ID = f.nextID
+	e.Position = event.Position{TxID: tx, ID: f.nextID}
+	f.events = append(f.events, fakeEvent{e: e})
+	return f.nextID
+}
+
+func (f *fakeFeed) commit(tx int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.open, tx)
+	for i := range f.events {
+		if f.events[i].e.Position.TxID == tx {
+			f.events[i].committed = true
+		}
+	}
+}
+
+// visible - стабильная часть ленты после позиции after
+func (f *fakeFeed) visible(after event.Position) []event.Event {
+	xmin := f.nextTx + 1
+	for tx := range f.open {
+		xmin = min(xmin, tx)
+	}
+
+	var events []event.Event
+	for _, fe := range f.events {
+		if fe.committed && fe.e.Position.TxID < xmin && after.Less(fe.e.Position) {
+			events = append(events, fe.e)
+		}
+	}
+	slices.SortFunc(events, func(a, b event.Event) int {
+		if a.Position.Less(b.Position) {
+			return -1
+		}
+		return 1
+	})
+
+	return events
+}
+
+func (f *fakeFeed) GetEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := f.visible(after)
+	return events[:min(limit, len(events))], nil
+}
+
+func (f *fakeFeed) GetLastEvents(ctx context.Context, after event.Position, limit int) ([]event.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := f.visible(after)
+	return events[max(0, len(events)-limit):], nil
+}
+
+func (f *fakeFeed) LastPosition(ctx context.Context) (event.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := f.visible(event.Position{})
+	if len(events) == 0 {
+		return event.Position{}, nil
+	}
+	return events[len(events)-1].Position, nil
+}
+
+func (f *fakeFeed) EventPosition(ctx context.Context, id int64) (event.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, fe := range f.events {
+		if fe.e.ID == id && fe.committed {
+			return fe.e.Position, nil
+		}
+	}
+	return event.Position{}, db.ErrNotFound
+}
+
+func (f *fakeFeed) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newTestHub(feed *fakeFeed) *Hub {
+	return NewHub(zap.NewNop().Sugar(), feed, config.Stream{
+		Buffer:         16,
+		KeepAlive:      time.Second,
+		ReplayLimit:    3,
+		ReconnectDelay: time.Second,
+		PollInterval:   10 * time.Millisecond,
+	})
+}
+
+// received - события, уже доставленные подписчику
+func received(sub *Subscription) []int64 {
+	var ids []int64
+	for {
+		select {
+		case e := <-sub.C:
+			ids = append(ids, e.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHubDeliversLateCommittedEvent(t *testing.T) {
+	feed := newFakeFeed()
+	hub := newTestHub(feed)
+	ctx := context.Background()
+
+	if err := hub.start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sub := hub.Subscribe(Filter{})
+
+	// Транзакция с id=1 фиксируется после транзакции с id=2
+	slow := feed.begin()
+	first := feed.insert(slow, 1)
+	fast := feed.begin()
+	second := feed.insert(fast, 2)
+	feed.commit(fast)
+
+	hub.catchUp(ctx)
+	if ids := received(sub); len(ids) != 0 {
+		t.Fatalf("delivered %v before the older transaction committed", ids)
+	}
+
+	feed.commit(slow)
+	hub.catchUp(ctx)
+
+	ids := received(sub)
+	if !slices.Equal(ids, []int64{first, second}) {
+		t.Fatalf("delivered %v, want [%d %d]", ids, first, second)
+	}
+
+	hub.catchUp(ctx)
+	if ids := received(sub); len(ids) != 0 {
+		t.Fatalf("delivered %v twice", ids)
+	}
+}
+
+func TestHubReplayAfterOutOfOrderIDs(t *testing.T) {
+	feed := newFakeFeed()
+	hub := newTestHub(feed)
+	ctx := context.Background()
+
+	// Более старая транзакция получает больший id: лента - 2, затем 1
+	older := feed.begin()
+	newer := feed.begin()
+	low := feed.insert(newer, 1)
+	high := feed.insert(older, 2)
+	feed.commit(newer)
+	feed.commit(older)
+
+	// Клиент получил только событие с большим id и продолжает ленту после него
+	replay, err := hub.Replay(ctx, high, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay) != 1 || replay[0].ID != low {
+		t.Fatalf("replay after %d = %v, want event %d", high, eventIDs(replay), low)
+	}
+
+	replay, err = hub.Replay(ctx, low, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay) != 0 {
+		t.Fatalf("replay after the last event = %v, want none", eventIDs(replay))
+	}
+}
+
+func TestHubReplayLimit(t *testing.T) {
+	feed := newFakeFeed()
+	hub := newTestHub(feed)
+	ctx := context.Background()
+
+	for task := int64(1); task <= 5; task++ {
+		tx := feed.begin()
+		feed.insert(tx, task)
+		feed.commit(tx)
+	}
+
+	tests := []struct {
+		name    string
+		afterID int64
+		filter  Filter
+		want    []int64
+	}{
+		{name: "after first", afterID: 1, want: []int64{3, 4, 5}},
+		{name: "after fourth", afterID: 4, want: []int64{5}},
+		{name: "unknown id", afterID: 99, want: []int64{3, 4, 5}},
+		{name: "filtered", afterID: 1, filter: Filter{TaskID: 4}, want: []int64{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, err := hub.Replay(ctx, tt.afterID, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := eventIDs(replay); !slices.Equal(got, tt.want) {
+				t.Fatalf("replay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHubPollsWithoutNotification(t *testing.T) {
+	feed := newFakeFeed()
+	hub := newTestHub(feed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor(t, func() bool { return hub.Check(ctx) == nil })
+	sub := hub.Subscribe(Filter{})
+
+	// Уведомление пришло, пока более старая транзакция не завершилась, и
+	// событие было невидимо. После ее фиксации уведомлений нет, событие
+	// доставляет опрос
+	older := feed.begin()
+	tx := feed.begin()
+	id := feed.insert(tx, 7)
+	feed.commit(tx)
+	feed.commit(older)
+
+	select {
+	case e := <-sub.C:
+		if e.ID != id {
+			t.Fatalf("delivered event %d, want %d", e.ID, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered by polling")
+	}
+}
+
+func TestHubStopClosesSubscriptions(t *testing.T) {
+	hub := newTestHub(newFakeFeed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run(ctx)
+	}()
+
+	sub := hub.Subscribe(Filter{})
+	cancel()
+	<-done
+
+	if _, ok := <-sub.C; ok {
+		t.Fatal("subscription is open after the hub stopped")
+	}
+	if err := hub.Check(context.Background()); err == nil {
+		t.Fatal("stopped hub reports ready")
+	}
+}
+
+func TestPositionLess(t *testing.T) {
+	tests := []struct {
+		a, b event.Position
+		want bool
+	}{
+		{event.Position{TxID: 1, ID: 9}, event.Position{TxID: 2, ID: 1}, true},
+		{event.Position{TxID: 2, ID: 1}, event.Position{TxID: 1, ID: 9}, false},
+		{event.Position{TxID: 1, ID: 1}, event.Position{TxID: 1, ID: 2}, true},
+		{event.Position{TxID: 1, ID: 2}, event.Position{TxID: 1, ID: 2}, false},
+		{event.Position{}, event.Position{TxID: 1, ID: 1}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Less(tt.b); got != tt.want {
+			t.Errorf("%+v.Less(%+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func eventIDs(events []event.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(errors.New("condition not met"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,246 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"restapi/internal/dto"
+	"restapi/internal/event"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	replayTimeout = 10 * time.Second
+)
+
+type service struct {
+	log       *zap.SugaredLogger
+	hub       *Hub
+	keepAlive time.Duration
+	ws        fiber.Handler
+}
+
+// Service - интерфейс потоковой выдачи событий задач
+type Service interface {
+	Stream(ctx *fiber.Ctx) error
+	WebSocket(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, hub *Hub) Service {
+	s := &service{
+		log:       log,
+		hub:       hub,
+		keepAlive: hub.cfg.KeepAlive,
+	}
+	s.ws = websocket.New(s.serveWebSocket)
+
+	return s
+}
+
+// Stream - отдает события задач через Server-Sent Events.
+// Клиент может продолжить ленту с заголовком Last-Event-ID
+func (s *service) Stream(ctx *fiber.Ctx) error {
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		s.log.Errorf("Invalid stream filter: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	lastID, err := lastEventID(ctx)
+	if err != nil {
+		s.log.Error("Invalid Last-Event-ID")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid Last-Event-ID")
+	}
+
+	// Подписка оформляется до чтения истории, чтобы не пропустить события между ними
+	sub := s.hub.Subscribe(filter)
+
+	replay, err := s.replay(lastID, filter)
+	if err != nil {
+		s.hub.Unsubscribe(sub)
+		s.log.Errorf("Error replaying events: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer s.hub.Unsubscribe(sub)
+
+		send := func(e event.Event) error {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			return w.Flush()
+		}
+
+		s.pump(sub, replay, send, func() error {
+			fmt.Fprint(w, ": ping\n\n")
+			return w.Flush()
+		}, nil)
+	})
+
+	return nil
+}
+
+// WebSocket - отдает события задач через WebSocket, позиция ленты передается
+// параметром last_event_id
+func (s *service) WebSocket(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "WebSocket upgrade required")
+	}
+
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		s.log.Errorf("Invalid stream filter: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldIncorrect, err.Error())
+	}
+
+	lastID, err := lastEventID(ctx)
+	if err != nil {
+		s.log.Error("Invalid last_event_id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid last_event_id")
+	}
+
+	ctx.Locals("filter", filter)
+	ctx.Locals("last_event_id", lastID)
+
+	return s.ws(ctx)
+}
+
+func (s *service) serveWebSocket(conn *websocket.Conn) {
+	filter, _ := conn.Locals("filter").(Filter)
+	lastID, _ := conn.Locals("last_event_id").(int64)
+
+	sub := s.hub.Subscribe(filter)
+	defer s.hub.Unsubscribe(sub)
+
+	replay, err := s.replay(lastID, filter)
+	if err != nil {
+		s.log.Errorf("Error replaying events: %v", zap.Error(err))
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "replay failed"))
+		return
+	}
+
+	// Входящие сообщения не ожидаются, чтение нужно только для обнаружения закрытия
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(e event.Event) error {
+		return conn.WriteJSON(e)
+	}
+
+	s.pump(sub, replay, send, func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.keepAlive))
+	}, closed)
+}
+
+// pump отправляет историю, затем новые события подписки до ошибки записи,
+// закрытия подписки или закрытия соединения
+func (s *service) pump(sub *Subscription, replay []event.Event, send func(event.Event) error, ping func() error, closed <-chan struct{}) {
+	var last event.Position
+	for _, e := range replay {
+		if err := send(e); err != nil {
+			return
+		}
+		last = e.Position
+	}
+
+	ticker := time.NewTicker(s.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			// Событие могло прийти и в истории, и в подписке
+			if !last.Less(e.Position) {
+				continue
+			}
+			if err := send(e); err != nil {
+				return
+			}
+			last = e.Position
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (s *service) replay(lastID int64, filter Filter) ([]event.Event, error) {
+	if lastID == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	defer cancel()
+
+	return s.hub.Replay(ctx, lastID, filter)
+}
+
+// parseFilter - разбирает фильтр из параметров status, event и task_id
+func parseFilter(ctx *fiber.Ctx) (Filter, error) {
+	var taskID int64
+	if value := ctx.Query("task_id"); value != "" {
+		var err error
+		if taskID, err = strconv.ParseInt(value, 10, 64); err != nil || taskID <= 0 {
+			return Filter{}, fmt.Errorf("invalid task_id %q", value)
+		}
+	}
+
+	return NewFilter(splitList(ctx.Query("status")), splitList(ctx.Query("event")), taskID)
+}
+
+// splitList - разбирает список значений через запятую
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
+}
+
+// lastEventID - возвращает позицию ленты из заголовка Last-Event-ID или параметра last_event_id
+func lastEventID(ctx *fiber.Ctx) (int64, error) {
+	value := ctx.Get("Last-Event-ID")
+	if value == "" {
+		value = ctx.Query("last_event_id")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
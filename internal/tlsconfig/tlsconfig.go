@@ -0,0 +1,130 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"restapi/internal/config"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Certificate - сертификат сервера, который перечитывается при изменении файлов,
+// чтобы продлить его без перезапуска
+type Certificate struct {
+	log      *zap.SugaredLogger
+	cfg      config.TLS
+	cert     atomic.Pointer[tls.Certificate]
+	modified time.Time
+}
+
+// NewCertificate загружает сертификат и ключ из TLS_CERT_FILE и TLS_KEY_FILE
+func NewCertificate(log *zap.SugaredLogger, cfg config.TLS) (*Certificate, error) {
+	c := &Certificate{
+		log: log,
+		cfg: cfg,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// load читает пару сертификат-ключ и запоминает время изменения файлов
+func (c *Certificate) load() error {
+	modified, err := c.modTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "error loading TLS certificate")
+	}
+
+	c.cert.Store(&cert)
+	c.modified = modified
+
+	return nil
+}
+
+// modTime - время последнего изменения сертификата или ключа
+func (c *Certificate) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.cfg.CertFile, c.cfg.KeyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "error reading TLS certificate")
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// GetCertificate - текущий сертификат для tls.Config
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Run перечитывает сертификат при изменении файлов до отмены контекста.
+// Если новая пара не загружается, остается прежний сертификат
+func (c *Certificate) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modified, err := c.modTime()
+			if err != nil {
+				c.log.Errorf("Error checking TLS certificate: %v", err)
+				continue
+			}
+			if !modified.After(c.modified) {
+				continue
+			}
+
+			if err := c.load(); err != nil {
+				c.log.Errorf("Error reloading TLS certificate, keeping current: %v", err)
+				continue
+			}
+			c.log.Info("TLS certificate reloaded")
+		}
+	}
+}
+
+// Config собирает tls.Config с сертификатом cert. Если задан TLS_CLIENT_CA_FILE,
+// клиенты должны предъявить сертификат, подписанный этим УЦ
+func Config(cert *Certificate, cfg config.TLS) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cert.GetCertificate,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading TLS client CA")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,260 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"restapi/internal/config"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeCert создает самоподписанный сертификат с номером serial и записывает пару в файлы cfg.
+// Время изменения файлов сдвигается на age, чтобы замену было видно без ожидания
+func writeCert(t *testing.T, cfg config.TLS, serial int64, age time.Duration) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	writeFile(t, cfg.CertFile, certPEM, age)
+	writeFile(t, cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), age)
+
+	return certPEM
+}
+
+func writeFile(t *testing.T, file string, data []byte, age time.Duration) {
+	t.Helper()
+
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Now().Add(age)
+	if err := os.Chtimes(file, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testConfig(t *testing.T) config.TLS {
+	dir := t.TempDir()
+	return config.TLS{
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		ReloadInterval: 5 * time.Millisecond,
+	}
+}
+
+// serial - номер текущего сертификата
+func serial(t *testing.T, c *Certificate) int64 {
+	t.Helper()
+
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
+
+// waitSerial ждет, пока сертификат сменится на serial
+func waitSerial(t *testing.T, c *Certificate, want int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for serial(t, c) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("serial = %d, want %d", serial(t, c), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	cfg := testConfig(t)
+	writeCert(t, cfg, 1, -time.Hour)
+
+	c, err := NewCertificate(zap.NewNop().Sugar(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := serial(t, c); got != 1 {
+		t.Fatalf("serial = %d, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Продленный сертификат подхватывается без перезапуска
+	writeCert(t, cfg, 2, -time.Minute)
+	waitSerial(t, c, 2)
+
+	// Битая пара не заменяет рабочий сертификат
+	writeFile(t, cfg.CertFile, []byte("not a certificate"), 0)
+	time.Sleep(20 * cfg.ReloadInterval)
+	if got := serial(t, c); got != 2 {
+		t.Errorf("serial after broken update = %d, want 2", got)
+	}
+
+	// Исправленная пара загружается при следующей проверке
+	writeCert(t, cfg, 3, time.Minute)
+	waitSerial(t, c, 3)
+}
+
+func TestCertificateHandshake(t *testing.T) {
+	cfg := testConfig(t)
+	writeCert(t, cfg, 1, -time.Hour)
+
+	c, err := NewCertificate(zap.NewNop().Sugar(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsCfg, err := Config(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	// peerSerial - номер сертификата, который сервер предъявил при рукопожатии
+	peerSerial := func() int64 {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if got := peerSerial(); got != 1 {
+		t.Fatalf("peer serial = %d, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go c.Run(ctx)
+
+	writeCert(t, cfg, 2, -time.Minute)
+	waitSerial(t, c, 2)
+	if got := peerSerial(); got != 2 {
+		t.Errorf("peer serial after reload = %d, want 2", got)
+	}
+}
+
+func TestNewCertificateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, cfg config.TLS)
+	}{
+		{name: "missing files", write: func(t *testing.T, cfg config.TLS) {}},
+		{name: "invalid pair", write: func(t *testing.T, cfg config.TLS) {
+			writeFile(t, cfg.CertFile, []byte("not a certificate"), 0)
+			writeFile(t, cfg.KeyFile, []byte("not a key"), 0)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			tt.write(t, cfg)
+
+			if _, err := NewCertificate(zap.NewNop().Sugar(), cfg); err == nil {
+				t.Error("NewCertificate = nil, want error")
+			}
+		})
+	}
+}
+
+func TestConfigClientCA(t *testing.T) {
+	cfg := testConfig(t)
+	caPEM := writeCert(t, cfg, 1, 0)
+
+	c, err := NewCertificate(zap.NewNop().Sugar(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ca       []byte
+		wantAuth tls.ClientAuthType
+		wantErr  bool
+	}{
+		{name: "without client CA", wantAuth: tls.NoClientCert},
+		{name: "client CA", ca: caPEM, wantAuth: tls.RequireAndVerifyClientCert},
+		{name: "no certificates in CA file", ca: []byte("garbage"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsCfg := cfg
+			if tt.ca != nil {
+				tlsCfg.ClientCAFile = filepath.Join(t.TempDir(), "ca.crt")
+				writeFile(t, tlsCfg.ClientCAFile, tt.ca, 0)
+			}
+
+			got, err := Config(c, tlsCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (got.ClientAuth != tt.wantAuth || got.MinVersion != tls.VersionTLS12) {
+				t.Errorf("ClientAuth = %v, MinVersion = %x, want %v and TLS 1.2", got.ClientAuth, got.MinVersion, tt.wantAuth)
+			}
+		})
+	}
+}
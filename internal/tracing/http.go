@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"errors"
+	"restapi/internal/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// headerCarrier - заголовки запроса fasthttp для propagation.TextMapCarrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
+
+// Middleware продолжает трассу из заголовка traceparent или начинает новую.
+// Идентификатор запроса из ответа (X-Request-ID) попадает в атрибут request_id.
+// Контекст со спаном и логгер запроса с trace_id передаются обработчикам через UserContext
+func Middleware(log *zap.SugaredLogger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), headerCarrier{header: &ctx.Request().Header})
+
+		// Строки Fiber ссылаются на буфер запроса, а спан отправляется позже
+		method := utils.CopyString(ctx.Method())
+		self := ctx.Route()
+
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(utils.CopyString(ctx.Path())),
+		}
+		if id := ctx.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+			attrs = append(attrs, attribute.String("request_id", utils.CopyString(id)))
+		}
+
+		spanCtx, span := Tracer().Start(parent, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		reqLog := logger.FromContext(ctx.UserContext(), log)
+		ctx.SetUserContext(logger.WithContext(spanCtx, Logger(spanCtx, reqLog)))
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		if route := ctx.Route(); route != self {
+			span.SetName(method + " " + route.Path)
+			span.SetAttributes(semconv.HTTPRoute(route.Path))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+
+		return err
+	}
+}
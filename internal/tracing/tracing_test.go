@@ -0,0 +1,188 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"restapi/internal/api/middleware"
+	"restapi/internal/config"
+	"restapi/internal/service"
+	"restapi/internal/tracing"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID = "00f067aa0ba902b7"
+)
+
+// queryService - TaskService, который выполняет "запрос" через трейсер pgx,
+// как хранилище на настоящем пуле
+type queryService struct {
+	service.TaskService
+}
+
+func (s queryService) GetTask(ctx context.Context, id int64) (service.Task, error) {
+	tracer := tracing.NewQueryTracer()
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT * FROM tasks WHERE id = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	return service.Task{ID: id}, nil
+}
+
+// setupExporter устанавливает провайдер с экспортом в память на время теста
+// и возвращает функцию чтения записанных спанов
+func setupExporter(t *testing.T) func() tracetest.SpanStubs {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(config.Tracing{SampleRatio: 1, ServiceName: "restapi-test"}, exporter)
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return func() tracetest.SpanStubs {
+		if err := provider.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return exporter.GetSpans()
+	}
+}
+
+func newTestApp() *fiber.App {
+	log := zap.NewNop().Sugar()
+	svc := service.WithTracing(queryService{})
+
+	app := fiber.New()
+	app.Use(middleware.RequestLogger(log, func(string, string) (string, bool) { return "", false }))
+	app.Use(tracing.Middleware(log))
+	app.Get("/v1/tasks/:id", func(ctx *fiber.Ctx) error {
+		task, err := svc.GetTask(ctx.UserContext(), 7)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(task)
+	})
+
+	return app
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func TestSpansFromRequestToQuery(t *testing.T) {
+	spans := setupExporter(t)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/v1/tasks/7", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	req.Header.Set(fiber.HeaderXRequestID, "req-42")
+
+	resp, err := newTestApp().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	all := spans()
+	if len(all) != 3 {
+		t.Fatalf("spans = %d, want HTTP, service and query", len(all))
+	}
+	httpSpan := findSpan(t, all, "GET /v1/tasks/:id")
+	serviceSpan := findSpan(t, all, "TaskService.GetTask")
+	querySpan := findSpan(t, all, "SELECT")
+
+	// Трасса продолжается из traceparent
+	if got := httpSpan.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("trace id = %s, want %s", got, traceID)
+	}
+	if got := httpSpan.Parent.SpanID().String(); got != parentSpanID || !httpSpan.Parent.IsRemote() {
+		t.Errorf("HTTP span parent = %s, want remote %s", got, parentSpanID)
+	}
+	if httpSpan.SpanKind != trace.SpanKindServer {
+		t.Errorf("HTTP span kind = %v, want server", httpSpan.SpanKind)
+	}
+
+	// HTTP -> TaskService -> запрос к БД
+	links := []struct {
+		child, parent tracetest.SpanStub
+	}{
+		{serviceSpan, httpSpan},
+		{querySpan, serviceSpan},
+	}
+	for _, link := range links {
+		if link.child.Parent.SpanID() != link.parent.SpanContext.SpanID() {
+			t.Errorf("parent of %q = %s, want %q (%s)", link.child.Name, link.child.Parent.SpanID(),
+				link.parent.Name, link.parent.SpanContext.SpanID())
+		}
+		if link.child.SpanContext.TraceID() != httpSpan.SpanContext.TraceID() {
+			t.Errorf("span %q is in another trace", link.child.Name)
+		}
+	}
+
+	if v, ok := attr(httpSpan, "request_id"); !ok || v.AsString() != "req-42" {
+		t.Errorf("request_id = %q, want req-42", v.AsString())
+	}
+	if v, _ := attr(httpSpan, "http.route"); v.AsString() != "/v1/tasks/:id" {
+		t.Errorf("http.route = %q", v.AsString())
+	}
+	if v, _ := attr(httpSpan, "http.response.status_code"); v.AsInt64() != fiber.StatusOK {
+		t.Errorf("status code = %d, want 200", v.AsInt64())
+	}
+	if v, _ := attr(querySpan, "db.query.text"); v.AsString() != "SELECT * FROM tasks WHERE id = $1" {
+		t.Errorf("db.query.text = %q", v.AsString())
+	}
+}
+
+func TestGeneratedRequestIDOnSpan(t *testing.T) {
+	spans := setupExporter(t)
+
+	resp, err := newTestApp().Test(httptest.NewRequest(fiber.MethodGet, "/v1/tasks/7", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	httpSpan := findSpan(t, spans(), "GET /v1/tasks/:id")
+	if httpSpan.Parent.IsValid() {
+		t.Errorf("HTTP span without traceparent has parent %s", httpSpan.Parent.SpanID())
+	}
+
+	id := resp.Header.Get(fiber.HeaderXRequestID)
+	if v, _ := attr(httpSpan, "request_id"); id == "" || v.AsString() != id {
+		t.Errorf("request_id = %q, want response X-Request-ID %q", v.AsString(), id)
+	}
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"restapi/internal/config"
+	"restapi/internal/event"
+	"restapi/internal/health"
+	"restapi/internal/metrics"
+	"restapi/internal/repo/db"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	userAgent    = "restapi-webhooks/1.0"
+	maxErrorBody = 512
+)
+
+// Dispatcher ставит события в очередь доставки и отправляет их подписчикам
+type Dispatcher struct {
+	log    *zap.SugaredLogger
+	repo   db.WebhookRepository
+	client *http.Client
+	cfg    config.Webhook
+	beat   *health.Heartbeat
+}
+
+// NewDispatcher создает диспетчер вебхуков
+func NewDispatcher(log *zap.SugaredLogger, repo db.WebhookRepository, cfg config.Webhook) *Dispatcher {
+	return &Dispatcher{
+		log:    log,
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		beat:   health.NewHeartbeat(cfg.PollInterval),
+	}
+}
+
+// Check - проверка живости: очередь доставок разбирается
+func (d *Dispatcher) Check(ctx context.Context) error {
+	return d.beat.Check(ctx)
+}
+
+// Publish ставит событие в очередь доставки всем активным подписчикам
+func (d *Dispatcher) Publish(ctx context.Context, e event.Event) error {
+	hooks, err := d.repo.GetWebhooksByEvent(ctx, e.Type)
+	if err != nil {
+		return errors.Wrap(err, "failed to get subscribers")
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	for _, hook := range hooks {
+		if err := d.repo.CreateDelivery(ctx, hook.ID, e.ID, e.Type, payload); err != nil {
+			return errors.Wrapf(err, "failed to enqueue delivery for webhook %d", hook.ID)
+		}
+	}
+
+	return nil
+}
+
+// Run обрабатывает очередь доставок до отмены контекста
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.beat.Beat()
+		d.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process отправляет до BatchSize готовых доставок. Доставки забираются по одной:
+// аренда покрывает только текущую отправку, и остальные доставки не повторятся
+// у другого экземпляра, пока эта ждет ответа подписчика
+func (d *Dispatcher) process(ctx context.Context) {
+	lease := d.cfg.Timeout + d.cfg.PollInterval
+
+	hooks := make(map[int64]*db.Webhook)
+	for i := 0; i < d.cfg.BatchSize && ctx.Err() == nil; i++ {
+		deliveries, err := d.repo.ClaimDeliveries(ctx, 1, lease)
+		if err != nil {
+			d.log.Errorf("Error claiming webhook deliveries: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		delivery := deliveries[0]
+
+		hook, ok := hooks[delivery.WebhookID]
+		if !ok {
+			hook, err = d.repo.GetWebhook(ctx, delivery.WebhookID)
+			if err != nil {
+				d.skip(ctx, delivery, err)
+				continue
+			}
+			hooks[hook.ID] = hook
+		}
+
+		d.beat.Beat()
+		d.deliver(ctx, hook, delivery)
+	}
+}
+
+// deliver отправляет доставку и сохраняет результат
+func (d *Dispatcher) deliver(ctx context.Context, hook *db.Webhook, delivery *db.WebhookDelivery) {
+	code, err := d.send(ctx, hook, delivery)
+	if err == nil {
+		metrics.ObserveDelivery("success")
+		if err := d.repo.SucceedDelivery(ctx, delivery.ID, code); err != nil {
+			d.log.Errorf("Error saving webhook delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	d.log.Warnf("Webhook delivery %d attempt %d failed: %v", delivery.ID, delivery.Attempts+1, err)
+	d.retry(ctx, delivery, code, err)
+}
+
+// skip сохраняет доставку, подписку которой не удалось прочитать. Удаленная подписка
+// означает отказ, ошибка базы - повтор с задержкой, как при ошибке отправки
+func (d *Dispatcher) skip(ctx context.Context, delivery *db.WebhookDelivery, err error) {
+	if !errors.Is(err, db.ErrNotFound) {
+		d.log.Errorf("Error getting webhook %d: %v", delivery.WebhookID, err)
+		d.retry(ctx, delivery, 0, err)
+		return
+	}
+
+	d.log.Warnf("Webhook %d of delivery %d not found, delivery failed", delivery.WebhookID, delivery.ID)
+	metrics.ObserveDelivery("failed")
+	if err := d.repo.FailDelivery(ctx, delivery.ID, 0, "webhook not found"); err != nil {
+		d.log.Errorf("Error saving webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retry сохраняет неудачную попытку: повтор через backoff или отказ после MaxAttempts
+func (d *Dispatcher) retry(ctx context.Context, delivery *db.WebhookDelivery, code int, cause error) {
+	attempts := delivery.Attempts + 1
+
+	var err error
+	if attempts >= d.cfg.MaxAttempts {
+		metrics.ObserveDelivery("failed")
+		err = d.repo.FailDelivery(ctx, delivery.ID, code, cause.Error())
+	} else {
+		metrics.ObserveDelivery("retry")
+		err = d.repo.RetryDelivery(ctx, delivery.ID, code, cause.Error(), time.Now().Add(d.backoff(attempts)))
+	}
+	if err != nil {
+		d.log.Errorf("Error saving webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send выполняет HTTP-запрос к подписчику
+func (d *Dispatcher) send(ctx context.Context, hook *db.Webhook, delivery *db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build request")
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff возвращает задержку перед следующей попыткой: base * 2^(attempt-1), не больше max
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+
+	return delay
+}
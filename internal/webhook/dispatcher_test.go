@@ -0,0 +1,327 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"restapi/internal/config"
+	"restapi/internal/repo/db"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fakeRepo - очередь доставок в памяти. Методы подписок, которые не нужны
+// диспетчеру, не реализованы
+type fakeRepo struct {
+	db.WebhookRepository
+
+	mu   sync.Mutex
+	hook db.Webhook
+	// hookErr - ошибка чтения подписки
+	hookErr    error
+	deliveries []*db.WebhookDelivery
+	// log - порядок вызовов: claim:<limit>, succeed, retry, fail
+	log []string
+}
+
+func (r *fakeRepo) GetWebhook(ctx context.Context, id int64) (*db.Webhook, error) {
+	if r.hookErr != nil {
+		return nil, r.hookErr
+	}
+	hook := r.hook
+	return &hook, nil
+}
+
+func (r *fakeRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*db.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.log = append(r.log, "claim:"+strconv.Itoa(limit))
+
+	var claimed []*db.WebhookDelivery
+	for _, d := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == db.DeliveryPending && !d.NextAttemptAt.After(time.Now()) {
+			d.NextAttemptAt = time.Now().Add(lease)
+			copied := *d
+			claimed = append(claimed, &copied)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *fakeRepo) SucceedDelivery(ctx context.Context, id int64, code int) error {
+	return r.update(id, "succeed", func(d *db.WebhookDelivery) {
+		d.Status, d.ResponseCode = db.DeliverySucceeded, code
+	})
+}
+
+func (r *fakeRepo) RetryDelivery(ctx context.Context, id int64, code int, lastError string, next time.Time) error {
+	return r.update(id, "retry", func(d *db.WebhookDelivery) {
+		d.ResponseCode, d.LastError, d.NextAttemptAt = code, lastError, next
+	})
+}
+
+func (r *fakeRepo) FailDelivery(ctx context.Context, id int64, code int, lastError string) error {
+	return r.update(id, "fail", func(d *db.WebhookDelivery) {
+		d.Status, d.ResponseCode, d.LastError = db.DeliveryFailed, code, lastError
+	})
+}
+
+func (r *fakeRepo) update(id int64, op string, apply func(d *db.WebhookDelivery)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.log = append(r.log, op)
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			d.Attempts++
+			apply(d)
+			return nil
+		}
+	}
+
+	return db.ErrNotFound
+}
+
+// due делает все отложенные доставки готовыми к отправке
+func (r *fakeRepo) due() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		d.NextAttemptAt = time.Time{}
+	}
+}
+
+func newTestDispatcher(repo *fakeRepo) *Dispatcher {
+	return NewDispatcher(zap.NewNop().Sugar(), repo, config.Webhook{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		BatchSize:    50,
+		MaxAttempts:  3,
+		BaseBackoff:  time.Minute,
+		MaxBackoff:   time.Hour,
+	})
+}
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	const secret = "s3cret"
+	payload := []byte(`{"id":7,"type":"task.created"}`)
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || !Verify(secret, timestamp, body, r.Header.Get(HeaderSignature)) {
+			t.Errorf("invalid signature %q for body %s", r.Header.Get(HeaderSignature), body)
+		}
+		if got := r.Header.Get(HeaderEvent); got != "task.created" {
+			t.Errorf("event header = %q, want task.created", got)
+		}
+		if got := r.Header.Get(HeaderDelivery); got != "1" {
+			t.Errorf("delivery header = %q, want 1", got)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &fakeRepo{
+		hook: db.Webhook{ID: 3, URL: receiver.URL, Secret: secret, Active: true},
+		deliveries: []*db.WebhookDelivery{
+			{ID: 1, WebhookID: 3, Event: "task.created", Payload: payload, Status: db.DeliveryPending},
+		},
+	}
+	d := newTestDispatcher(repo)
+	delivery := repo.deliveries[0]
+
+	start := time.Now()
+	d.process(context.Background())
+
+	if delivery.Status != db.DeliveryPending || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusServiceUnavailable {
+		t.Fatalf("after failure: status %s, attempts %d, code %d", delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+	if delay := delivery.NextAttemptAt.Sub(start); delay < time.Minute || delay > time.Minute+time.Second {
+		t.Errorf("retry delay = %v, want base backoff 1m", delay)
+	}
+
+	// До срока повтора доставка не отправляется
+	d.process(context.Background())
+	if requests != 1 {
+		t.Fatalf("requests before retry time = %d, want 1", requests)
+	}
+
+	repo.due()
+	d.process(context.Background())
+
+	if delivery.Status != db.DeliverySucceeded || delivery.Attempts != 2 || delivery.ResponseCode != http.StatusNoContent {
+		t.Fatalf("after retry: status %s, attempts %d, code %d", delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestDispatcherFailsAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := &fakeRepo{
+		hook: db.Webhook{ID: 3, URL: receiver.URL, Secret: "s", Active: true},
+		deliveries: []*db.WebhookDelivery{
+			{ID: 1, WebhookID: 3, Event: "task.deleted", Payload: []byte(`{}`), Status: db.DeliveryPending},
+		},
+	}
+	d := newTestDispatcher(repo)
+
+	for range 3 {
+		repo.due()
+		d.process(context.Background())
+	}
+
+	delivery := repo.deliveries[0]
+	if delivery.Status != db.DeliveryFailed || delivery.Attempts != 3 {
+		t.Fatalf("status %s, attempts %d, want failed after 3", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastError == "" {
+		t.Error("last error is empty")
+	}
+}
+
+func TestDispatcherClaimsOneDeliveryAtATime(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	repo := &fakeRepo{hook: db.Webhook{ID: 3, URL: receiver.URL, Secret: "s", Active: true}}
+	for id := int64(1); id <= 3; id++ {
+		repo.deliveries = append(repo.deliveries, &db.WebhookDelivery{
+			ID: id, WebhookID: 3, Event: "task.updated", Payload: []byte(`{}`), Status: db.DeliveryPending,
+		})
+	}
+
+	newTestDispatcher(repo).process(context.Background())
+
+	// Следующая доставка забирается только после отправки предыдущей, иначе
+	// ее аренда истекает, пока ждут остальные
+	want := []string{"claim:1", "succeed", "claim:1", "succeed", "claim:1", "succeed", "claim:1"}
+	if len(repo.log) != len(want) {
+		t.Fatalf("calls = %v, want %v", repo.log, want)
+	}
+	for i := range want {
+		if repo.log[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", repo.log, want)
+		}
+	}
+}
+
+func TestDispatcherWebhookErrors(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name         string
+		hookErr      error
+		attempts     int
+		wantCall     string
+		wantStatus   string
+		wantRetry    bool
+		wantLastErr  string
+		wantDelivery bool
+	}{
+		{
+			name: "deleted webhook fails delivery", hookErr: errors.Wrap(db.ErrNotFound, "webhook not found"),
+			wantCall: "fail", wantStatus: db.DeliveryFailed, wantLastErr: "webhook not found",
+		},
+		{
+			name: "database error retries with backoff", hookErr: errors.New("connection refused"),
+			wantCall: "retry", wantStatus: db.DeliveryPending, wantRetry: true, wantLastErr: "connection refused", wantDelivery: true,
+		},
+		{
+			name: "database error on last attempt fails", hookErr: errors.New("connection refused"), attempts: 2,
+			wantCall: "fail", wantStatus: db.DeliveryFailed, wantLastErr: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{
+				hook:    db.Webhook{ID: 3, URL: receiver.URL, Secret: "s", Active: true},
+				hookErr: tt.hookErr,
+				deliveries: []*db.WebhookDelivery{
+					{ID: 1, WebhookID: 3, Event: "task.updated", Payload: []byte(`{}`), Status: db.DeliveryPending, Attempts: tt.attempts},
+				},
+			}
+			d := newTestDispatcher(repo)
+			delivery := repo.deliveries[0]
+
+			start := time.Now()
+			d.process(context.Background())
+
+			if want := []string{"claim:1", tt.wantCall, "claim:1"}; !slices.Equal(repo.log, want) {
+				t.Fatalf("calls = %v, want %v", repo.log, want)
+			}
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.attempts+1 || delivery.LastError != tt.wantLastErr {
+				t.Fatalf("status %s, attempts %d, last error %q, want %s, %d, %q",
+					delivery.Status, delivery.Attempts, delivery.LastError, tt.wantStatus, tt.attempts+1, tt.wantLastErr)
+			}
+			if delay := delivery.NextAttemptAt.Sub(start); tt.wantRetry && (delay < time.Minute || delay > time.Minute+time.Second) {
+				t.Errorf("retry delay = %v, want base backoff 1m", delay)
+			}
+
+			// Когда база снова доступна, отложенная доставка отправляется
+			repo.hookErr = nil
+			repo.due()
+			d.process(context.Background())
+			if delivered := delivery.Status == db.DeliverySucceeded; delivered != tt.wantDelivery {
+				t.Errorf("status after recovery = %s, want delivered %v", delivery.Status, tt.wantDelivery)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := newTestDispatcher(&fakeRepo{})
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
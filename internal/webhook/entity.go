@@ -0,0 +1,22 @@
+package webhook
+
+// WebhookRequest - запрос на создание подписки
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=task.created task.updated task.status_changed task.deleted"`
+	Active *bool    `json:"active"`
+}
+
+// UpdateWebhookRequest - запрос на обновление подписки
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=task.created task.updated task.status_changed task.deleted"`
+	Active bool     `json:"active"`
+}
+
+// CreatedWebhook - ответ на создание подписки
+type CreatedWebhook struct {
+	ID     int64  `json:"id"`
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	deliveriesLimit int = 20
+)
+
+type service struct {
+	log  *zap.SugaredLogger
+	repo db.WebhookRepository
+}
+
+// Service - интерфейс управления вебхуками
+type Service interface {
+	CreateWebhook(ctx *fiber.Ctx) error
+	GetWebhook(ctx *fiber.Ctx) error
+	GetWebhooks(ctx *fiber.Ctx) error
+	UpdateWebhook(ctx *fiber.Ctx) error
+	DeleteWebhook(ctx *fiber.Ctx) error
+	GetDeliveries(ctx *fiber.Ctx) error
+	Redeliver(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, repo db.WebhookRepository) Service {
+	return &service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (s *service) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), s.log)
+}
+
+// CreateWebhook - создает подписку, секрет подписи возвращается только в ответе на создание
+func (s *service) CreateWebhook(ctx *fiber.Ctx) error {
+	var req WebhookRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			s.logger(ctx).Errorf("Error generating webhook secret: %v", zap.Error(err))
+			return dto.InternalServerError(ctx)
+		}
+	}
+
+	hook := db.Webhook{
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: req.Active == nil || *req.Active,
+	}
+
+	id, err := s.repo.CreateWebhook(ctx.UserContext(), hook)
+	if err != nil {
+		s.logger(ctx).Errorf("Error creating webhook: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   CreatedWebhook{ID: id, Secret: secret},
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(responce)
+}
+
+// GetWebhook - возвращает подписку по id
+func (s *service) GetWebhook(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid webhook id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid webhook id")
+	}
+
+	hook, err := s.repo.GetWebhook(ctx.UserContext(), int64(id))
+	if err != nil {
+		return s.repoError(ctx, err, "Error getting webhook", "Webhook not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   hook,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetWebhooks - возвращает все подписки
+func (s *service) GetWebhooks(ctx *fiber.Ctx) error {
+	hooks, err := s.repo.GetWebhooks(ctx.UserContext())
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting webhooks: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   hooks,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// UpdateWebhook - обновляет подписку
+func (s *service) UpdateWebhook(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid webhook id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid webhook id")
+	}
+
+	var req UpdateWebhookRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return dto.InvalidRequestError(ctx, err)
+	}
+
+	hook := db.Webhook{
+		ID:     int64(id),
+		URL:    req.URL,
+		Events: req.Events,
+		Active: req.Active,
+	}
+
+	if err := s.repo.UpdateWebhook(ctx.UserContext(), hook); err != nil {
+		return s.repoError(ctx, err, "Error updating webhook", "Webhook not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteWebhook - удаляет подписку
+func (s *service) DeleteWebhook(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid webhook id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid webhook id")
+	}
+
+	if err := s.repo.DeleteWebhook(ctx.UserContext(), int64(id)); err != nil {
+		return s.repoError(ctx, err, "Error deleting webhook", "Webhook not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetDeliveries - возвращает журнал доставок подписки с пагинацией
+func (s *service) GetDeliveries(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid webhook id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid webhook id")
+	}
+
+	page := ctx.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * deliveriesLimit
+
+	deliveries, err := s.repo.GetDeliveries(ctx.UserContext(), int64(id), deliveriesLimit, offset)
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting deliveries: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   deliveries,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// Redeliver - возвращает доставку в очередь для повторной отправки
+func (s *service) Redeliver(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid webhook id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid webhook id")
+	}
+
+	deliveryID, err := ctx.ParamsInt("delivery_id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid delivery id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid delivery id")
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx.UserContext(), int64(deliveryID))
+	if err != nil {
+		return s.repoError(ctx, err, "Error getting delivery", "Delivery not found")
+	}
+
+	if delivery.WebhookID != int64(id) {
+		return dto.NotFoundError(ctx, "Delivery not found")
+	}
+
+	if err := s.repo.Redeliver(ctx.UserContext(), delivery.ID); err != nil {
+		return s.repoError(ctx, err, "Error redelivering", "Delivery not found")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(responce)
+}
+
+// repoError - преобразует ошибку репозитория в ответ
+func (s *service) repoError(ctx *fiber.Ctx, err error, msg, notFound string) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return dto.NotFoundError(ctx, notFound)
+	}
+
+	s.logger(ctx).Errorf("%s: %v", msg, zap.Error(err))
+	return dto.InternalServerError(ctx)
+}
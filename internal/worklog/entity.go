@@ -0,0 +1,37 @@
+package worklog
+
+import (
+	"restapi/internal/repo/db"
+	"time"
+)
+
+// StartRequest - запрос на запуск таймера
+type StartRequest struct {
+	// UserID - кто работает над задачей, идентификатор пользователя во внешней системе
+	UserID      string `json:"user_id" validate:"required,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+}
+
+// StopRequest - запрос на остановку таймера
+type StopRequest struct {
+	UserID string `json:"user_id" validate:"required,max=200"`
+}
+
+// WorklogRequest - запись о работе за период [started_at, ended_at)
+type WorklogRequest struct {
+	UserID      string     `json:"user_id" validate:"required,max=200"`
+	StartedAt   *time.Time `json:"started_at" validate:"required"`
+	EndedAt     *time.Time `json:"ended_at" validate:"required"`
+	Description string     `json:"description" validate:"max=2000"`
+}
+
+// Report - отчет по учету времени
+type Report struct {
+	GroupBy  string     `json:"group_by"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	Timezone string     `json:"timezone"`
+	// TotalSeconds - время по всем строкам отчета
+	TotalSeconds int64             `json:"total_seconds"`
+	Rows         []db.WorklogTotal `json:"rows"`
+}
@@ -0,0 +1,337 @@
+package worklog
+
+import (
+	"restapi/internal/api/middleware"
+	"restapi/internal/dto"
+	"restapi/internal/logger"
+	"restapi/internal/repo/db"
+	"restapi/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	maxUserID       int = 200
+	defaultTimezone     = "UTC"
+)
+
+type service struct {
+	log  *zap.SugaredLogger
+	repo db.WorklogRepository
+}
+
+// Service - интерфейс учета времени по задачам
+type Service interface {
+	StartWorklog(ctx *fiber.Ctx) error
+	StopWorklog(ctx *fiber.Ctx) error
+	CreateWorklog(ctx *fiber.Ctx) error
+	GetWorklogs(ctx *fiber.Ctx) error
+	DeleteWorklog(ctx *fiber.Ctx) error
+	GetReport(ctx *fiber.Ctx) error
+}
+
+func NewService(log *zap.SugaredLogger, repo db.WorklogRepository) Service {
+	return &service{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger - логгер запроса с trace_id, если он есть в контексте
+func (s *service) logger(ctx *fiber.Ctx) *zap.SugaredLogger {
+	return logger.FromContext(ctx.UserContext(), s.log)
+}
+
+// StartWorklog - запускает таймер пользователя по задаче
+func (s *service) StartWorklog(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req StartRequest
+
+	if ok, err := s.parseBody(ctx, &req); !ok {
+		return err
+	}
+	if ok, err := s.checkUser(ctx, req.UserID); !ok {
+		return err
+	}
+
+	worklog, err := s.repo.StartWorklog(ctx.UserContext(), int64(id), req.UserID, req.Description)
+	if err != nil {
+		return s.repoError(ctx, err, "Error starting timer", "Task not found", "User already has a running timer")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(responce)
+}
+
+// StopWorklog - останавливает таймер пользователя по задаче
+func (s *service) StopWorklog(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req StopRequest
+
+	if ok, err := s.parseBody(ctx, &req); !ok {
+		return err
+	}
+	if ok, err := s.checkUser(ctx, req.UserID); !ok {
+		return err
+	}
+
+	worklog, err := s.repo.StopWorklog(ctx.UserContext(), int64(id), req.UserID)
+	if err != nil {
+		return s.repoError(ctx, err, "Error stopping timer", "Running timer not found", "")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// CreateWorklog - добавляет запись о работе за период
+func (s *service) CreateWorklog(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	var req WorklogRequest
+
+	if ok, err := s.parseBody(ctx, &req); !ok {
+		return err
+	}
+	if ok, err := s.checkUser(ctx, req.UserID); !ok {
+		return err
+	}
+
+	if !req.EndedAt.After(*req.StartedAt) {
+		s.logger(ctx).Error("Invalid worklog: ended_at is not after started_at")
+		return dto.ValidationError(ctx, "Invalid request body", []dto.FieldError{{
+			In:      "body",
+			Field:   "ended_at",
+			Rule:    "gtfield",
+			Param:   "started_at",
+			Message: "ended_at must be after started_at",
+		}})
+	}
+
+	worklog, err := s.repo.CreateWorklog(ctx.UserContext(), db.Worklog{
+		TaskID:      int64(id),
+		UserID:      req.UserID,
+		StartedAt:   *req.StartedAt,
+		EndedAt:     req.EndedAt,
+		Description: req.Description,
+	})
+	if err != nil {
+		return s.repoError(ctx, err, "Error creating worklog", "Task not found", "Worklog overlaps another worklog of the user")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   worklog,
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(responce)
+}
+
+// GetWorklogs - возвращает записи учета времени по задаче
+func (s *service) GetWorklogs(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	worklogs, err := s.repo.GetWorklogs(ctx.UserContext(), int64(id))
+	if err != nil {
+		s.logger(ctx).Errorf("Error getting worklogs: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   worklogs,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// DeleteWorklog - удаляет запись учета времени
+func (s *service) DeleteWorklog(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid task id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid task id")
+	}
+
+	worklogID, err := ctx.ParamsInt("worklog_id")
+	if err != nil {
+		s.logger(ctx).Error("Invalid worklog id")
+		return dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid worklog id")
+	}
+
+	// С ключом API удаляются только записи его пользователя
+	if err := s.repo.DeleteWorklog(ctx.UserContext(), int64(id), int64(worklogID), middleware.UserID(ctx)); err != nil {
+		return s.repoError(ctx, err, "Error deleting worklog", "Worklog not found", "")
+	}
+
+	responce := dto.Response{
+		Status: "success",
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// GetReport - возвращает суммарное время по пользователям, задачам, статусам или дням за период
+func (s *service) GetReport(ctx *fiber.Ctx) error {
+	report := Report{
+		GroupBy:  ctx.Query("group_by", db.WorklogByUser),
+		Timezone: ctx.Query("timezone", defaultTimezone),
+	}
+
+	switch report.GroupBy {
+	case db.WorklogByUser, db.WorklogByTask, db.WorklogByStatus, db.WorklogByDay:
+	default:
+		return s.queryError(ctx, "group_by", "oneof", "group_by must be one of: user task status day")
+	}
+
+	// Дни считает Postgres, ему неизвестен часовой пояс Local
+	loc, err := time.LoadLocation(report.Timezone)
+	if err != nil || report.Timezone == "Local" {
+		return s.queryError(ctx, "timezone", "timezone", "Unknown time zone")
+	}
+
+	filter := db.WorklogFilter{UserID: ctx.Query("user_id")}
+	if len(filter.UserID) > maxUserID {
+		return s.queryError(ctx, "user_id", "max", "user_id is too long")
+	}
+	if taskID := ctx.Query("task_id"); taskID != "" {
+		if filter.TaskID, err = strconv.ParseInt(taskID, 10, 64); err != nil || filter.TaskID < 1 {
+			return s.queryError(ctx, "task_id", "integer", "task_id must be a positive integer")
+		}
+	}
+	if status := ctx.Query("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	if filter.From, err = parseBound(ctx.Query("from"), loc, false); err != nil {
+		return s.queryError(ctx, "from", "datetime", "from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.To, err = parseBound(ctx.Query("to"), loc, true); err != nil {
+		return s.queryError(ctx, "to", "datetime", "to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return s.queryError(ctx, "to", "gtfield", "to must be after from")
+	}
+	report.From, report.To = filter.From, filter.To
+
+	report.Rows, err = s.repo.ReportWorklogs(ctx.UserContext(), report.GroupBy, filter, report.Timezone)
+	if err != nil {
+		s.logger(ctx).Errorf("Error reporting worklogs: %v", zap.Error(err))
+		return dto.InternalServerError(ctx)
+	}
+	for _, row := range report.Rows {
+		report.TotalSeconds += row.Seconds
+	}
+
+	responce := dto.Response{
+		Status: "success",
+		Data:   report,
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(responce)
+}
+
+// parseBound - граница периода отчета: время RFC 3339 или дата в часовом поясе loc.
+// Дата окончания включается в период целиком
+func parseBound(value string, loc *time.Location, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+// parseBody - разбирает и проверяет тело запроса. При ошибке отправляет ответ
+// и возвращает false
+func (s *service) parseBody(ctx *fiber.Ctx, req any) (bool, error) {
+	if err := ctx.BodyParser(req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return false, dto.BadResponseError(ctx, dto.FieldBadFormat, "Invalid request body")
+	}
+
+	if err := validator.Validate(ctx.UserContext(), req); err != nil {
+		s.logger(ctx).Errorf("Invalid request body: %v", zap.Error(err))
+		return false, dto.InvalidRequestError(ctx, err)
+	}
+
+	return true, nil
+}
+
+// checkUser - запрос с ключом API может вести учет времени только за пользователя ключа,
+// запрос с TOKEN - за любого пользователя. При отказе отправляет ответ и возвращает false
+func (s *service) checkUser(ctx *fiber.Ctx, userID string) (bool, error) {
+	caller := middleware.UserID(ctx)
+	if caller == "" || caller == userID {
+		return true, nil
+	}
+
+	s.logger(ctx).Warnf("Worklog of user %s requested with API key of user %s", userID, caller)
+	return false, dto.ForbiddenError(ctx, "user_id does not match the API key user")
+}
+
+// queryError - ответ о неверном параметре строки запроса
+func (s *service) queryError(ctx *fiber.Ctx, field, rule, message string) error {
+	s.logger(ctx).Errorf("Invalid query parameter %s", field)
+	return dto.ValidationError(ctx, "Invalid query parameters", []dto.FieldError{{
+		In:      "query",
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+	}})
+}
+
+// repoError - преобразует ошибку репозитория в ответ
+func (s *service) repoError(ctx *fiber.Ctx, err error, msg, notFound, conflict string) error {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return dto.NotFoundError(ctx, notFound)
+	case errors.Is(err, db.ErrConflict):
+		return dto.ConflictError(ctx, conflict)
+	}
+
+	s.logger(ctx).Errorf("%s: %v", msg, zap.Error(err))
+	return dto.InternalServerError(ctx)
+}
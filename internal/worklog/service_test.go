@@ -0,0 +1,127 @@
+package worklog
+
+import (
+	"context"
+	"net/http/httptest"
+	"restapi/internal/api/middleware"
+	"restapi/internal/auth"
+	"restapi/internal/repo/db"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const token = "secret"
+
+// fakeRepo запоминает пользователей, за которых ведется учет. Остальные методы
+// хранилища не реализованы
+type fakeRepo struct {
+	db.WorklogRepository
+
+	started []string
+	// deletedBy - userID, с которым вызывалось удаление
+	deletedBy []string
+}
+
+func (r *fakeRepo) StartWorklog(ctx context.Context, taskID int64, userID, description string) (*db.Worklog, error) {
+	r.started = append(r.started, userID)
+	return &db.Worklog{ID: 1, TaskID: taskID, UserID: userID}, nil
+}
+
+func (r *fakeRepo) DeleteWorklog(ctx context.Context, taskID, id int64, userID string) error {
+	r.deletedBy = append(r.deletedBy, userID)
+	return nil
+}
+
+// fakeKeys - ключ API пользователя alice
+type fakeKeys struct {
+	hash string
+}
+
+func (k fakeKeys) GetAPIKeyUser(ctx context.Context, hash string) (string, error) {
+	if hash != k.hash {
+		return "", errors.Wrap(db.ErrNotFound, "API key not found")
+	}
+	return "alice", nil
+}
+
+func newTestApp(t *testing.T, repo *fakeRepo) (*fiber.App, string) {
+	t.Helper()
+
+	key, _, hash, err := auth.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := zap.NewNop().Sugar()
+	svc := NewService(log, repo)
+
+	app := fiber.New()
+	app.Use(middleware.Autorization(log, auth.NewAuthenticator(func() string { return token }, fakeKeys{hash: hash})))
+	app.Post("/tasks/:id/worklogs/start", svc.StartWorklog)
+	app.Delete("/tasks/:id/worklogs/:worklog_id", svc.DeleteWorklog)
+
+	return app, key
+}
+
+func TestWorklogCallerCheck(t *testing.T) {
+	repo := &fakeRepo{}
+	app, key := newTestApp(t, repo)
+
+	tests := []struct {
+		name   string
+		secret string
+		userID string
+		want   int
+	}{
+		{name: "own worklog", secret: key, userID: "alice", want: fiber.StatusCreated},
+		{name: "other user", secret: key, userID: "bob", want: fiber.StatusForbidden},
+		{name: "token for any user", secret: token, userID: "bob", want: fiber.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/tasks/7/worklogs/start", strings.NewReader(`{"user_id":"`+tt.userID+`"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.secret)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	if want := []string{"alice", "bob"}; !slices.Equal(repo.started, want) {
+		t.Errorf("timers started for %v, want %v", repo.started, want)
+	}
+}
+
+func TestDeleteWorklogOwner(t *testing.T) {
+	repo := &fakeRepo{}
+	app, key := newTestApp(t, repo)
+
+	for _, secret := range []string{key, token} {
+		req := httptest.NewRequest(fiber.MethodDelete, "/tasks/7/worklogs/1", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+secret)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// С ключом удаляется только запись его пользователя, с TOKEN - любая
+	if !slices.Equal(repo.deletedBy, []string{"alice", ""}) {
+		t.Errorf("delete called with users %q, want [alice \"\"]", repo.deletedBy)
+	}
+}
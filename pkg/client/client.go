@@ -0,0 +1,289 @@
+// Package client - клиент REST API задач для Go.
+//
+//	c, err := client.New("https://tasks.example.com", client.WithToken(token))
+//	id, err := c.CreateTask(ctx, client.CreateTaskRequest{Title: "...", Description: "...", Status: client.StatusNew})
+//	for task, err := range c.AllTasks(ctx, client.ListOptions{Statuses: []string{client.StatusNew}}) {
+//		...
+//	}
+//
+// Ошибки API возвращаются как *Error с кодом из поля error ответа.
+// Ответы 429 и 5xx, а также сетевые ошибки повторяются по RetryPolicy
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultTimeout - ограничение одного HTTP-запроса клиента по умолчанию
+const defaultTimeout = 30 * time.Second
+
+// RetryPolicy - повтор запросов при 429, 5xx и сетевых ошибках с экспоненциальной
+// задержкой. POST повторяется только после 429: лимит запросов отклоняет запрос
+// до обработчика, а повтор после 5xx мог бы создать задачу дважды
+type RetryPolicy struct {
+	// MaxAttempts - число попыток вместе с первой, 1 отключает повторы
+	MaxAttempts int
+	// BaseDelay - задержка перед первым повтором, дальше удваивается
+	BaseDelay time.Duration
+	// MaxDelay - наибольшая задержка. Если Retry-After ответа больше, запрос не повторяется
+	MaxDelay time.Duration
+}
+
+// DefaultRetry - политика повторов по умолчанию
+var DefaultRetry = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// Client - клиент REST API задач, безопасен для одновременного использования
+type Client struct {
+	baseURL   string
+	token     string
+	http      *http.Client
+	retry     RetryPolicy
+	userAgent string
+}
+
+// Option - настройка клиента
+type Option func(*Client)
+
+// WithToken задает токен для заголовка Authorization: Bearer
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient задает HTTP-клиент, например с настройками TLS или прокси
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithRetry задает политику повторов
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithUserAgent задает заголовок User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New создает клиент для API по адресу baseURL, например https://tasks.example.com
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, errors.Errorf("invalid base URL %q: expected http(s)://host", baseURL)
+	}
+
+	c := &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		http:      &http.Client{Timeout: defaultTimeout},
+		retry:     DefaultRetry,
+		userAgent: "restapi-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+
+	return c, nil
+}
+
+// response - общий вид ответа API
+type response struct {
+	Status string          `json:"status"`
+	Error  *Error          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// do выполняет запрос с повторами и разбирает data ответа в out, если out не nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, target, payload)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.retry.MaxAttempts || method == http.MethodPost {
+				return err
+			}
+			if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode < http.StatusBadRequest {
+			return decodeData(resp, out)
+		}
+
+		apiErr := decodeError(resp)
+		if attempt >= c.retry.MaxAttempts || !retryable(method, resp.StatusCode) {
+			return apiErr
+		}
+
+		delay := c.backoff(attempt)
+		if apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > c.retry.MaxDelay {
+				return apiErr
+			}
+			delay = apiErr.RetryAfter
+		}
+		if err := c.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// result - прочитанный ответ
+type result struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// send отправляет одну попытку запроса и читает ответ целиком
+func (c *Client) send(ctx context.Context, method, target string, payload []byte) (*result, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", method, req.URL.Path)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s %s response", method, req.URL.Path)
+	}
+
+	return &result{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
+
+// decodeData разбирает data успешного ответа
+func decodeData(resp *result, out any) error {
+	if out == nil {
+		return nil
+	}
+
+	var r response
+	if err := json.Unmarshal(resp.Body, &r); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	if err := json.Unmarshal(r.Data, out); err != nil {
+		return errors.Wrap(err, "failed to decode response data")
+	}
+
+	return nil
+}
+
+// decodeError собирает *Error из ответа с ошибкой. Ответ не в формате API,
+// например от прокси, дает ошибку без кода
+func decodeError(resp *result) *Error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	var r response
+	if err := json.Unmarshal(resp.Body, &r); err == nil && r.Error != nil {
+		apiErr.Code = r.Error.Code
+		apiErr.Desc = r.Error.Desc
+		apiErr.Fields = r.Error.Fields
+	} else {
+		apiErr.Desc = http.StatusText(resp.StatusCode)
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
+
+// retryable - можно ли повторить запрос после ответа с кодом status
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	return status >= http.StatusInternalServerError && method != http.MethodPost
+}
+
+// backoff - задержка перед повтором после попытки attempt: экспонента со случайной
+// половиной, чтобы клиенты не повторяли запросы одновременно
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.retry.MaxDelay {
+		delay = c.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// wait ждет delay или отмены ctx
+func (c *Client) wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
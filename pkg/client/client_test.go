@@ -0,0 +1,421 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"restapi/internal/api"
+	"restapi/internal/auth"
+	"restapi/internal/board"
+	"restapi/internal/config"
+	"restapi/internal/gql"
+	"restapi/internal/health"
+	"restapi/internal/ratelimit"
+	"restapi/internal/recurrence"
+	"restapi/internal/reminder"
+	"restapi/internal/repo/db"
+	"restapi/internal/service"
+	"restapi/internal/stream"
+	"restapi/internal/webhook"
+	"restapi/internal/worklog"
+	"restapi/pkg/client"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const token = "secret"
+
+// fakeRepo - задачи в памяти. err - ошибка всех запросов к хранилищу, как при отказе БД
+type fakeRepo struct {
+	db.Repository
+
+	mu      sync.Mutex
+	tasks   []*db.Task
+	err     error
+	creates int
+}
+
+func (r *fakeRepo) CreateTask(ctx context.Context, task db.Task) (*db.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.creates++
+	if r.err != nil {
+		return nil, r.err
+	}
+	task.ID = int64(len(r.tasks) + 1)
+	r.tasks = append(r.tasks, &task)
+	return &task, nil
+}
+
+func (r *fakeRepo) GetTask(ctx context.Context, id int64) (*db.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, task := range r.tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return nil, errors.Wrap(db.ErrNotFound, "task not found")
+}
+
+func (r *fakeRepo) GetAllTasks(ctx context.Context, filter db.TaskFilter, limit int, offset int) ([]*db.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	var tasks []*db.Task
+	for _, task := range r.tasks {
+		if len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, task.Status) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks[min(offset, len(tasks)):min(offset+limit, len(tasks))], nil
+}
+
+// testServer - httptest-сервер с настоящим роутером API и счетчиком запросов
+type testServer struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+// newTestServer запускает роутер API над repo. limiter nil - без лимита запросов
+func newTestServer(t *testing.T, repo *fakeRepo, limiter *ratelimit.Limiter) *testServer {
+	t.Helper()
+
+	log := zap.NewNop().Sugar()
+	svc := service.NewService(repo)
+	routers := &api.Routers{
+		Service:    svc,
+		Webhook:    webhook.NewService(log, nil),
+		Recurrence: recurrence.NewService(log, nil),
+		Reminder:   reminder.NewService(log, nil),
+		Worklog:    worklog.NewService(log, nil),
+		Board:      board.NewService(log, nil),
+		Stream:     stream.NewService(log, stream.NewHub(log, nil, config.Stream{})),
+		GraphQL:    gql.NewService(log, svc),
+		Health:     health.NewChecker(log, config.Health{}),
+		Auth:       auth.NewAuthenticator(func() string { return token }, nil),
+		RateLimit:  limiter,
+		LogLevel:   zap.NewAtomicLevel(),
+	}
+
+	app, err := api.NewRouters(routers, func() config.Rest { return config.Rest{} }, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &testServer{}
+	handler := adaptor.FiberApp(app)
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// newClient создает клиент с короткими задержками повторов
+func newClient(t *testing.T, ts *testServer, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	opts = append([]client.Option{
+		client.WithToken(token),
+		client.WithRetry(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}),
+	}, opts...)
+
+	c, err := client.New(ts.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestCreateAndGetTask(t *testing.T) {
+	ts := newTestServer(t, &fakeRepo{}, nil)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	id, err := c.CreateTask(ctx, client.CreateTaskRequest{Title: "Buy milk", Description: "2 liters", Status: client.StatusNew})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := c.GetTask(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != id || task.Title != "Buy milk" || task.Status != client.StatusNew {
+		t.Errorf("task = %+v", task)
+	}
+
+	if _, err := c.GetTask(ctx, id+1); !client.IsNotFound(err) {
+		t.Errorf("get unknown task: err = %v, want not found", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts := newTestServer(t, &fakeRepo{}, nil)
+	ctx := context.Background()
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := newClient(t, ts, client.WithToken("wrong")).GetTask(ctx, 1)
+
+		var apiErr *client.Error
+		if !client.IsUnauthorized(err) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("err = %v, want 401 %s", err, client.CodeUnauthorized)
+		}
+		if apiErr.RequestID == "" {
+			t.Error("error has no request id")
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := newClient(t, ts).CreateTask(ctx, client.CreateTaskRequest{Description: "no title", Status: client.StatusNew})
+
+		var apiErr *client.Error
+		if !client.IsValidation(err) || !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want validation error", err)
+		}
+		if apiErr.Code != client.CodeIncorrect {
+			t.Errorf("code = %q, want %s", apiErr.Code, client.CodeIncorrect)
+		}
+		if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "title" || apiErr.Fields[0].Rule != "required" {
+			t.Errorf("fields = %+v, want title required", apiErr.Fields)
+		}
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		_, err := newClient(t, ts).CreateTask(ctx, client.CreateTaskRequest{Title: "t", Description: "d", Status: "archived"})
+
+		var apiErr *client.Error
+		if !client.IsValidation(err) || !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want validation error", err)
+		}
+		if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "status" || apiErr.Fields[0].Rule != "oneof" {
+			t.Errorf("fields = %+v, want status oneof", apiErr.Fields)
+		}
+	})
+
+	t.Run("WIP limit", func(t *testing.T) {
+		full := newTestServer(t, &fakeRepo{err: errors.Wrap(db.ErrWIPLimit, "column new has 2 of 2 tasks")}, nil)
+		_, err := newClient(t, full).CreateTask(ctx, client.CreateTaskRequest{Title: "t", Description: "d", Status: client.StatusNew})
+
+		var apiErr *client.Error
+		if !client.IsConflict(err) || !errors.As(err, &apiErr) || apiErr.Code != client.CodeConflict {
+			t.Fatalf("err = %v, want 409 %s", err, client.CodeConflict)
+		}
+	})
+}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	// Одна запись в корзине, следующая появляется через 50 мс: второй запрос
+	// получает 429 с Retry-After: 1 и проходит после ожидания
+	limiter, err := ratelimit.NewLimiter(zap.NewNop().Sugar(), ratelimit.NewMemoryStore(), config.RateLimit{
+		ReadRate: 100, ReadBurst: 100, WriteRate: 20, WriteBurst: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeRepo{}
+	ts := newTestServer(t, repo, limiter)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := c.CreateTask(ctx, client.CreateTaskRequest{Title: "t", Description: "d", Status: client.StatusNew}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := ts.requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3: the rate limited POST is retried once", got)
+	}
+	if repo.creates != 2 {
+		t.Errorf("tasks created = %d, want 2", repo.creates)
+	}
+}
+
+func TestRateLimitRetryAfterTooLong(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(zap.NewNop().Sugar(), ratelimit.NewMemoryStore(), config.RateLimit{
+		ReadRate: 100, ReadBurst: 100, WriteRate: 0.01, WriteBurst: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, &fakeRepo{}, limiter)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	req := client.CreateTaskRequest{Title: "t", Description: "d", Status: client.StatusNew}
+	if _, err := c.CreateTask(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retry-After больше MaxDelay: ошибка возвращается сразу
+	_, err = c.CreateTask(ctx, req)
+
+	var apiErr *client.Error
+	if !client.IsRateLimited(err) || !errors.As(err, &apiErr) || apiErr.RetryAfter <= 2*time.Second {
+		t.Fatalf("err = %v, want rate limited with long Retry-After", err)
+	}
+	if got := ts.requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestServerErrorRetry(t *testing.T) {
+	repo := &fakeRepo{err: errors.New("connection refused")}
+	ts := newTestServer(t, repo, nil)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	// Повтор POST после 500 мог бы создать задачу дважды
+	_, err := c.CreateTask(ctx, client.CreateTaskRequest{Title: "t", Description: "d", Status: client.StatusNew})
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("create: err = %v, want 500", err)
+	}
+	if got := ts.requests.Load(); got != 1 || repo.creates != 1 {
+		t.Errorf("create: requests = %d, repository calls = %d, want no retry", got, repo.creates)
+	}
+
+	// GET повторяется до MaxAttempts
+	ts.requests.Store(0)
+	if _, err := c.GetTask(ctx, 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("get: err = %v, want 500", err)
+	}
+	if got := ts.requests.Load(); got != 3 {
+		t.Errorf("get: requests = %d, want 3 attempts", got)
+	}
+}
+
+func TestAllTasks(t *testing.T) {
+	newRepo := func(n int) *fakeRepo {
+		repo := &fakeRepo{}
+		for i := 1; i <= n; i++ {
+			status := client.StatusNew
+			if i%2 == 0 {
+				status = client.StatusDone
+			}
+			repo.tasks = append(repo.tasks, &db.Task{ID: int64(i), Title: "t", Status: status})
+		}
+		return repo
+	}
+
+	tests := []struct {
+		name     string
+		tasks    int
+		opts     client.ListOptions
+		want     []int64
+		requests int32
+	}{
+		{name: "partial last page", tasks: 5, opts: client.ListOptions{Limit: 2}, want: []int64{1, 2, 3, 4, 5}, requests: 3},
+		// Полная последняя страница: следующая пуста, API отвечает на нее 400 FIELD_NOT_FOUND
+		{name: "full last page", tasks: 4, opts: client.ListOptions{Limit: 2}, want: []int64{1, 2, 3, 4}, requests: 3},
+		{name: "no tasks", opts: client.ListOptions{Limit: 2}, want: nil, requests: 1},
+		{name: "from page", tasks: 5, opts: client.ListOptions{Page: 2, Limit: 2}, want: []int64{3, 4, 5}, requests: 2},
+		{name: "filter", tasks: 5, opts: client.ListOptions{Limit: 2, Statuses: []string{client.StatusDone}}, want: []int64{2, 4}, requests: 2},
+		{name: "default limit", tasks: 5, want: []int64{1, 2, 3, 4, 5}, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, newRepo(tt.tasks), nil)
+			c := newClient(t, ts)
+
+			var ids []int64
+			for task, err := range c.AllTasks(context.Background(), tt.opts) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, task.ID)
+			}
+
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("tasks = %v, want %v", ids, tt.want)
+			}
+			if got := ts.requests.Load(); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestAllTasksStops(t *testing.T) {
+	repo := &fakeRepo{}
+	for i := 1; i <= 5; i++ {
+		repo.tasks = append(repo.tasks, &db.Task{ID: int64(i), Title: "t", Status: client.StatusNew})
+	}
+	ts := newTestServer(t, repo, nil)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	// Обход прерван на первой странице: следующая не запрашивается
+	for task, err := range c.AllTasks(ctx, client.ListOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.ID == 2 {
+			break
+		}
+	}
+	if got := ts.requests.Load(); got != 1 {
+		t.Errorf("requests after break = %d, want 1", got)
+	}
+
+	// Ошибка заканчивает обход
+	repo.err = errors.New("connection refused")
+	var errs int
+	for _, err := range c.AllTasks(ctx, client.ListOptions{Limit: 2}) {
+		if err == nil {
+			t.Fatal("task yielded from a failed page")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("errors = %d, want 1", errs)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	ts := newTestServer(t, &fakeRepo{err: errors.New("connection refused")}, nil)
+	c := newClient(t, ts, client.WithRetry(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute}))
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := c.GetTask(ctx, 1); !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("during retry delay", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := c.GetTask(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("request returned after %v, want the retry delay interrupted", elapsed)
+		}
+	})
+}
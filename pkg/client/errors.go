@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Коды ошибок API, совпадают с кодами в поле error.code ответа
+const (
+	CodeBadFormat    = "FIELD_BADFORMAT"
+	CodeIncorrect    = "FIELD_INCORRECT"
+	CodeNotFound     = "FIELD_NOT_FOUND"
+	CodeUnavailable  = "SERVICE_UNAVAILABLE"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeRateLimited  = "RATE_LIMITED"
+	CodeConflict     = "CONFLICT"
+)
+
+// Error - ошибка, которую вернул API
+type Error struct {
+	// StatusCode - HTTP-статус ответа
+	StatusCode int `json:"-"`
+	// Code - код из error.code, пустой, если ответ не в формате API
+	Code   string       `json:"code"`
+	Desc   string       `json:"desc"`
+	Fields []FieldError `json:"fields,omitempty"`
+	// RequestID - X-Request-ID ответа для поиска запроса в журнале сервиса
+	RequestID string `json:"-"`
+	// RetryAfter - через сколько можно повторить запрос по заголовку Retry-After
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError - нарушение правила валидации в поле запроса
+type FieldError struct {
+	// In - часть запроса: path, query или body
+	In      string `json:"in,omitempty"`
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("restapi: %d", e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	msg += ": " + e.Desc
+	for _, f := range e.Fields {
+		msg += "; " + f.Message
+	}
+
+	return msg
+}
+
+// IsNotFound - ресурс не найден
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsValidation - запрос отклонен проверкой, подробности в Fields
+func IsValidation(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+// IsUnauthorized - токен не задан или неверен
+func IsUnauthorized(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == CodeUnauthorized
+}
+
+// IsForbidden - ключ API не дает доступа к данным другого пользователя
+func IsForbidden(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+}
+
+// IsRateLimited - превышен лимит запросов и повторы не помогли
+func IsRateLimited(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == CodeRateLimited
+}
+
+// IsConflict - запрос противоречит текущему состоянию, например колонка доски заполнена
+func IsConflict(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
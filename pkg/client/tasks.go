@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Статусы задач
+const (
+	StatusNew        = "new"
+	StatusInProgress = "in_progress"
+	StatusDone       = "done"
+)
+
+// Порядок списка задач
+const (
+	SortCreatedAt = "created_at"
+	// SortRank - по колонкам статусов, внутри колонки по Rank
+	SortRank = "rank"
+)
+
+// MaxLimit - наибольший размер страницы списка задач
+const MaxLimit int = 100
+
+// Task - задача
+type Task struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// RecurrenceID - правило повторения, по которому создана задача
+	RecurrenceID *int64 `json:"recurrence_id,omitempty"`
+	// DueAt - срок задачи
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// Rank - ключ порядка задачи в колонке статуса, сравнивается побайтно
+	Rank      string    `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTaskRequest - данные для создания задачи
+type CreateTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// DueAt - срок задачи, необязательный
+	DueAt *time.Time `json:"due_at,omitempty"`
+}
+
+// UpdateTaskRequest - новые значения полей задачи
+type UpdateTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// DueAt - новый срок, nil - срок не меняется
+	DueAt *time.Time `json:"due_at,omitempty"`
+}
+
+// ListOptions - страница и фильтр списка задач
+type ListOptions struct {
+	// Page - номер страницы, начиная с 1
+	Page int
+	// Limit - размер страницы до MaxLimit, 0 - размер по умолчанию сервиса
+	Limit int
+	// Statuses - только задачи с этими статусами, пустой - все
+	Statuses []string
+	// Sort - порядок списка: SortCreatedAt (по умолчанию) или SortRank
+	Sort string
+}
+
+// query - параметры запроса списка
+func (o ListOptions) query() url.Values {
+	query := url.Values{}
+	if o.Page > 0 {
+		query.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if len(o.Statuses) > 0 {
+		query.Set("status", strings.Join(o.Statuses, ","))
+	}
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+
+	return query
+}
+
+// MoveTaskRequest - новое место задачи на доске. Без соседей задача встает в конец колонки
+type MoveTaskRequest struct {
+	// Status - колонка, пустой - текущая колонка задачи
+	Status string `json:"status,omitempty"`
+	// AfterID - задача, под которой встает перемещаемая
+	AfterID *int64 `json:"after_id,omitempty"`
+	// BeforeID - задача, над которой встает перемещаемая
+	BeforeID *int64 `json:"before_id,omitempty"`
+}
+
+// created - ответ на создание
+type created struct {
+	ID int64 `json:"id"`
+}
+
+// CreateTask создает задачу и возвращает ее id
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest) (int64, error) {
+	var out created
+	if err := c.do(ctx, http.MethodPost, "/v1/tasks", nil, req, &out); err != nil {
+		return 0, err
+	}
+
+	return out.ID, nil
+}
+
+// GetTask возвращает задачу по id
+func (c *Client) GetTask(ctx context.Context, id int64) (Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodGet, taskPath(id), nil, nil, &task); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// ListTasks возвращает одну страницу задач. Страница за последней пуста
+func (c *Client) ListTasks(ctx context.Context, opts ListOptions) ([]Task, error) {
+	var tasks []Task
+	err := c.do(ctx, http.MethodGet, "/v1/tasks", opts.query(), nil, &tasks)
+	if err != nil {
+		// Пустую страницу API возвращает как 400 FIELD_NOT_FOUND
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.Code == CodeNotFound {
+			return []Task{}, nil
+		}
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// AllTasks обходит все задачи, начиная со страницы opts.Page, и запрашивает
+// следующую страницу по мере обхода. Без opts.Limit страницы берутся по MaxLimit.
+// После ошибки обход заканчивается. Задачи, созданные или удаленные во время обхода,
+// могут быть пропущены или встретиться дважды: страницы отсчитываются от начала списка
+func (c *Client) AllTasks(ctx context.Context, opts ListOptions) iter.Seq2[Task, error] {
+	return func(yield func(Task, error) bool) {
+		opts := opts
+		if opts.Page < 1 {
+			opts.Page = 1
+		}
+		if opts.Limit <= 0 {
+			opts.Limit = MaxLimit
+		}
+
+		for ; ; opts.Page++ {
+			tasks, err := c.ListTasks(ctx, opts)
+			if err != nil {
+				yield(Task{}, err)
+				return
+			}
+
+			for _, task := range tasks {
+				if !yield(task, nil) {
+					return
+				}
+			}
+
+			if len(tasks) < opts.Limit {
+				return
+			}
+		}
+	}
+}
+
+// UpdateTask заменяет все поля задачи
+func (c *Client) UpdateTask(ctx context.Context, id int64, req UpdateTaskRequest) error {
+	return c.do(ctx, http.MethodPut, taskPath(id), nil, req, nil)
+}
+
+// MoveTask переносит задачу на доске и возвращает ее с новым Rank. Переполненная
+// колонка или соседи не из нее - ошибка *Error со статусом 409
+func (c *Client) MoveTask(ctx context.Context, id int64, req MoveTaskRequest) (Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPost, taskPath(id)+"/move", nil, req, &task); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// DeleteTask удаляет задачу
+func (c *Client) DeleteTask(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, taskPath(id), nil, nil, nil)
+}
+
+func taskPath(id int64) string {
+	return "/v1/tasks/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,4 @@
+// Package pb содержит код, сгенерированный из proto/ (buf, protoc-gen-go, protoc-gen-go-grpc)
+package pb
+
+//go:generate sh -c "cd ../../proto && buf generate"
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+// FieldError - нарушение правила валидации в поле
+type FieldError struct {
+	// Field - путь к полю по именам JSON, например events[0]
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Errors - все нарушения правил при проверке структуры
+type Errors struct {
+	errs validator.ValidationErrors
+}
+
+// Error - нарушения на английском через точку с запятой
+func (e *Errors) Error() string {
+	fields := e.Fields("")
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Fields возвращает нарушения с сообщениями на языке из заголовка Accept-Language.
+// Неподдерживаемые языки заменяются английским
+func (e *Errors) Fields(acceptLanguage string) []FieldError {
+	trans := Translator(acceptLanguage)
+
+	fields := make([]FieldError, 0, len(e.errs))
+	for _, fe := range e.errs {
+		field := fieldPath(fe)
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(trans, field, fe.Tag(), fe.Param(), kind(fe)),
+		})
+	}
+
+	return fields
+}
+
+// fieldPath - путь к полю без имени проверяемой структуры
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+
+	return path
+}
+
+// kind - вид значения для правил, смысл которых зависит от типа: длина строки,
+// число элементов или само число
+func kind(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return kindItems
+	default:
+		return kindNumber
+	}
+}
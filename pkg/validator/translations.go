@@ -0,0 +1,178 @@
+package validator
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Виды значений для сообщений min, max, len, lt, lte, gt, gte
+const (
+	kindString = "string"
+	kindItems  = "items"
+	kindNumber = "number"
+)
+
+// unknownRule - ключ сообщения для правил без перевода
+const unknownRule = "unknown"
+
+// messages - сообщения по языкам. Ключ - правило или правило-вид значения,
+// {0} - поле, {1} - параметр правила
+var messages = map[string]map[string]string{
+	"en": {
+		"required":   "{0} is required",
+		"oneof":      "{0} must be one of: {1}",
+		"url":        "{0} must be a valid URL",
+		"email":      "{0} must be a valid email address",
+		"tag":        "{0} has invalid format",
+		"min-string": "{0} must be at least {1} characters long",
+		"min-items":  "{0} must contain at least {1} item(s)",
+		"min-number": "{0} must be {1} or greater",
+		"max-string": "{0} must be at most {1} characters long",
+		"max-items":  "{0} must contain at most {1} item(s)",
+		"max-number": "{0} must be {1} or less",
+		"len-string": "{0} must be exactly {1} characters long",
+		"len-items":  "{0} must contain exactly {1} item(s)",
+		"len-number": "{0} must be equal to {1}",
+		"lt-string":  "{0} must be shorter than {1} characters",
+		"lt-items":   "{0} must contain fewer than {1} item(s)",
+		"lt-number":  "{0} must be less than {1}",
+		"lte-string": "{0} must be at most {1} characters long",
+		"lte-items":  "{0} must contain at most {1} item(s)",
+		"lte-number": "{0} must be {1} or less",
+		"gt-string":  "{0} must be longer than {1} characters",
+		"gt-items":   "{0} must contain more than {1} item(s)",
+		"gt-number":  "{0} must be greater than {1}",
+		"gte-string": "{0} must be at least {1} characters long",
+		"gte-items":  "{0} must contain at least {1} item(s)",
+		"gte-number": "{0} must be {1} or greater",
+		unknownRule:  "{0} failed the {1} rule",
+	},
+	"ru": {
+		"required":   "Поле {0} обязательно",
+		"oneof":      "Поле {0} должно быть одним из: {1}",
+		"url":        "Поле {0} должно содержать корректный URL",
+		"email":      "Поле {0} должно содержать корректный email",
+		"tag":        "Поле {0} имеет неверный формат",
+		"min-string": "Длина поля {0} должна быть не меньше {1}",
+		"min-items":  "Поле {0} должно содержать не меньше {1} элементов",
+		"min-number": "Поле {0} должно быть не меньше {1}",
+		"max-string": "Длина поля {0} должна быть не больше {1}",
+		"max-items":  "Поле {0} должно содержать не больше {1} элементов",
+		"max-number": "Поле {0} должно быть не больше {1}",
+		"len-string": "Длина поля {0} должна быть равна {1}",
+		"len-items":  "Поле {0} должно содержать ровно {1} элементов",
+		"len-number": "Поле {0} должно быть равно {1}",
+		"lt-string":  "Длина поля {0} должна быть меньше {1}",
+		"lt-items":   "Поле {0} должно содержать меньше {1} элементов",
+		"lt-number":  "Поле {0} должно быть меньше {1}",
+		"lte-string": "Длина поля {0} должна быть не больше {1}",
+		"lte-items":  "Поле {0} должно содержать не больше {1} элементов",
+		"lte-number": "Поле {0} должно быть не больше {1}",
+		"gt-string":  "Длина поля {0} должна быть больше {1}",
+		"gt-items":   "Поле {0} должно содержать больше {1} элементов",
+		"gt-number":  "Поле {0} должно быть больше {1}",
+		"gte-string": "Длина поля {0} должна быть не меньше {1}",
+		"gte-items":  "Поле {0} должно содержать не меньше {1} элементов",
+		"gte-number": "Поле {0} должно быть не меньше {1}",
+		unknownRule:  "Поле {0} не прошло проверку {1}",
+	},
+}
+
+// universal - переводчики сообщений, английский используется по умолчанию
+var universal = newUniversal()
+
+func newUniversal() *ut.UniversalTranslator {
+	fallback := en.New()
+	uni := ut.New(fallback, fallback, ru.New())
+
+	for locale, texts := range messages {
+		trans, _ := uni.GetTranslator(locale)
+		for key, text := range texts {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	return uni
+}
+
+// Translator возвращает переводчик по заголовку Accept-Language с учетом весов q.
+// ru-RU сводится к ru, неподдерживаемые языки - к английскому
+func Translator(acceptLanguage string) ut.Translator {
+	trans, _ := universal.FindTranslator(languages(acceptLanguage)...)
+	return trans
+}
+
+// languages разбирает Accept-Language в список языков по убыванию веса
+func languages(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+
+	var parsed []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(value, 64); err == nil {
+				q = weight
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		base, _, _ := strings.Cut(tag, "-")
+		parsed = append(parsed, language{tag: strings.ToLower(base), q: q})
+	}
+
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].q > parsed[j].q
+	})
+
+	tags := make([]string, 0, len(parsed))
+	for _, l := range parsed {
+		tags = append(tags, l.tag)
+	}
+
+	return tags
+}
+
+// message - сообщение о нарушении правила rule с параметром param
+func message(trans ut.Translator, field, rule, param, kind string) string {
+	if msg, err := trans.T(rule+"-"+kind, field, param); err == nil {
+		return msg
+	}
+	if msg, err := trans.T(rule, field, param); err == nil {
+		return msg
+	}
+
+	msg, _ := trans.T(unknownRule, field, rule)
+	return msg
+}
+
+type languageKey struct{}
+
+// WithLanguage сохраняет в контексте заголовок Accept-Language для транспортов,
+// которые формируют ошибки вдали от запроса
+func WithLanguage(ctx context.Context, acceptLanguage string) context.Context {
+	return context.WithValue(ctx, languageKey{}, acceptLanguage)
+}
+
+// Language возвращает заголовок Accept-Language из контекста
+func Language(ctx context.Context) string {
+	acceptLanguage, _ := ctx.Value(languageKey{}).(string)
+	return acceptLanguage
+}
@@ -2,8 +2,9 @@ package validator
 
 import (
 	"context"
-	"errors"
+	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/go-playground/validator"
 )
@@ -12,16 +13,6 @@ import (
 
 var global *validator.Validate
 
-const (
-	ErrInvalidFormat      = "Invalid format"
-	ErrFieldRequired      = "Field is required"
-	ErrFieldExceedsMaxLen = "Field exceeds maximum length"
-	ErrFieldBelowMinLen   = "Field is below minimum length"
-	ErrFieldExceedsMaxVal = "Field exceeds maximum value"
-	ErrFieldBelowMinVal   = "Field is below minimum value"
-	ErrUnknownValidation  = "Unknown validation error"
-)
-
 func init() {
 	SetValidator(New())
 }
@@ -30,6 +21,15 @@ func New() *validator.Validate {
 	v := validator.New()
 	_ = v.RegisterValidation("tag", validateTag)
 
+	// В ошибках поля называются так же, как в JSON
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
 	return v
 }
 
@@ -46,38 +46,17 @@ func validateTag(fl validator.FieldLevel) bool {
 	return re.MatchString(fl.Field().String())
 }
 
+// Validate проверяет структуру и возвращает *Errors со всеми нарушенными правилами
 func Validate(ctx context.Context, structure any) error {
-	return parseValidationErrors(Validator().StructCtx(ctx, structure))
-}
-
-func parseValidationErrors(err error) error {
+	err := Validator().StructCtx(ctx, structure)
 	if err == nil {
 		return nil
 	}
 
 	vErrors, ok := err.(validator.ValidationErrors)
-	if !ok || len(vErrors) == 0 {
-		return nil
-	}
-
-	validationError := vErrors[0]
-	var validationErrorDescription string
-	switch validationError.Tag() {
-	case "tag":
-		validationErrorDescription = ErrInvalidFormat
-	case "required":
-		validationErrorDescription = ErrFieldRequired
-	case "max":
-		validationErrorDescription = ErrFieldExceedsMaxLen
-	case "min":
-		validationErrorDescription = ErrFieldBelowMinLen
-	case "lt", "lte":
-		validationErrorDescription = ErrFieldExceedsMaxVal
-	case "gt", "gte":
-		validationErrorDescription = ErrFieldBelowMinVal
-	default:
-		validationErrorDescription = ErrUnknownValidation
+	if !ok {
+		return err
 	}
 
-	return errors.New(validationErrorDescription + ": " + validationError.Namespace())
+	return &Errors{errs: vErrors}
 }
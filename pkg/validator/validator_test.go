@@ -0,0 +1,159 @@
+package validator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type testItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type testInput struct {
+	Title  string     `json:"title" validate:"required,min=3"`
+	Status []string   `json:"status" validate:"dive,oneof=new done"`
+	Tags   []string   `json:"tags" validate:"max=1"`
+	Tag    string     `json:"tag" validate:"omitempty,tag"`
+	Points int        `json:"points" validate:"gte=1"`
+	Email  string     `json:"email" validate:"omitempty,email"`
+	Items  []testItem `json:"items" validate:"dive"`
+	Code   string     `json:"code" validate:"omitempty,alpha"`
+}
+
+// invalidInput нарушает по одному правилу в каждом поле
+var invalidInput = testInput{
+	Title:  "ab",
+	Status: []string{"new", "closed"},
+	Tags:   []string{"a", "b"},
+	Tag:    "Bad tag",
+	Points: 0,
+	Email:  "alice",
+	Items:  []testItem{{Name: "ok"}, {}},
+	Code:   "42",
+}
+
+func validate(t *testing.T, input testInput) *Errors {
+	t.Helper()
+
+	err := Validate(context.Background(), input)
+	var vErrors *Errors
+	if !errors.As(err, &vErrors) {
+		t.Fatalf("Validate = %v, want *Errors", err)
+	}
+
+	return vErrors
+}
+
+func TestValidateValid(t *testing.T) {
+	input := testInput{Title: "Buy milk", Status: []string{"done"}, Tag: "#home", Points: 3, Email: "alice@example.com", Items: []testItem{{Name: "a"}}}
+	if err := Validate(context.Background(), input); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+}
+
+func TestFields(t *testing.T) {
+	want := []FieldError{
+		{Field: "title", Rule: "min", Param: "3", Message: "title must be at least 3 characters long"},
+		{Field: "status[1]", Rule: "oneof", Param: "new done", Message: "status[1] must be one of: new done"},
+		{Field: "tags", Rule: "max", Param: "1", Message: "tags must contain at most 1 item(s)"},
+		{Field: "tag", Rule: "tag", Message: "tag has invalid format"},
+		{Field: "points", Rule: "gte", Param: "1", Message: "points must be 1 or greater"},
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "items[1].name", Rule: "required", Message: "items[1].name is required"},
+		{Field: "code", Rule: "alpha", Message: "code failed the alpha rule"},
+	}
+
+	// Все нарушения сообщаются сразу, путь к полю - по именам JSON
+	got := validate(t, invalidInput).Fields("")
+	if !slices.Equal(got, want) {
+		t.Errorf("Fields:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestFieldsLanguage(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "title must be at least 3 characters long"},
+		{acceptLanguage: "ru", want: "Длина поля title должна быть не меньше 3"},
+		{acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8", want: "Длина поля title должна быть не меньше 3"},
+		{acceptLanguage: "de, ru;q=0.5", want: "Длина поля title должна быть не меньше 3"},
+		{acceptLanguage: "en;q=0.1, ru;q=0.8", want: "Длина поля title должна быть не меньше 3"},
+		{acceptLanguage: "ru;q=0, en", want: "title must be at least 3 characters long"},
+		{acceptLanguage: "fr", want: "title must be at least 3 characters long"},
+		{acceptLanguage: "*", want: "title must be at least 3 characters long"},
+	}
+
+	vErrors := validate(t, invalidInput)
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			if got := vErrors.Fields(tt.acceptLanguage)[0].Message; got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsRussian(t *testing.T) {
+	want := []string{
+		"Длина поля title должна быть не меньше 3",
+		"Поле status[1] должно быть одним из: new done",
+		"Поле tags должно содержать не больше 1 элементов",
+		"Поле tag имеет неверный формат",
+		"Поле points должно быть не меньше 1",
+		"Поле email должно содержать корректный email",
+		"Поле items[1].name обязательно",
+		"Поле code не прошло проверку alpha",
+	}
+
+	var got []string
+	for _, f := range validate(t, invalidInput).Fields("ru") {
+		got = append(got, f.Message)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("messages:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestErrorsError(t *testing.T) {
+	err := validate(t, testInput{Title: "Buy milk", Points: 0, Code: "42"})
+
+	want := "points must be 1 or greater; code failed the alpha rule"
+	if err.Error() != want {
+		t.Errorf("Error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "ru-RU", want: []string{"ru"}},
+		{header: "EN-us, ru;q=0.9", want: []string{"en", "ru"}},
+		{header: "en;q=0.2, ru;q=0.7, de", want: []string{"de", "ru", "en"}},
+		{header: "ru;q=0, *, en;q=bad", want: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		if got := languages(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("languages(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageContext(t *testing.T) {
+	ctx := WithLanguage(context.Background(), "ru")
+	if got := Language(ctx); got != "ru" {
+		t.Errorf("Language = %q, want ru", got)
+	}
+	if got := Language(context.Background()); got != "" {
+		t.Errorf("Language without value = %q, want empty", got)
+	}
+}